@@ -5,9 +5,12 @@ var (
 	ErrSuccess             = newError(0, "ok")
 	ErrBadRequest          = newError(400, "Bad Request")
 	ErrUnauthorized        = newError(401, "Unauthorized")
+	ErrForbidden           = newError(403, "Forbidden")
 	ErrNotFound            = newError(404, "Not Found")
+	ErrRequestTooLarge     = newError(413, "Request Entity Too Large")
 	ErrInternalServerError = newError(500, "Internal Server Error")
 
 	// more biz errors
 	ErrEmailAlreadyUse = newError(1001, "The email is already in use.")
+	ErrHoldingExists   = newError(1002, "The holding already exists.")
 )
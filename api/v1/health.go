@@ -0,0 +1,25 @@
+package v1
+
+// Health statuses. A component is up, down or skipped when it is not configured; the report is down if
+// a critical component is down, and degraded if any other component is.
+const (
+	HealthUp       = "up"
+	HealthDegraded = "degraded"
+	HealthDown     = "down"
+	HealthSkipped  = "skipped"
+)
+
+type ComponentHealth struct {
+	Status    string `json:"status" example:"up"`
+	Critical  bool   `json:"critical" example:"true"` // Whether the component being down fails the check
+	LatencyMs int64  `json:"latencyMs" example:"3"`
+	Detail    string `json:"detail,omitempty" example:"last successful run 2m0s ago"`
+	Error     string `json:"error,omitempty"`
+}
+
+// HealthResponse is returned as is, without the usual envelope, with status 503 when the report is down.
+type HealthResponse struct {
+	Status     string                     `json:"status" example:"up"`
+	CheckedAt  int64                      `json:"checkedAt" example:"1700000000000"`
+	Components map[string]ComponentHealth `json:"components"`
+}
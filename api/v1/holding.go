@@ -0,0 +1,38 @@
+package v1
+
+type CreateHoldingRequest struct {
+	Asset     string  `json:"asset" binding:"required,max=20" example:"BTC"`
+	Venue     string  `json:"venue" binding:"max=20" example:"LEDGER"` // Defaults to MANUAL
+	Quantity  float64 `json:"quantity" binding:"gt=0" example:"0.5"`
+	CostBasis float64 `json:"costBasis" binding:"gte=0" example:"30000"` // Average cost per unit in USDT, 0 if unknown
+}
+
+type UpdateHoldingRequest struct {
+	Quantity  float64 `json:"quantity" binding:"gt=0" example:"0.5"`
+	CostBasis float64 `json:"costBasis" binding:"gte=0" example:"30000"`
+}
+
+type Holding struct {
+	Id        uint    `json:"id"`
+	Asset     string  `json:"asset" example:"BTC"`
+	Venue     string  `json:"venue" example:"LEDGER"`
+	Quantity  float64 `json:"quantity" example:"0.5"`
+	CostBasis float64 `json:"costBasis" example:"30000"`
+	UpdatedAt int64   `json:"updatedAt" example:"1700000000000"`
+}
+type HoldingResponse struct {
+	Response
+	Data Holding
+}
+type ListHoldingsResponse struct {
+	Response
+	Data []Holding
+}
+
+type ImportHoldingsResponseData struct {
+	Imported int `json:"imported" example:"3"`
+}
+type ImportHoldingsResponse struct {
+	Response
+	Data ImportHoldingsResponseData
+}
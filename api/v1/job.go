@@ -0,0 +1,41 @@
+package v1
+
+type JobRun struct {
+	ID         uint   `json:"id" example:"42"`
+	Name       string `json:"name" example:"price_monitor"`
+	Trigger    string `json:"trigger" example:"schedule"` // schedule or manual
+	Status     string `json:"status" example:"succeeded"` // pending, running, succeeded, failed or timed_out
+	Instance   string `json:"instance" example:"task-7d9f-1-a1b2c3d4"`
+	CreatedAt  int64  `json:"createdAt" example:"1700000000000"`
+	StartedAt  int64  `json:"startedAt" example:"1700000000000"`
+	FinishedAt int64  `json:"finishedAt" example:"1700000042000"`
+	DurationMs int64  `json:"durationMs" example:"42000"`
+	Succeeded  int64  `json:"succeeded" example:"38"`
+	Failed     int64  `json:"failed" example:"2"`
+	Error      string `json:"error,omitempty"`
+}
+
+type Job struct {
+	Name         string  `json:"name" example:"price_monitor"`
+	Enabled      bool    `json:"enabled" example:"true"`
+	Paused       bool    `json:"paused" example:"false"`
+	Schedule     string  `json:"schedule" example:"every 5m0s"`
+	TimeoutMs    int64   `json:"timeoutMs" example:"300000"`
+	RunOnStartup bool    `json:"runOnStartup" example:"true"`
+	Singleton    bool    `json:"singleton" example:"true"`
+	LastRun      *JobRun `json:"lastRun"`
+}
+type ListJobsResponse struct {
+	Response
+	Data []Job
+}
+
+type ListJobRunsResponse struct {
+	Response
+	Data []JobRun
+}
+
+type TriggerJobResponse struct {
+	Response
+	Data JobRun
+}
@@ -0,0 +1,68 @@
+package v1
+
+type PaperPosition struct {
+	Exchange          string  `json:"exchange" example:"BINANCE"`
+	Symbol            string  `json:"symbol" example:"BTCUSDT"`
+	Quantity          float64 `json:"quantity" example:"0.02"`
+	EntryPrice        float64 `json:"entryPrice" example:"50025"`
+	LastPrice         float64 `json:"lastPrice" example:"52000"`
+	Value             float64 `json:"value" example:"1040"`
+	UnrealizedPnL     float64 `json:"unrealizedPnl" example:"39"` // Net of the entry fee
+	UnrealizedPercent float64 `json:"unrealizedPercent" example:"3.9"`
+	OpenedAt          int64   `json:"openedAt" example:"1700000000000"`
+}
+
+type PaperStrategyPerformance struct {
+	Strategy           string          `json:"strategy" example:"buy_the_dip"`
+	Signals            []string        `json:"signals" example:"price_drop"`
+	InitialCash        float64         `json:"initialCash" example:"10000"`
+	Cash               float64         `json:"cash" example:"8999"`
+	PositionsValue     float64         `json:"positionsValue" example:"1040"`
+	Equity             float64         `json:"equity" example:"10039"`
+	ReturnPercent      float64         `json:"returnPercent" example:"0.39"`
+	RealizedPnL        float64         `json:"realizedPnl" example:"0"`
+	UnrealizedPnL      float64         `json:"unrealizedPnl" example:"39"`
+	Fees               float64         `json:"fees" example:"1"`
+	Trades             int             `json:"trades" example:"1"`       // Fills
+	ClosedTrades       int             `json:"closedTrades" example:"0"` // Round trips
+	WinRate            float64         `json:"winRate" example:"0"`      // Percent of round trips with a profit
+	MaxDrawdownPercent float64         `json:"maxDrawdownPercent" example:"2.5"`
+	Positions          []PaperPosition `json:"positions"`
+	UpdatedAt          int64           `json:"updatedAt" example:"1700000000000"`
+}
+type GetPaperStrategyResponse struct {
+	Response
+	Data PaperStrategyPerformance
+}
+type ListPaperStrategiesResponse struct {
+	Response
+	Data []PaperStrategyPerformance
+}
+
+type PaperTrade struct {
+	Exchange    string  `json:"exchange" example:"BINANCE"`
+	Symbol      string  `json:"symbol" example:"BTCUSDT"`
+	Side        string  `json:"side" example:"buy"`
+	Quantity    float64 `json:"quantity" example:"0.02"`
+	Price       float64 `json:"price" example:"50025"` // Including slippage
+	MarketPrice float64 `json:"marketPrice" example:"50000"`
+	Fee         float64 `json:"fee" example:"1"`
+	RealizedPnL float64 `json:"realizedPnl" example:"0"`
+	Reason      string  `json:"reason" example:"price_drop"` // Entry signal, or take_profit, stop_loss or max_hold
+	Timestamp   int64   `json:"timestamp" example:"1700000000000"`
+}
+type ListPaperTradesResponse struct {
+	Response
+	Data []PaperTrade
+}
+
+type PaperEquityPoint struct {
+	Cash           float64 `json:"cash" example:"8999"`
+	PositionsValue float64 `json:"positionsValue" example:"1040"`
+	Equity         float64 `json:"equity" example:"10039"`
+	Timestamp      int64   `json:"timestamp" example:"1700000000000"`
+}
+type ListPaperEquityResponse struct {
+	Response
+	Data []PaperEquityPoint
+}
@@ -0,0 +1,39 @@
+package v1
+
+type PortfolioPosition struct {
+	Asset         string  `json:"asset" example:"BTC"`
+	Venue         string  `json:"venue" example:"BINANCE"`
+	Quantity      float64 `json:"quantity" example:"0.5"`
+	Price         float64 `json:"price" example:"60000"`
+	Value         float64 `json:"value" example:"30000"`
+	Allocation    float64 `json:"allocation" example:"75"`      // Percent of the portfolio value
+	CostBasis     float64 `json:"costBasis" example:"50000"`    // Manual holdings only, 0 if unknown
+	UnrealizedPnL float64 `json:"unrealizedPnl" example:"5000"` // 0 without a cost basis
+}
+type PortfolioPnL struct {
+	Days          int     `json:"days" example:"7"`
+	Change        float64 `json:"change" example:"-1200"`
+	ChangePercent float64 `json:"changePercent" example:"-3.85"`
+}
+type GetPortfolioResponseData struct {
+	TotalValue      float64             `json:"totalValue" example:"40000"`
+	Positions       []PortfolioPosition `json:"positions"`
+	PnL             []PortfolioPnL      `json:"pnl"`
+	PeakValue       float64             `json:"peakValue" example:"42000"`
+	DrawdownPercent float64             `json:"drawdownPercent" example:"4.76"`
+	Unpriced        []string            `json:"unpriced"` // Assets for which no price was found
+	Timestamp       int64               `json:"timestamp" example:"1700000000000"`
+}
+type GetPortfolioResponse struct {
+	Response
+	Data GetPortfolioResponseData
+}
+
+type PortfolioSnapshot struct {
+	Date       string  `json:"date" example:"2024-01-31"`
+	TotalValue float64 `json:"totalValue" example:"40000"`
+}
+type ListPortfolioSnapshotsResponse struct {
+	Response
+	Data []PortfolioSnapshot
+}
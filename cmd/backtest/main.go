@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"klineio/internal/repository"
+	"klineio/internal/service"
+	"klineio/pkg/config"
+	"klineio/pkg/log"
+
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+var (
+	cfg        = pflag.StringP("config", "c", "config/local.yml", "config file path.")
+	from       = pflag.String("from", "", "start date (YYYY-MM-DD, UTC), defaults to 30 days ago")
+	to         = pflag.String("to", "", "end date (YYYY-MM-DD, UTC, exclusive), defaults to now")
+	exchanges  = pflag.StringSlice("exchanges", []string{"BINANCE", "OKEX"}, "exchanges to replay")
+	symbols    = pflag.StringSlice("symbols", nil, "symbols to replay, defaults to every symbol with stored 1m candles")
+	threshold  = pflag.Float64("threshold", 0, "price drop threshold as a fraction, overrides price_monitor.default_threshold")
+	step       = pflag.Duration("step", 5*time.Minute, "interval between simulated price monitor runs")
+	cooldown   = pflag.Duration("cooldown", 0, "minimum time between alerts of one signal for a symbol, 0 alerts on every run like the monitor")
+	format     = pflag.String("format", "table", "output format: table or csv")
+	out        = pflag.StringP("out", "o", "", "file the alerts are written to, defaults to stdout")
+	summaryOut = pflag.String("summary-out", "", "file the summary is written to in the output format, defaults to after the alerts (table) or stderr (csv)")
+)
+
+func main() {
+	pflag.Parse()
+
+	// Check for -conf parameter manually
+	configPath := *cfg
+	for i, arg := range os.Args {
+		if arg == "-conf" && i+1 < len(os.Args) {
+			configPath = os.Args[i+1]
+			break
+		}
+	}
+
+	conf := config.NewConfig(configPath)
+	logger := log.NewLog(conf)
+
+	opts, err := backtestOptions(time.Now())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *format != "table" && *format != "csv" {
+		fmt.Fprintf(os.Stderr, "unsupported format: %s\n", *format)
+		os.Exit(2)
+	}
+
+	settings := service.LoadAlertSettings(conf, logger)
+	if pflag.CommandLine.Changed("threshold") {
+		settings.Threshold = *threshold
+	}
+
+	db := repository.NewDB(conf, logger)
+	repo := repository.NewRepository(logger, db)
+	candleRepo := repository.NewCandleRepository(repo, logger)
+	klineService := service.NewKlineService(candleRepo, repository.NewExchangePriceRepository(repo, logger), logger, conf)
+	backtester := service.NewBacktester(klineService, settings)
+
+	ctx := context.Background()
+	var alerts []service.BacktestAlert
+	for _, exchangeName := range *exchanges {
+		exchangeName = strings.ToUpper(exchangeName)
+		list := *symbols
+		if len(list) == 0 {
+			if list, err = candleRepo.ListSymbols(ctx, exchangeName, service.BaseInterval.String()); err != nil {
+				logger.Fatal("Failed to list stored symbols", zap.Error(err), zap.String("exchange", exchangeName))
+			}
+		}
+		for _, symbol := range list {
+			symbolAlerts, err := backtester.Run(ctx, exchangeName, strings.ToUpper(symbol), opts)
+			if err != nil {
+				logger.Error("Failed to backtest symbol", zap.Error(err), zap.String("exchange", exchangeName), zap.String("symbol", symbol))
+				continue
+			}
+			alerts = append(alerts, symbolAlerts...)
+		}
+	}
+	summaries := service.SummarizeBacktest(alerts)
+
+	alertsOut, closeAlerts := create(*out)
+	defer closeAlerts()
+	if *format == "csv" {
+		writeAlertsCSV(alertsOut, alerts)
+	} else {
+		writeAlertsTable(alertsOut, alerts)
+	}
+
+	summaryW := io.Writer(os.Stderr)
+	if *summaryOut != "" {
+		w, closeSummary := create(*summaryOut)
+		defer closeSummary()
+		summaryW = w
+	} else if *format == "table" {
+		summaryW = alertsOut
+		fmt.Fprintln(summaryW)
+	}
+	if *format == "csv" && *summaryOut != "" {
+		writeSummaryCSV(summaryW, summaries)
+	} else {
+		writeSummaryTable(summaryW, summaries, opts)
+	}
+}
+
+// backtestOptions builds the replay window from the flags.
+func backtestOptions(now time.Time) (service.BacktestOptions, error) {
+	opts := service.BacktestOptions{
+		From:     now.UTC().Truncate(24*time.Hour).AddDate(0, 0, -30),
+		To:       now,
+		Step:     *step,
+		Cooldown: *cooldown,
+	}
+	if *from != "" {
+		t, err := time.Parse("2006-01-02", *from)
+		if err != nil {
+			return opts, fmt.Errorf("invalid --from: %w", err)
+		}
+		opts.From = t
+	}
+	if *to != "" {
+		t, err := time.Parse("2006-01-02", *to)
+		if err != nil {
+			return opts, fmt.Errorf("invalid --to: %w", err)
+		}
+		opts.To = t
+	}
+	if !opts.From.Before(opts.To) {
+		return opts, fmt.Errorf("--from must be before --to")
+	}
+	return opts, nil
+}
+
+// create opens path for writing, or returns stdout if path is empty.
+func create(path string) (io.Writer, func()) {
+	if path == "" {
+		return os.Stdout, func() {}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	return f, func() { f.Close() }
+}
+
+func horizonName(d time.Duration) string {
+	if d >= 24*time.Hour && d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(d.String(), "0s"), "0m")
+}
+
+// percent formats a percentage, or "-" for NaN.
+func percent(v float64, csv bool) string {
+	if math.IsNaN(v) {
+		if csv {
+			return ""
+		}
+		return "-"
+	}
+	if csv {
+		return strconv.FormatFloat(v, 'f', 4, 64)
+	}
+	return fmt.Sprintf("%+.2f%%", v)
+}
+
+func writeAlertsTable(w io.Writer, alerts []service.BacktestAlert) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := []string{"TIME", "EXCHANGE", "SYMBOL", "SIGNAL", "PRICE"}
+	for _, h := range service.BacktestHorizons {
+		header = append(header, "RET_"+strings.ToUpper(horizonName(h)))
+	}
+	fmt.Fprintln(tw, strings.Join(append(header, "DETAIL"), "\t"))
+	for _, a := range alerts {
+		row := []string{a.Time.UTC().Format("2006-01-02 15:04"), a.Exchange, a.Symbol, a.Signal, strconv.FormatFloat(a.Price, 'g', 8, 64)}
+		for _, r := range a.Returns {
+			row = append(row, percent(r, false))
+		}
+		fmt.Fprintln(tw, strings.Join(append(row, a.Message), "\t"))
+	}
+	tw.Flush()
+}
+
+func writeAlertsCSV(w io.Writer, alerts []service.BacktestAlert) {
+	cw := csv.NewWriter(w)
+	header := []string{"time", "exchange", "symbol", "signal", "price"}
+	for _, h := range service.BacktestHorizons {
+		header = append(header, "return_"+horizonName(h))
+	}
+	cw.Write(append(header, "detail"))
+	for _, a := range alerts {
+		row := []string{a.Time.UTC().Format(time.RFC3339), a.Exchange, a.Symbol, a.Signal, strconv.FormatFloat(a.Price, 'f', -1, 64)}
+		for _, r := range a.Returns {
+			row = append(row, percent(r, true))
+		}
+		cw.Write(append(row, a.Message))
+	}
+	cw.Flush()
+}
+
+func writeSummaryTable(w io.Writer, summaries []service.BacktestSummary, opts service.BacktestOptions) {
+	fmt.Fprintf(w, "Summary %s to %s, forward returns as mean / median / hit rate (samples)\n",
+		opts.From.UTC().Format("2006-01-02 15:04"), opts.To.UTC().Format("2006-01-02 15:04"))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := []string{"SIGNAL", "ALERTS", "SYMBOLS"}
+	for _, h := range service.BacktestHorizons {
+		header = append(header, strings.ToUpper(horizonName(h)))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, s := range summaries {
+		row := []string{s.Signal, strconv.Itoa(s.Alerts), strconv.Itoa(s.Symbols)}
+		for _, h := range s.Horizons {
+			if h.Samples == 0 {
+				row = append(row, "-")
+				continue
+			}
+			row = append(row, fmt.Sprintf("%s / %s / %.0f%% (%d)", percent(h.Mean, false), percent(h.Median, false), h.HitRate, h.Samples))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	tw.Flush()
+}
+
+func writeSummaryCSV(w io.Writer, summaries []service.BacktestSummary) {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"signal", "alerts", "symbols", "horizon", "samples", "mean_return", "median_return", "hit_rate"})
+	for _, s := range summaries {
+		for _, h := range s.Horizons {
+			cw.Write([]string{s.Signal, strconv.Itoa(s.Alerts), strconv.Itoa(s.Symbols), horizonName(h.Horizon),
+				strconv.Itoa(h.Samples), percent(h.Mean, true), percent(h.Median, true), percent(h.HitRate, true)})
+		}
+	}
+	cw.Flush()
+}
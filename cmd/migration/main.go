@@ -36,7 +36,7 @@ func main() {
 	// Set GORM logger level to Info to see auto-migration SQL statements
 	// db.Logger = db.Logger.LogMode(gorm.Info) // Set LogMode to Info to see SQL
 
-	err := db.AutoMigrate(&model.ExchangePrice{}, &model.MonitorConfig{}) // AutoMigrate the models
+	err := db.AutoMigrate(&model.ExchangePrice{}, &model.MonitorConfig{}, &model.PriceSpread{}, &model.Candle{}, &model.OrderBookSnapshot{}, &model.LargeTrade{}, &model.PortfolioSnapshot{}, &model.PortfolioPosition{}, &model.Holding{}, &model.PaperAccount{}, &model.PaperPosition{}, &model.PaperTrade{}, &model.PaperEquity{}, &model.JobLock{}, &model.JobRun{}, &model.JobState{}) // AutoMigrate the models
 	if err != nil {
 		logger.Fatal("failed to auto migrate database", zap.Error(err))
 	}
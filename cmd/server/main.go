@@ -4,10 +4,13 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"os"
 
 	"klineio/cmd/server/wire"
+	"klineio/internal/server"
 	"klineio/pkg/config"
 	"klineio/pkg/log"
+	"klineio/pkg/tracing"
 
 	"go.uber.org/zap"
 )
@@ -29,18 +32,33 @@ import (
 // @externalDocs.url          https://swagger.io/resources/open-api/
 func main() {
 	var envConf = flag.String("conf", "config/local.yml", "config path, eg: -conf ./config/local.yml")
+	var roleList = flag.String("roles", "http,worker", "comma separated roles to run: http, task and worker, eg: -roles http,task,worker")
 	flag.Parse()
+	roles, err := server.ParseRoles(*roleList)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 	conf := config.NewConfig(*envConf)
 
 	logger := log.NewLog(conf)
 
-	app, cleanup, err := wire.NewWire(conf, logger)
+	shutdownTracing, err := tracing.Setup(conf, logger, "klineio-server")
+	if err != nil {
+		panic(err)
+	}
+	defer shutdownTracing()
+
+	app, cleanup, err := wire.NewWire(conf, logger, roles)
 	defer cleanup()
 	if err != nil {
 		panic(err)
 	}
-	logger.Info("server start", zap.String("host", fmt.Sprintf("http://%s:%d", conf.GetString("http.host"), conf.GetInt("http.port"))))
-	logger.Info("docs addr", zap.String("addr", fmt.Sprintf("http://%s:%d/swagger/index.html", conf.GetString("http.host"), conf.GetInt("http.port"))))
+	logger.Info("server start", zap.Stringer("roles", roles))
+	if roles[server.RoleHTTP] {
+		logger.Info("http addr", zap.String("host", fmt.Sprintf("http://%s:%d", conf.GetString("http.host"), conf.GetInt("http.port"))))
+		logger.Info("docs addr", zap.String("addr", fmt.Sprintf("http://%s:%d/swagger/index.html", conf.GetString("http.host"), conf.GetInt("http.port"))))
+	}
 	if err = app.Run(context.Background()); err != nil {
 		panic(err)
 	}
@@ -9,7 +9,9 @@ import (
 	"klineio/internal/repository"
 	"klineio/internal/server"
 	"klineio/internal/service"
+	"klineio/internal/task"
 	"klineio/pkg/app"
+	"klineio/pkg/broker"
 	"klineio/pkg/exchange"
 	"klineio/pkg/jwt"
 	"klineio/pkg/log"
@@ -26,6 +28,11 @@ func provideDingTalkWebhookURL(conf *viper.Viper) string {
 	return conf.GetString("dingtalk.webhook_url")
 }
 
+// provideNotifiers lists the notifiers alerts are delivered to.
+func provideNotifiers(dingTalk *notifier.DingTalkNotifier) []notifier.Notifier {
+	return []notifier.Notifier{dingTalk}
+}
+
 var repositorySet = wire.NewSet(
 	repository.NewDB,
 	repository.NewRedis,
@@ -34,58 +41,110 @@ var repositorySet = wire.NewSet(
 	repository.NewTransaction,
 	repository.NewUserRepository,
 	repository.NewExchangePriceRepository,
+	repository.NewPriceSpreadRepository,
+	repository.NewCandleRepository,
+	repository.NewOrderBookRepository,
+	repository.NewTradeRepository,
+	repository.NewPortfolioRepository,
+	repository.NewHoldingRepository,
+	repository.NewPaperTradingRepository,
+	repository.NewLocker,
+	repository.NewJobRepository,
 	// repository.NewMonitorConfigRepository, // 暂时注释掉，因为该函数不存在
 )
 
 var serviceSet = wire.NewSet(
 	service.NewService,
 	service.NewUserService,
+	service.NewAlertService,
+	service.NewWatchdogService,
 	service.NewPriceMonitorService,
+	service.NewSpreadMonitorService,
+	service.NewAnomalyMonitorService,
+	service.NewKlineService,
+	service.NewUniverseService,
+	service.NewOrderBookService,
+	service.NewTradeService,
+	service.NewPortfolioService,
+	service.NewPaperTradingService,
+	service.NewDigestService,
+	service.NewBackfillService,
+	service.NewRetentionService,
+	service.NewJobService,
+	service.NewHoldingService,
+	service.NewHealthService,
 )
 
 var handlerSet = wire.NewSet(
 	handler.NewHandler,
 	handler.NewUserHandler,
+	handler.NewPortfolioHandler,
+	handler.NewHoldingHandler,
+	handler.NewPaperTradingHandler,
+	handler.NewJobHandler,
+	handler.NewHealthHandler,
 )
 
 var jobSet = wire.NewSet(
 	job.NewJob,
-	job.NewUserJob,
+	job.NewAlertJob,
 	job.NewPriceMonitorJob,
+	job.NewOrderBookJob,
+	job.NewTradeJob,
+	job.NewPortfolioJob,
+	job.NewPaperTradingJob,
+	job.NewDigestJob,
+	job.NewBackfillJob,
+	job.NewRetentionJob,
+	job.NewWatchdogJob,
+)
+
+var taskSet = wire.NewSet(
+	task.NewTask,
+	task.NewUserTask,
 )
 
 var exchangeClientSet = wire.NewSet(
 	exchange.NewBinanceClient,
 	exchange.NewOKEXClient,
+	exchange.NewBinanceAccountClient,
+	exchange.NewOKEXAccountClient,
 )
 
 var notifierSet = wire.NewSet(
 	notifier.NewDingTalkNotifier,
+	provideNotifiers,
+	broker.NewBroker,
 )
 
 var serverSet = wire.NewSet(
 	server.NewHTTPServer,
 	server.NewJobServer,
+	server.NewTaskServer,
+	server.NewAdminServer,
 )
 
-// build App
+// build App with the servers of the roles this process runs
 func newApp(
+	roles server.Roles,
+	adminServer *server.AdminServer,
 	httpServer *http.Server,
+	taskServer *server.TaskServer,
 	jobServer *server.JobServer,
-	// task *server.Task,
 ) *app.App {
 	return app.NewApp(
-		app.WithServer(httpServer, jobServer),
+		app.WithServer(roles.Servers(adminServer, httpServer, taskServer, jobServer)...),
 		app.WithName("klineio-server"), // Modified app name
 	)
 }
 
-func NewWire(conf *viper.Viper, logger *log.Logger) (*app.App, func(), error) {
+func NewWire(conf *viper.Viper, logger *log.Logger, roles server.Roles) (*app.App, func(), error) {
 	panic(wire.Build(
 		repositorySet,
 		serviceSet,
 		handlerSet,
 		jobSet,
+		taskSet,
 		exchangeClientSet,
 		notifierSet,
 		serverSet,
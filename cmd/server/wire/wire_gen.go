@@ -14,7 +14,9 @@ import (
 	"klineio/internal/repository"
 	"klineio/internal/server"
 	"klineio/internal/service"
+	"klineio/internal/task"
 	"klineio/pkg/app"
+	"klineio/pkg/broker"
 	"klineio/pkg/exchange"
 	"klineio/pkg/jwt"
 	"klineio/pkg/log"
@@ -25,30 +27,86 @@ import (
 
 // Injectors from wire.go:
 
-func NewWire(conf *viper.Viper, logger *log.Logger) (*app.App, func(), error) {
-	jwtJWT := jwt.NewJwt(conf)
+func NewWire(conf *viper.Viper, logger *log.Logger, roles server.Roles) (*app.App, func(), error) {
 	handlerHandler := handler.NewHandler(logger)
 	db := repository.NewDB(conf, logger)
 	repositoryRepository := repository.NewRepository(logger, db)
+	jobRepository := repository.NewJobRepository(repositoryRepository, logger)
+	binanceClient := exchange.NewBinanceClient(logger, conf)
+	okexClient := exchange.NewOKEXClient(logger, conf)
+	healthService, cleanup := service.NewHealthService(repositoryRepository, jobRepository, binanceClient, okexClient, logger, conf)
+	healthHandler := handler.NewHealthHandler(handlerHandler, healthService)
+	adminServer := server.NewAdminServer(logger, conf, healthHandler)
+	jwtJWT := jwt.NewJwt(conf)
 	transaction := repository.NewTransaction(repositoryRepository)
 	sidSid := sid.NewSid()
 	serviceService := service.NewService(transaction, logger, sidSid, jwtJWT)
 	userRepository := repository.NewUserRepository(repositoryRepository)
 	userService := service.NewUserService(serviceService, userRepository)
 	userHandler := handler.NewUserHandler(handlerHandler, userService)
-	httpServer := server.NewHTTPServer(logger, conf, jwtJWT, userHandler)
+	portfolioRepository := repository.NewPortfolioRepository(repositoryRepository, logger)
+	holdingRepository := repository.NewHoldingRepository(repositoryRepository, logger)
 	exchangePriceRepository := repository.NewExchangePriceRepository(repositoryRepository, logger)
-	binanceClient := exchange.NewBinanceClient(logger, conf)
-	okexClient := exchange.NewOKEXClient(logger, conf)
+	binanceAccountClient := exchange.NewBinanceAccountClient(logger, conf)
+	okexAccountClient := exchange.NewOKEXAccountClient(logger, conf)
+	brokerBroker, cleanup2, err := broker.NewBroker(conf, logger)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
 	string2 := provideDingTalkWebhookURL(conf)
 	dingTalkNotifier := notifier.NewDingTalkNotifier(string2, logger)
-	priceMonitorService := service.NewPriceMonitorService(exchangePriceRepository, binanceClient, okexClient, dingTalkNotifier, logger, conf)
+	v := provideNotifiers(dingTalkNotifier)
+	watchdogService := service.NewWatchdogService(exchangePriceRepository, logger, conf)
+	alertService := service.NewAlertService(brokerBroker, v, watchdogService, logger, conf)
+	portfolioService := service.NewPortfolioService(portfolioRepository, holdingRepository, exchangePriceRepository, binanceAccountClient, okexAccountClient, binanceClient, okexClient, alertService, logger, conf)
+	portfolioHandler := handler.NewPortfolioHandler(handlerHandler, portfolioService)
+	holdingService := service.NewHoldingService(serviceService, holdingRepository)
+	holdingHandler := handler.NewHoldingHandler(handlerHandler, holdingService)
+	paperTradingRepository := repository.NewPaperTradingRepository(repositoryRepository, logger)
+	paperTradingService := service.NewPaperTradingService(paperTradingRepository, binanceClient, okexClient, logger, conf)
+	paperTradingHandler := handler.NewPaperTradingHandler(handlerHandler, paperTradingService)
+	jobService := service.NewJobService(serviceService, jobRepository, conf)
+	jobHandler := handler.NewJobHandler(handlerHandler, jobService)
+	httpServer := server.NewHTTPServer(logger, conf, jwtJWT, userHandler, portfolioHandler, holdingHandler, paperTradingHandler, jobHandler, healthHandler)
+	locker, cleanup3, err := repository.NewLocker(conf, repositoryRepository, logger)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	userTask := task.NewUserTask(userRepository, logger)
+	priceSpreadRepository := repository.NewPriceSpreadRepository(repositoryRepository, logger)
+	spreadMonitorService := service.NewSpreadMonitorService(priceSpreadRepository, alertService, logger, conf)
+	anomalyMonitorService := service.NewAnomalyMonitorService(exchangePriceRepository, alertService, logger, conf)
+	candleRepository := repository.NewCandleRepository(repositoryRepository, logger)
+	klineService := service.NewKlineService(candleRepository, exchangePriceRepository, logger, conf)
+	universeService := service.NewUniverseService(logger, conf)
+	priceMonitorService := service.NewPriceMonitorService(exchangePriceRepository, binanceClient, okexClient, alertService, spreadMonitorService, anomalyMonitorService, klineService, universeService, paperTradingService, watchdogService, logger, conf)
 	priceMonitorJob := job.NewPriceMonitorJob(priceMonitorService, logger)
-	jobJob := job.NewJob(transaction, logger, sidSid, priceMonitorJob)
-	userJob := job.NewUserJob(jobJob, userRepository)
-	jobServer := server.NewJobServer(logger, userJob)
-	appApp := newApp(httpServer, jobServer)
+	orderBookRepository := repository.NewOrderBookRepository(repositoryRepository, logger)
+	orderBookService := service.NewOrderBookService(orderBookRepository, universeService, binanceClient, okexClient, alertService, logger, conf)
+	orderBookJob := job.NewOrderBookJob(orderBookService, logger)
+	tradeRepository := repository.NewTradeRepository(repositoryRepository, logger)
+	tradeService := service.NewTradeService(tradeRepository, universeService, binanceClient, okexClient, alertService, logger, conf)
+	tradeJob := job.NewTradeJob(tradeService, logger)
+	portfolioJob := job.NewPortfolioJob(portfolioService, logger)
+	paperTradingJob := job.NewPaperTradingJob(paperTradingService, logger)
+	digestService := service.NewDigestService(exchangePriceRepository, portfolioRepository, paperTradingService, alertService, logger, conf)
+	digestJob := job.NewDigestJob(digestService, logger)
+	backfillService := service.NewBackfillService(klineService, candleRepository, binanceClient, okexClient, logger, conf)
+	backfillJob := job.NewBackfillJob(backfillService, logger)
+	retentionService := service.NewRetentionService(exchangePriceRepository, candleRepository, orderBookRepository, tradeRepository, priceSpreadRepository, logger, conf)
+	retentionJob := job.NewRetentionJob(retentionService, logger)
+	watchdogJob := job.NewWatchdogJob(watchdogService, logger)
+	taskServer := server.NewTaskServer(logger, conf, locker, jobRepository, userTask, priceMonitorJob, orderBookJob, tradeJob, portfolioJob, paperTradingJob, digestJob, backfillJob, retentionJob, watchdogJob)
+	alertJob := job.NewAlertJob(brokerBroker, alertService, conf, logger)
+	jobServer := server.NewJobServer(logger, alertJob)
+	appApp := newApp(roles, adminServer, httpServer, taskServer, jobServer)
 	return appApp, func() {
+		cleanup3()
+		cleanup2()
+		cleanup()
 	}, nil
 }
 
@@ -59,25 +117,34 @@ func provideDingTalkWebhookURL(conf *viper.Viper) string {
 	return conf.GetString("dingtalk.webhook_url")
 }
 
-var repositorySet = wire.NewSet(repository.NewDB, repository.NewRedis, repository.NewMongo, repository.NewRepository, repository.NewTransaction, repository.NewUserRepository, repository.NewExchangePriceRepository)
+// provideNotifiers lists the notifiers alerts are delivered to.
+func provideNotifiers(dingTalk *notifier.DingTalkNotifier) []notifier.Notifier {
+	return []notifier.Notifier{dingTalk}
+}
+
+var repositorySet = wire.NewSet(repository.NewDB, repository.NewRedis, repository.NewMongo, repository.NewRepository, repository.NewTransaction, repository.NewUserRepository, repository.NewExchangePriceRepository, repository.NewPriceSpreadRepository, repository.NewCandleRepository, repository.NewOrderBookRepository, repository.NewTradeRepository, repository.NewPortfolioRepository, repository.NewHoldingRepository, repository.NewPaperTradingRepository, repository.NewLocker, repository.NewJobRepository)
 
-var serviceSet = wire.NewSet(service.NewService, service.NewUserService, service.NewPriceMonitorService)
+var serviceSet = wire.NewSet(service.NewService, service.NewUserService, service.NewAlertService, service.NewWatchdogService, service.NewPriceMonitorService, service.NewSpreadMonitorService, service.NewAnomalyMonitorService, service.NewKlineService, service.NewUniverseService, service.NewOrderBookService, service.NewTradeService, service.NewPortfolioService, service.NewPaperTradingService, service.NewDigestService, service.NewBackfillService, service.NewRetentionService, service.NewJobService, service.NewHoldingService, service.NewHealthService)
 
-var handlerSet = wire.NewSet(handler.NewHandler, handler.NewUserHandler)
+var handlerSet = wire.NewSet(handler.NewHandler, handler.NewUserHandler, handler.NewPortfolioHandler, handler.NewHoldingHandler, handler.NewPaperTradingHandler, handler.NewJobHandler, handler.NewHealthHandler)
 
-var jobSet = wire.NewSet(job.NewJob, job.NewUserJob, job.NewPriceMonitorJob)
+var jobSet = wire.NewSet(job.NewJob, job.NewAlertJob, job.NewPriceMonitorJob, job.NewOrderBookJob, job.NewTradeJob, job.NewPortfolioJob, job.NewPaperTradingJob, job.NewDigestJob, job.NewBackfillJob, job.NewRetentionJob, job.NewWatchdogJob)
 
-var exchangeClientSet = wire.NewSet(exchange.NewBinanceClient, exchange.NewOKEXClient)
+var taskSet = wire.NewSet(task.NewTask, task.NewUserTask)
 
-var notifierSet = wire.NewSet(notifier.NewDingTalkNotifier)
+var exchangeClientSet = wire.NewSet(exchange.NewBinanceClient, exchange.NewOKEXClient, exchange.NewBinanceAccountClient, exchange.NewOKEXAccountClient)
 
-var serverSet = wire.NewSet(server.NewHTTPServer, server.NewJobServer)
+var notifierSet = wire.NewSet(notifier.NewDingTalkNotifier, provideNotifiers, broker.NewBroker)
 
-// build App
+var serverSet = wire.NewSet(server.NewHTTPServer, server.NewJobServer, server.NewTaskServer, server.NewAdminServer)
+
+// build App with the servers of the roles this process runs
 func newApp(
+	roles server.Roles,
+	adminServer *server.AdminServer,
 	httpServer *http.Server,
+	taskServer *server.TaskServer,
 	jobServer *server.JobServer,
-
 ) *app.App {
-	return app.NewApp(app.WithServer(httpServer, jobServer), app.WithName("klineio-server"))
+	return app.NewApp(app.WithServer(roles.Servers(adminServer, httpServer, taskServer, jobServer)...), app.WithName("klineio-server"))
 }
@@ -6,6 +6,7 @@ import (
 	"klineio/cmd/task/wire"
 	"klineio/pkg/config"
 	"klineio/pkg/log"
+	"klineio/pkg/tracing"
 	"os"
 	"os/signal"
 	"syscall"
@@ -34,6 +35,12 @@ func main() {
 	logger := log.NewLog(conf)
 	logger.Info("start task")
 
+	shutdownTracing, err := tracing.Setup(conf, logger, "klineio-task")
+	if err != nil {
+		panic(err)
+	}
+	defer shutdownTracing()
+
 	// Create a context that can be cancelled by OS signals
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
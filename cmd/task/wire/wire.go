@@ -4,12 +4,14 @@
 package wire
 
 import (
+	"klineio/internal/handler"
 	"klineio/internal/job"
 	"klineio/internal/repository"
 	"klineio/internal/server"
 	"klineio/internal/service"
 	"klineio/internal/task"
 	"klineio/pkg/app"
+	"klineio/pkg/broker"
 	"klineio/pkg/exchange"
 	"klineio/pkg/log"
 	"klineio/pkg/notifier"
@@ -23,6 +25,11 @@ func provideDingTalkWebhookURL(conf *viper.Viper) string {
 	return conf.GetString("dingtalk.webhook_url")
 }
 
+// provideNotifiers lists the notifiers alerts are delivered to.
+func provideNotifiers(dingTalk *notifier.DingTalkNotifier) []notifier.Notifier {
+	return []notifier.Notifier{dingTalk}
+}
+
 var repositorySet = wire.NewSet(
 	repository.NewDB,
 	//repository.NewRedis,
@@ -30,36 +37,78 @@ var repositorySet = wire.NewSet(
 	repository.NewTransaction,
 	repository.NewUserRepository,
 	repository.NewExchangePriceRepository,
+	repository.NewPriceSpreadRepository,
+	repository.NewCandleRepository,
+	repository.NewOrderBookRepository,
+	repository.NewTradeRepository,
+	repository.NewPortfolioRepository,
+	repository.NewHoldingRepository,
+	repository.NewPaperTradingRepository,
+	repository.NewLocker,
+	repository.NewJobRepository,
 )
 
 var exchangeClientSet = wire.NewSet(
 	exchange.NewBinanceClient,
 	exchange.NewOKEXClient,
+	exchange.NewBinanceAccountClient,
+	exchange.NewOKEXAccountClient,
 )
 
 var notifierSet = wire.NewSet(
 	notifier.NewDingTalkNotifier,
+	provideNotifiers,
+	broker.NewBroker,
 )
 
 var serviceSet = wire.NewSet(
+	service.NewAlertService,
+	service.NewWatchdogService,
 	service.NewPriceMonitorService,
+	service.NewSpreadMonitorService,
+	service.NewAnomalyMonitorService,
+	service.NewKlineService,
+	service.NewUniverseService,
+	service.NewOrderBookService,
+	service.NewTradeService,
+	service.NewPortfolioService,
+	service.NewPaperTradingService,
+	service.NewDigestService,
+	service.NewBackfillService,
+	service.NewRetentionService,
+	service.NewHealthService,
+)
+
+var handlerSet = wire.NewSet(
+	handler.NewHandler,
+	handler.NewHealthHandler,
 )
 
 var taskSet = wire.NewSet(
 	task.NewTask,
 	task.NewUserTask,
 	job.NewPriceMonitorJob,
+	job.NewOrderBookJob,
+	job.NewTradeJob,
+	job.NewPortfolioJob,
+	job.NewPaperTradingJob,
+	job.NewDigestJob,
+	job.NewBackfillJob,
+	job.NewRetentionJob,
+	job.NewWatchdogJob,
 )
 var serverSet = wire.NewSet(
 	server.NewTaskServer,
+	server.NewAdminServer,
 )
 
 // build App
 func newApp(
 	task *server.TaskServer,
+	admin *server.AdminServer,
 ) *app.App {
 	return app.NewApp(
-		app.WithServer(task),
+		app.WithServer(task, admin),
 		app.WithName("klineio"), // Modified app name
 	)
 }
@@ -70,6 +119,7 @@ func NewWire(conf *viper.Viper, logger *log.Logger) (*app.App, func(), error) {
 		exchangeClientSet,
 		notifierSet,
 		serviceSet,
+		handlerSet,
 		taskSet,
 		serverSet,
 		// job.NewJob, // Removed unused provider again
@@ -9,12 +9,14 @@ package wire
 import (
 	"github.com/google/wire"
 	"github.com/spf13/viper"
+	"klineio/internal/handler"
 	"klineio/internal/job"
 	"klineio/internal/repository"
 	"klineio/internal/server"
 	"klineio/internal/service"
 	"klineio/internal/task"
 	"klineio/pkg/app"
+	"klineio/pkg/broker"
 	"klineio/pkg/exchange"
 	"klineio/pkg/log"
 	"klineio/pkg/notifier"
@@ -25,18 +27,66 @@ import (
 func NewWire(conf *viper.Viper, logger *log.Logger) (*app.App, func(), error) {
 	db := repository.NewDB(conf, logger)
 	repositoryRepository := repository.NewRepository(logger, db)
+	locker, cleanup, err := repository.NewLocker(conf, repositoryRepository, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	jobRepository := repository.NewJobRepository(repositoryRepository, logger)
 	userRepository := repository.NewUserRepository(repositoryRepository)
 	userTask := task.NewUserTask(userRepository, logger)
 	exchangePriceRepository := repository.NewExchangePriceRepository(repositoryRepository, logger)
 	binanceClient := exchange.NewBinanceClient(logger, conf)
 	okexClient := exchange.NewOKEXClient(logger, conf)
+	brokerBroker, cleanup2, err := broker.NewBroker(conf, logger)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
 	string2 := provideDingTalkWebhookURL(conf)
 	dingTalkNotifier := notifier.NewDingTalkNotifier(string2, logger)
-	priceMonitorService := service.NewPriceMonitorService(exchangePriceRepository, binanceClient, okexClient, dingTalkNotifier, logger, conf)
+	v := provideNotifiers(dingTalkNotifier)
+	watchdogService := service.NewWatchdogService(exchangePriceRepository, logger, conf)
+	alertService := service.NewAlertService(brokerBroker, v, watchdogService, logger, conf)
+	priceSpreadRepository := repository.NewPriceSpreadRepository(repositoryRepository, logger)
+	spreadMonitorService := service.NewSpreadMonitorService(priceSpreadRepository, alertService, logger, conf)
+	anomalyMonitorService := service.NewAnomalyMonitorService(exchangePriceRepository, alertService, logger, conf)
+	candleRepository := repository.NewCandleRepository(repositoryRepository, logger)
+	klineService := service.NewKlineService(candleRepository, exchangePriceRepository, logger, conf)
+	universeService := service.NewUniverseService(logger, conf)
+	paperTradingRepository := repository.NewPaperTradingRepository(repositoryRepository, logger)
+	paperTradingService := service.NewPaperTradingService(paperTradingRepository, binanceClient, okexClient, logger, conf)
+	priceMonitorService := service.NewPriceMonitorService(exchangePriceRepository, binanceClient, okexClient, alertService, spreadMonitorService, anomalyMonitorService, klineService, universeService, paperTradingService, watchdogService, logger, conf)
 	priceMonitorJob := job.NewPriceMonitorJob(priceMonitorService, logger)
-	taskServer := server.NewTaskServer(logger, conf, userTask, priceMonitorJob)
-	appApp := newApp(taskServer)
+	orderBookRepository := repository.NewOrderBookRepository(repositoryRepository, logger)
+	orderBookService := service.NewOrderBookService(orderBookRepository, universeService, binanceClient, okexClient, alertService, logger, conf)
+	orderBookJob := job.NewOrderBookJob(orderBookService, logger)
+	tradeRepository := repository.NewTradeRepository(repositoryRepository, logger)
+	tradeService := service.NewTradeService(tradeRepository, universeService, binanceClient, okexClient, alertService, logger, conf)
+	tradeJob := job.NewTradeJob(tradeService, logger)
+	portfolioRepository := repository.NewPortfolioRepository(repositoryRepository, logger)
+	holdingRepository := repository.NewHoldingRepository(repositoryRepository, logger)
+	binanceAccountClient := exchange.NewBinanceAccountClient(logger, conf)
+	okexAccountClient := exchange.NewOKEXAccountClient(logger, conf)
+	portfolioService := service.NewPortfolioService(portfolioRepository, holdingRepository, exchangePriceRepository, binanceAccountClient, okexAccountClient, binanceClient, okexClient, alertService, logger, conf)
+	portfolioJob := job.NewPortfolioJob(portfolioService, logger)
+	paperTradingJob := job.NewPaperTradingJob(paperTradingService, logger)
+	digestService := service.NewDigestService(exchangePriceRepository, portfolioRepository, paperTradingService, alertService, logger, conf)
+	digestJob := job.NewDigestJob(digestService, logger)
+	backfillService := service.NewBackfillService(klineService, candleRepository, binanceClient, okexClient, logger, conf)
+	backfillJob := job.NewBackfillJob(backfillService, logger)
+	retentionService := service.NewRetentionService(exchangePriceRepository, candleRepository, orderBookRepository, tradeRepository, priceSpreadRepository, logger, conf)
+	retentionJob := job.NewRetentionJob(retentionService, logger)
+	watchdogJob := job.NewWatchdogJob(watchdogService, logger)
+	taskServer := server.NewTaskServer(logger, conf, locker, jobRepository, userTask, priceMonitorJob, orderBookJob, tradeJob, portfolioJob, paperTradingJob, digestJob, backfillJob, retentionJob, watchdogJob)
+	handlerHandler := handler.NewHandler(logger)
+	healthService, cleanup3 := service.NewHealthService(repositoryRepository, jobRepository, binanceClient, okexClient, logger, conf)
+	healthHandler := handler.NewHealthHandler(handlerHandler, healthService)
+	adminServer := server.NewAdminServer(logger, conf, healthHandler)
+	appApp := newApp(taskServer, adminServer)
 	return appApp, func() {
+		cleanup3()
+		cleanup2()
+		cleanup()
 	}, nil
 }
 
@@ -47,20 +97,28 @@ func provideDingTalkWebhookURL(conf *viper.Viper) string {
 	return conf.GetString("dingtalk.webhook_url")
 }
 
-var repositorySet = wire.NewSet(repository.NewDB, repository.NewRepository, repository.NewTransaction, repository.NewUserRepository, repository.NewExchangePriceRepository)
+// provideNotifiers lists the notifiers alerts are delivered to.
+func provideNotifiers(dingTalk *notifier.DingTalkNotifier) []notifier.Notifier {
+	return []notifier.Notifier{dingTalk}
+}
+
+var repositorySet = wire.NewSet(repository.NewDB, repository.NewRepository, repository.NewTransaction, repository.NewUserRepository, repository.NewExchangePriceRepository, repository.NewPriceSpreadRepository, repository.NewCandleRepository, repository.NewOrderBookRepository, repository.NewTradeRepository, repository.NewPortfolioRepository, repository.NewHoldingRepository, repository.NewPaperTradingRepository, repository.NewLocker, repository.NewJobRepository)
+
+var exchangeClientSet = wire.NewSet(exchange.NewBinanceClient, exchange.NewOKEXClient, exchange.NewBinanceAccountClient, exchange.NewOKEXAccountClient)
 
-var exchangeClientSet = wire.NewSet(exchange.NewBinanceClient, exchange.NewOKEXClient)
+var notifierSet = wire.NewSet(notifier.NewDingTalkNotifier, provideNotifiers, broker.NewBroker)
 
-var notifierSet = wire.NewSet(notifier.NewDingTalkNotifier)
+var serviceSet = wire.NewSet(service.NewAlertService, service.NewWatchdogService, service.NewPriceMonitorService, service.NewSpreadMonitorService, service.NewAnomalyMonitorService, service.NewKlineService, service.NewUniverseService, service.NewOrderBookService, service.NewTradeService, service.NewPortfolioService, service.NewPaperTradingService, service.NewDigestService, service.NewBackfillService, service.NewRetentionService, service.NewHealthService)
 
-var serviceSet = wire.NewSet(service.NewPriceMonitorService)
+var handlerSet = wire.NewSet(handler.NewHandler, handler.NewHealthHandler)
 
-var taskSet = wire.NewSet(task.NewTask, task.NewUserTask, job.NewPriceMonitorJob)
+var taskSet = wire.NewSet(task.NewTask, task.NewUserTask, job.NewPriceMonitorJob, job.NewOrderBookJob, job.NewTradeJob, job.NewPortfolioJob, job.NewPaperTradingJob, job.NewDigestJob, job.NewBackfillJob, job.NewRetentionJob, job.NewWatchdogJob)
 
-var serverSet = wire.NewSet(server.NewTaskServer)
+var serverSet = wire.NewSet(server.NewTaskServer, server.NewAdminServer)
 
 // build App
 func newApp(task2 *server.TaskServer,
+	admin *server.AdminServer,
 ) *app.App {
-	return app.NewApp(app.WithServer(task2), app.WithName("klineio"))
+	return app.NewApp(app.WithServer(task2, admin), app.WithName("klineio"))
 }
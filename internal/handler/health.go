@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"klineio/api/v1"
+	"klineio/internal/service"
+)
+
+type HealthHandler struct {
+	*Handler
+	healthService *service.HealthService
+}
+
+func NewHealthHandler(handler *Handler, healthService *service.HealthService) *HealthHandler {
+	return &HealthHandler{
+		Handler:       handler,
+		healthService: healthService,
+	}
+}
+
+// Healthz godoc
+// @Summary 存活检查
+// @Schemes
+// @Description 检查数据库和价格监控最近一次成功执行的时间，关键组件异常时返回 503
+// @Tags 运维模块
+// @Produce json
+// @Success 200 {object} v1.HealthResponse
+// @Failure 503 {object} v1.HealthResponse
+// @Router /healthz [get]
+func (h *HealthHandler) Healthz(ctx *gin.Context) {
+	h.respond(ctx, h.healthService.Liveness(ctx))
+}
+
+// Readyz godoc
+// @Summary 就绪检查
+// @Schemes
+// @Description 检查数据库、Redis、Mongo、各交易所连通性、通知配置和价格监控，关键组件异常时返回 503
+// @Tags 运维模块
+// @Produce json
+// @Success 200 {object} v1.HealthResponse
+// @Failure 503 {object} v1.HealthResponse
+// @Router /readyz [get]
+func (h *HealthHandler) Readyz(ctx *gin.Context) {
+	h.respond(ctx, h.healthService.Readiness(ctx))
+}
+
+// respond writes the report as is, so that load balancers and probes only need the status code.
+func (h *HealthHandler) respond(ctx *gin.Context, report v1.HealthResponse) {
+	status := http.StatusOK
+	if report.Status == v1.HealthDown {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.JSON(status, report)
+}
@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"klineio/api/v1"
+	"klineio/internal/service"
+)
+
+// maxHoldingsCSVBytes caps the size of an imported holdings CSV, far above any real portfolio.
+const maxHoldingsCSVBytes = 1 << 20
+
+type HoldingHandler struct {
+	*Handler
+	holdingService service.HoldingService
+}
+
+func NewHoldingHandler(handler *Handler, holdingService service.HoldingService) *HoldingHandler {
+	return &HoldingHandler{
+		Handler:        handler,
+		holdingService: holdingService,
+	}
+}
+
+// holdingId parses the :id path parameter.
+func holdingId(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	return uint(id), err == nil
+}
+
+// ListHoldings godoc
+// @Summary 获取手动持仓列表
+// @Schemes
+// @Description
+// @Tags 持仓模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.ListHoldingsResponse
+// @Router /holdings [get]
+func (h *HoldingHandler) ListHoldings(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+
+	holdings, err := h.holdingService.List(ctx, userId)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("holdingService.List error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, holdings)
+}
+
+// CreateHolding godoc
+// @Summary 添加手动持仓
+// @Schemes
+// @Description 记录交易所账户以外的持仓（如冷钱包），venue 默认为 MANUAL；填写交易所名称时优先使用该交易所的价格
+// @Tags 持仓模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CreateHoldingRequest true "params"
+// @Success 200 {object} v1.HoldingResponse
+// @Router /holdings [post]
+func (h *HoldingHandler) CreateHolding(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+
+	var req v1.CreateHoldingRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	holding, err := h.holdingService.Create(ctx, userId, &req)
+	if err != nil {
+		if errors.Is(err, v1.ErrHoldingExists) {
+			v1.HandleError(ctx, http.StatusConflict, v1.ErrHoldingExists, nil)
+			return
+		}
+		h.logger.WithContext(ctx).Error("holdingService.Create error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, holding)
+}
+
+// UpdateHolding godoc
+// @Summary 修改手动持仓
+// @Schemes
+// @Description
+// @Tags 持仓模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "持仓 ID"
+// @Param request body v1.UpdateHoldingRequest true "params"
+// @Success 200 {object} v1.HoldingResponse
+// @Router /holdings/{id} [put]
+func (h *HoldingHandler) UpdateHolding(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+
+	id, ok := holdingId(ctx)
+	var req v1.UpdateHoldingRequest
+	if err := ctx.ShouldBindJSON(&req); !ok || err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	holding, err := h.holdingService.Update(ctx, userId, id, &req)
+	if err != nil {
+		if errors.Is(err, v1.ErrNotFound) {
+			v1.HandleError(ctx, http.StatusNotFound, v1.ErrNotFound, nil)
+			return
+		}
+		h.logger.WithContext(ctx).Error("holdingService.Update error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, holding)
+}
+
+// DeleteHolding godoc
+// @Summary 删除手动持仓
+// @Schemes
+// @Description
+// @Tags 持仓模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "持仓 ID"
+// @Success 200 {object} v1.Response
+// @Router /holdings/{id} [delete]
+func (h *HoldingHandler) DeleteHolding(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+
+	id, ok := holdingId(ctx)
+	if !ok {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.holdingService.Delete(ctx, userId, id); err != nil {
+		if errors.Is(err, v1.ErrNotFound) {
+			v1.HandleError(ctx, http.StatusNotFound, v1.ErrNotFound, nil)
+			return
+		}
+		h.logger.WithContext(ctx).Error("holdingService.Delete error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ImportHoldings godoc
+// @Summary 导入手动持仓 CSV
+// @Schemes
+// @Description CSV 首行为表头，必需列 asset、quantity，可选列 cost_basis（USDT 单位成本）、venue；已存在的同币种同地点持仓将被覆盖。可通过 multipart 的 file 字段上传，或直接以 text/csv 作为请求体，大小不超过 1 MiB
+// @Tags 持仓模块
+// @Accept mpfd,text/csv
+// @Produce json
+// @Security Bearer
+// @Param file formData file false "CSV 文件"
+// @Success 200 {object} v1.ImportHoldingsResponse
+// @Router /holdings/import [post]
+func (h *HoldingHandler) ImportHoldings(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxHoldingsCSVBytes)
+	var body io.Reader = ctx.Request.Body
+	file, err := ctx.FormFile("file")
+	if tooLarge := new(http.MaxBytesError); errors.As(err, &tooLarge) {
+		v1.HandleError(ctx, http.StatusRequestEntityTooLarge, v1.ErrRequestTooLarge, nil)
+		return
+	}
+	if err == nil {
+		f, err := file.Open()
+		if err != nil {
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+
+	imported, err := h.holdingService.Import(ctx, userId, body)
+	if err != nil {
+		if tooLarge := new(http.MaxBytesError); errors.As(err, &tooLarge) {
+			v1.HandleError(ctx, http.StatusRequestEntityTooLarge, v1.ErrRequestTooLarge, nil)
+			return
+		}
+		if errors.Is(err, service.ErrInvalidHoldingsCSV) {
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		h.logger.WithContext(ctx).Error("holdingService.Import error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, v1.ImportHoldingsResponseData{Imported: imported})
+}
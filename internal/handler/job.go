@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"klineio/api/v1"
+	"klineio/internal/service"
+)
+
+type JobHandler struct {
+	*Handler
+	jobService service.JobService
+}
+
+func NewJobHandler(handler *Handler, jobService service.JobService) *JobHandler {
+	return &JobHandler{
+		Handler:    handler,
+		jobService: jobService,
+	}
+}
+
+// ListJobs godoc
+// @Summary 获取定时任务列表
+// @Schemes
+// @Description 返回每个任务的调度配置、是否暂停和最近一次执行
+// @Tags 任务模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.ListJobsResponse
+// @Router /jobs [get]
+func (h *JobHandler) ListJobs(ctx *gin.Context) {
+	jobs, err := h.jobService.List(ctx)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("jobService.List error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, jobs)
+}
+
+// ListRuns godoc
+// @Summary 获取任务执行记录
+// @Schemes
+// @Description 按时间倒序返回任务的执行记录，包括状态、耗时、成功/失败数量和错误
+// @Tags 任务模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param name path string true "任务名称"
+// @Param limit query int false "条数，默认 50"
+// @Success 200 {object} v1.ListJobRunsResponse
+// @Router /jobs/{name}/runs [get]
+func (h *JobHandler) ListRuns(ctx *gin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	runs, err := h.jobService.ListRuns(ctx, ctx.Param("name"), limit)
+	if err != nil {
+		h.handleError(ctx, "jobService.ListRuns error", err)
+		return
+	}
+
+	v1.HandleSuccess(ctx, runs)
+}
+
+// TriggerJob godoc
+// @Summary 手动触发任务
+// @Schemes
+// @Description 创建一条待执行记录，由任务服务在数秒内执行，暂停中的任务也会执行。仅限 security.admin_user_ids 中的管理员
+// @Tags 任务模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param name path string true "任务名称"
+// @Success 200 {object} v1.TriggerJobResponse
+// @Router /jobs/{name}/trigger [post]
+func (h *JobHandler) TriggerJob(ctx *gin.Context) {
+	run, err := h.jobService.Trigger(ctx, ctx.Param("name"))
+	if err != nil {
+		h.handleError(ctx, "jobService.Trigger error", err)
+		return
+	}
+
+	v1.HandleSuccess(ctx, run)
+}
+
+// PauseJob godoc
+// @Summary 暂停任务
+// @Schemes
+// @Description 暂停后所有任务服务实例都跳过该任务的定时执行。仅限 security.admin_user_ids 中的管理员
+// @Tags 任务模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param name path string true "任务名称"
+// @Success 200 {object} v1.Response
+// @Router /jobs/{name}/pause [post]
+func (h *JobHandler) PauseJob(ctx *gin.Context) {
+	if err := h.jobService.Pause(ctx, ctx.Param("name")); err != nil {
+		h.handleError(ctx, "jobService.Pause error", err)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ResumeJob godoc
+// @Summary 恢复任务
+// @Schemes
+// @Description 仅限 security.admin_user_ids 中的管理员
+// @Tags 任务模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param name path string true "任务名称"
+// @Success 200 {object} v1.Response
+// @Router /jobs/{name}/resume [post]
+func (h *JobHandler) ResumeJob(ctx *gin.Context) {
+	if err := h.jobService.Resume(ctx, ctx.Param("name")); err != nil {
+		h.handleError(ctx, "jobService.Resume error", err)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+func (h *JobHandler) handleError(ctx *gin.Context, msg string, err error) {
+	if errors.Is(err, v1.ErrNotFound) {
+		v1.HandleError(ctx, http.StatusNotFound, v1.ErrNotFound, nil)
+		return
+	}
+	h.logger.WithContext(ctx).Error(msg, zap.Error(err))
+	v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"klineio/api/v1"
+	"klineio/internal/service"
+)
+
+type PaperTradingHandler struct {
+	*Handler
+	paperTradingService *service.PaperTradingService
+}
+
+func NewPaperTradingHandler(handler *Handler, paperTradingService *service.PaperTradingService) *PaperTradingHandler {
+	return &PaperTradingHandler{
+		Handler:             handler,
+		paperTradingService: paperTradingService,
+	}
+}
+
+// ListStrategies godoc
+// @Summary 获取模拟交易策略列表
+// @Schemes
+// @Description 返回所有已配置策略的权益、收益率、盈亏、胜率和最大回撤
+// @Tags 模拟交易模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.ListPaperStrategiesResponse
+// @Router /paper/strategies [get]
+func (h *PaperTradingHandler) ListStrategies(ctx *gin.Context) {
+	strategies, err := h.paperTradingService.ListPerformance(ctx)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("paperTradingService.ListPerformance error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, strategies)
+}
+
+// GetStrategy godoc
+// @Summary 获取模拟交易策略表现
+// @Schemes
+// @Description 返回策略的表现和当前持仓
+// @Tags 模拟交易模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param name path string true "策略名称"
+// @Success 200 {object} v1.GetPaperStrategyResponse
+// @Router /paper/strategies/{name} [get]
+func (h *PaperTradingHandler) GetStrategy(ctx *gin.Context) {
+	perf, err := h.paperTradingService.GetPerformance(ctx, ctx.Param("name"))
+	if err != nil {
+		h.handleError(ctx, "paperTradingService.GetPerformance error", err)
+		return
+	}
+
+	v1.HandleSuccess(ctx, perf)
+}
+
+// ListTrades godoc
+// @Summary 获取模拟交易成交记录
+// @Schemes
+// @Description 按时间倒序返回策略的成交记录
+// @Tags 模拟交易模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param name path string true "策略名称"
+// @Param limit query int false "条数，默认 100"
+// @Success 200 {object} v1.ListPaperTradesResponse
+// @Router /paper/strategies/{name}/trades [get]
+func (h *PaperTradingHandler) ListTrades(ctx *gin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	trades, err := h.paperTradingService.ListTrades(ctx, ctx.Param("name"), limit)
+	if err != nil {
+		h.handleError(ctx, "paperTradingService.ListTrades error", err)
+		return
+	}
+
+	v1.HandleSuccess(ctx, trades)
+}
+
+// ListEquity godoc
+// @Summary 获取模拟交易权益曲线
+// @Schemes
+// @Description
+// @Tags 模拟交易模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param name path string true "策略名称"
+// @Param days query int false "天数，默认 30"
+// @Success 200 {object} v1.ListPaperEquityResponse
+// @Router /paper/strategies/{name}/equity [get]
+func (h *PaperTradingHandler) ListEquity(ctx *gin.Context) {
+	days, err := strconv.Atoi(ctx.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	points, err := h.paperTradingService.ListEquity(ctx, ctx.Param("name"), days)
+	if err != nil {
+		h.handleError(ctx, "paperTradingService.ListEquity error", err)
+		return
+	}
+
+	v1.HandleSuccess(ctx, points)
+}
+
+func (h *PaperTradingHandler) handleError(ctx *gin.Context, msg string, err error) {
+	if errors.Is(err, v1.ErrNotFound) {
+		v1.HandleError(ctx, http.StatusNotFound, v1.ErrNotFound, nil)
+		return
+	}
+	h.logger.WithContext(ctx).Error(msg, zap.Error(err))
+	v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"klineio/api/v1"
+	"klineio/internal/service"
+)
+
+type PortfolioHandler struct {
+	*Handler
+	portfolioService *service.PortfolioService
+}
+
+func NewPortfolioHandler(handler *Handler, portfolioService *service.PortfolioService) *PortfolioHandler {
+	return &PortfolioHandler{
+		Handler:          handler,
+		portfolioService: portfolioService,
+	}
+}
+
+// GetPortfolio godoc
+// @Summary 获取组合估值
+// @Schemes
+// @Description 按最新价格估值当前持仓，并返回仓位占比、1/7/30 日盈亏和回撤
+// @Tags 组合模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.GetPortfolioResponse
+// @Router /portfolio [get]
+func (h *PortfolioHandler) GetPortfolio(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+	if userId == "" {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	portfolio, err := h.portfolioService.GetPortfolio(ctx, userId)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("portfolioService.GetPortfolio error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, portfolio)
+}
+
+// ListSnapshots godoc
+// @Summary 获取组合每日市值
+// @Schemes
+// @Description
+// @Tags 组合模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param days query int false "天数，默认 30"
+// @Success 200 {object} v1.ListPortfolioSnapshotsResponse
+// @Router /portfolio/snapshots [get]
+func (h *PortfolioHandler) ListSnapshots(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+	if userId == "" {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	days, err := strconv.Atoi(ctx.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	snapshots, err := h.portfolioService.ListSnapshots(ctx, userId, days)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("portfolioService.ListSnapshots error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, snapshots)
+}
@@ -0,0 +1,58 @@
+package job
+
+import (
+	"context"
+
+	"klineio/internal/service"
+	"klineio/pkg/broker"
+	"klineio/pkg/event"
+	"klineio/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// AlertJob consumes the alert events published to the broker and delivers them to the notifiers.
+type AlertJob struct {
+	broker broker.Broker
+	alerts *service.AlertService
+	group  string
+	logger *log.Logger
+}
+
+// NewAlertJob creates a new AlertJob. Workers share the alerts of the alerts.consumer_group consumer group,
+// "klineio-notifier" by default, so each alert is delivered once however many workers run.
+func NewAlertJob(b broker.Broker, alerts *service.AlertService, conf *viper.Viper, logger *log.Logger) *AlertJob {
+	group := conf.GetString("alerts.consumer_group")
+	if group == "" {
+		group = "klineio-notifier"
+	}
+	return &AlertJob{broker: b, alerts: alerts, group: group, logger: logger}
+}
+
+// Consume delivers alerts until ctx is done. It only waits for ctx when alerts are delivered directly.
+func (j *AlertJob) Consume(ctx context.Context) error {
+	if !j.alerts.ViaBroker() {
+		j.logger.Info("Alerts are delivered directly, the alert consumer is idle")
+		<-ctx.Done()
+		return nil
+	}
+	j.logger.Info("Consuming alert events", zap.String("topic", event.TopicAlerts), zap.String("group", j.group))
+	return j.broker.Subscribe(ctx, event.TopicAlerts, j.group, j.handle)
+}
+
+func (j *AlertJob) handle(ctx context.Context, msg broker.Message) {
+	e, err := event.Decode(msg.Value)
+	if err != nil {
+		j.logger.Warn("Skipping malformed alert event", zap.Error(err), zap.String("topic", msg.Topic))
+		return
+	}
+	alert, err := e.Alert()
+	if err != nil {
+		j.logger.Warn("Skipping alert event", zap.Error(err), zap.String("id", e.ID))
+		return
+	}
+	if err := j.alerts.Deliver(ctx, *alert); err != nil {
+		j.logger.Error("Failed to deliver alert", zap.Error(err), zap.String("id", e.ID), zap.String("kind", alert.Kind))
+	}
+}
@@ -0,0 +1,79 @@
+package job
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"klineio/internal/service"
+	"klineio/pkg/broker"
+	"klineio/pkg/event"
+	"klineio/pkg/log"
+	"klineio/pkg/notifier"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// recordingNotifier records the titles of the messages it is sent.
+type recordingNotifier struct {
+	mu     sync.Mutex
+	titles []string
+}
+
+func (n *recordingNotifier) SendMarkdownMessage(ctx context.Context, title, text string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.titles = append(n.titles, title)
+	return nil
+}
+
+func (n *recordingNotifier) sent() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.titles...)
+}
+
+func TestAlertJobDeliversPublishedAlerts(t *testing.T) {
+	conf := viper.New()
+	conf.Set("broker.driver", broker.DriverMemory)
+	conf.Set("alerts.delivery", service.AlertDeliveryBroker)
+	logger := &log.Logger{Logger: zap.NewNop()}
+	b := broker.NewMemory()
+	recorder := &recordingNotifier{}
+	alerts := service.NewAlertService(b, []notifier.Notifier{recorder}, nil, logger, conf)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go NewAlertJob(b, alerts, conf, logger).Consume(ctx)
+
+	// Another service subscribing to the alerts receives the event alongside the consumer.
+	other := make(chan broker.Message, 1)
+	go b.Subscribe(ctx, event.TopicAlerts, "other-service", func(ctx context.Context, msg broker.Message) { other <- msg })
+	for {
+		if err := alerts.Send(ctx, event.Alert{Kind: event.KindSpread, Symbol: "BTCUSDT", Title: "跨交易所价差警报！"}); err != nil {
+			t.Fatal(err)
+		}
+		if len(recorder.sent()) > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	select {
+	case msg := <-other:
+		e, err := event.Decode(msg.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a, err := e.Alert(); err != nil || a.Kind != event.KindSpread {
+			t.Errorf("unexpected alert event: %+v, %v", a, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the other subscriber to receive the alert")
+	}
+	if got := recorder.sent()[0]; got != "跨交易所价差警报！" {
+		t.Errorf("unexpected alert delivered: %s", got)
+	}
+}
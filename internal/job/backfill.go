@@ -0,0 +1,37 @@
+package job
+
+import (
+	"context"
+
+	"klineio/internal/service"
+	"klineio/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+// BackfillJob defines the job for refetching recent candles of the stored symbols.
+type BackfillJob struct {
+	backfillSvc *service.BackfillService
+	logger      *log.Logger
+}
+
+// NewBackfillJob creates a new BackfillJob.
+func NewBackfillJob(
+	backfillSvc *service.BackfillService,
+	logger *log.Logger,
+) *BackfillJob {
+	return &BackfillJob{
+		backfillSvc: backfillSvc,
+		logger:      logger,
+	}
+}
+
+// Run executes the backfill job once.
+func (j *BackfillJob) Run(ctx context.Context) error {
+	j.logger.Info("Running BackfillJob once")
+	if err := j.backfillSvc.Run(ctx); err != nil {
+		j.logger.Error("Error running backfill service", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package job
+
+import (
+	"context"
+
+	"klineio/internal/service"
+	"klineio/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+// DigestJob defines the job for sending the daily digest.
+type DigestJob struct {
+	digestSvc *service.DigestService
+	logger    *log.Logger
+}
+
+// NewDigestJob creates a new DigestJob.
+func NewDigestJob(
+	digestSvc *service.DigestService,
+	logger *log.Logger,
+) *DigestJob {
+	return &DigestJob{
+		digestSvc: digestSvc,
+		logger:    logger,
+	}
+}
+
+// Run executes the digest job once.
+func (j *DigestJob) Run(ctx context.Context) error {
+	j.logger.Info("Running DigestJob once")
+	if err := j.digestSvc.Run(ctx); err != nil {
+		j.logger.Error("Error running digest service", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package job
+
+import (
+	"context"
+
+	"klineio/internal/service"
+	"klineio/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+// OrderBookJob defines the job for snapshotting order book depth.
+type OrderBookJob struct {
+	orderBookSvc *service.OrderBookService
+	logger       *log.Logger
+}
+
+// NewOrderBookJob creates a new OrderBookJob.
+func NewOrderBookJob(
+	orderBookSvc *service.OrderBookService,
+	logger *log.Logger,
+) *OrderBookJob {
+	return &OrderBookJob{
+		orderBookSvc: orderBookSvc,
+		logger:       logger,
+	}
+}
+
+// Run executes the order book job once.
+func (j *OrderBookJob) Run(ctx context.Context) error {
+	j.logger.Info("Running OrderBookJob once")
+	if err := j.orderBookSvc.Run(ctx); err != nil {
+		j.logger.Error("Error running order book service", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package job
+
+import (
+	"context"
+
+	"klineio/internal/service"
+	"klineio/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+// PaperTradingJob defines the job for marking paper positions to market and closing them on exits.
+type PaperTradingJob struct {
+	paperTradingSvc *service.PaperTradingService
+	logger          *log.Logger
+}
+
+// NewPaperTradingJob creates a new PaperTradingJob.
+func NewPaperTradingJob(
+	paperTradingSvc *service.PaperTradingService,
+	logger *log.Logger,
+) *PaperTradingJob {
+	return &PaperTradingJob{
+		paperTradingSvc: paperTradingSvc,
+		logger:          logger,
+	}
+}
+
+// Run executes the paper trading job once.
+func (j *PaperTradingJob) Run(ctx context.Context) error {
+	j.logger.Info("Running PaperTradingJob once")
+	if err := j.paperTradingSvc.Run(ctx); err != nil {
+		j.logger.Error("Error running paper trading service", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package job
+
+import (
+	"context"
+
+	"klineio/internal/service"
+	"klineio/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+// PortfolioJob defines the job for valuing and snapshotting portfolios.
+type PortfolioJob struct {
+	portfolioSvc *service.PortfolioService
+	logger       *log.Logger
+}
+
+// NewPortfolioJob creates a new PortfolioJob.
+func NewPortfolioJob(
+	portfolioSvc *service.PortfolioService,
+	logger *log.Logger,
+) *PortfolioJob {
+	return &PortfolioJob{
+		portfolioSvc: portfolioSvc,
+		logger:       logger,
+	}
+}
+
+// Run executes the order book job once.
+func (j *PortfolioJob) Run(ctx context.Context) error {
+	j.logger.Info("Running PortfolioJob once")
+	if err := j.portfolioSvc.Run(ctx); err != nil {
+		j.logger.Error("Error running portfolio service", zap.Error(err))
+		return err
+	}
+	return nil
+}
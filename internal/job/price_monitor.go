@@ -2,7 +2,6 @@ package job
 
 import (
 	"context"
-	"time"
 
 	"klineio/internal/service"
 	"klineio/pkg/log"
@@ -14,7 +13,6 @@ import (
 type PriceMonitorJob struct {
 	priceMonitorSvc *service.PriceMonitorService
 	logger          *log.Logger
-	interval        time.Duration
 }
 
 // NewPriceMonitorJob creates a new PriceMonitorJob.
@@ -25,7 +23,6 @@ func NewPriceMonitorJob(
 	return &PriceMonitorJob{
 		priceMonitorSvc: priceMonitorSvc,
 		logger:          logger,
-		interval:        1 * time.Minute, // Default to 1 minute, can be configured
 	}
 }
 
@@ -0,0 +1,37 @@
+package job
+
+import (
+	"context"
+
+	"klineio/internal/service"
+	"klineio/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+// RetentionJob defines the job for deleting expired market data.
+type RetentionJob struct {
+	retentionSvc *service.RetentionService
+	logger       *log.Logger
+}
+
+// NewRetentionJob creates a new RetentionJob.
+func NewRetentionJob(
+	retentionSvc *service.RetentionService,
+	logger *log.Logger,
+) *RetentionJob {
+	return &RetentionJob{
+		retentionSvc: retentionSvc,
+		logger:       logger,
+	}
+}
+
+// Run executes the retention job once.
+func (j *RetentionJob) Run(ctx context.Context) error {
+	j.logger.Info("Running RetentionJob once")
+	if err := j.retentionSvc.Run(ctx); err != nil {
+		j.logger.Error("Error running retention service", zap.Error(err))
+		return err
+	}
+	return nil
+}
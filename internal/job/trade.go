@@ -0,0 +1,37 @@
+package job
+
+import (
+	"context"
+
+	"klineio/internal/service"
+	"klineio/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+// TradeJob defines the job for polling recent trades.
+type TradeJob struct {
+	tradeSvc *service.TradeService
+	logger   *log.Logger
+}
+
+// NewTradeJob creates a new TradeJob.
+func NewTradeJob(
+	tradeSvc *service.TradeService,
+	logger *log.Logger,
+) *TradeJob {
+	return &TradeJob{
+		tradeSvc: tradeSvc,
+		logger:   logger,
+	}
+}
+
+// Run executes the trade job once.
+func (j *TradeJob) Run(ctx context.Context) error {
+	j.logger.Info("Running TradeJob once")
+	if err := j.tradeSvc.Run(ctx); err != nil {
+		j.logger.Error("Error running trade service", zap.Error(err))
+		return err
+	}
+	return nil
+}
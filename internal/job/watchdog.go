@@ -0,0 +1,32 @@
+package job
+
+import (
+	"context"
+
+	"klineio/internal/service"
+	"klineio/pkg/log"
+)
+
+// WatchdogJob defines the job for checking that prices are still being stored.
+type WatchdogJob struct {
+	watchdogSvc *service.WatchdogService
+	logger      *log.Logger
+}
+
+// NewWatchdogJob creates a new WatchdogJob.
+func NewWatchdogJob(
+	watchdogSvc *service.WatchdogService,
+	logger *log.Logger,
+) *WatchdogJob {
+	return &WatchdogJob{
+		watchdogSvc: watchdogSvc,
+		logger:      logger,
+	}
+}
+
+// Run executes the watchdog job once. Stale prices are reported on the ops channel rather than
+// failing the run.
+func (j *WatchdogJob) Run(ctx context.Context) error {
+	j.watchdogSvc.CheckPrices(ctx)
+	return nil
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+
+	v1 "klineio/api/v1"
+	"klineio/pkg/jwt"
+	"klineio/pkg/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// AdminAuth lets through only the users listed in security.admin_user_ids. It must follow StrictAuth,
+// which sets the claims; with no admin configured, every request is forbidden.
+func AdminAuth(conf *viper.Viper, logger *log.Logger) gin.HandlerFunc {
+	admins := make(map[string]bool)
+	for _, id := range conf.GetStringSlice("security.admin_user_ids") {
+		admins[id] = true
+	}
+	return func(ctx *gin.Context) {
+		claims, _ := ctx.Get("claims")
+		userInfo, ok := claims.(*jwt.MyCustomClaims)
+		if !ok || !admins[userInfo.UserId] {
+			logger.WithContext(ctx).Warn("Not an admin", zap.String("url", ctx.Request.URL.Path))
+			v1.HandleError(ctx, http.StatusForbidden, v1.ErrForbidden, nil)
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
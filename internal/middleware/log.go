@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"klineio/pkg/log"
 	"go.uber.org/zap"
+	oteltrace "go.opentelemetry.io/otel/trace"
 	"io"
 	"time"
 )
@@ -14,11 +15,17 @@ import (
 func RequestLogMiddleware(logger *log.Logger) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// The configuration is initialized once per request
-		uuid, err := random.UUIdV4()
-		if err != nil {
-			return
+		// Reuse the trace ID of the request's span so that logs and traces line up; without one, e.g. when
+		// tracing is disabled and the caller sent no traceparent, fall back to a random ID
+		sc := oteltrace.SpanContextFromContext(ctx.Request.Context())
+		trace := sc.TraceID().String()
+		if !sc.IsValid() {
+			uuid, err := random.UUIdV4()
+			if err != nil {
+				return
+			}
+			trace = cryptor.Md5String(uuid)
 		}
-		trace := cryptor.Md5String(uuid)
 		logger.WithValue(ctx, zap.String("trace", trace))
 		logger.WithValue(ctx, zap.String("request_method", ctx.Request.Method))
 		logger.WithValue(ctx, zap.Any("request_headers", ctx.Request.Header))
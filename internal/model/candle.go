@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Candle is a stored K-line. Only one candle is kept per symbol, exchange, interval and open time.
+type Candle struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+	Symbol    string         `gorm:"type:varchar(20);not null;index:idx_candle_key,unique" json:"symbol"`
+	Exchange  string         `gorm:"type:varchar(20);not null;index:idx_candle_key,unique" json:"exchange"`
+	Interval  string         `gorm:"type:varchar(8);not null;index:idx_candle_key,unique" json:"interval"`
+	OpenTime  int64          `gorm:"not null;index:idx_candle_key,unique" json:"open_time"` // Unix milliseconds
+	Open      float64        `gorm:"type:decimal(20,8);not null" json:"open"`
+	High      float64        `gorm:"type:decimal(20,8);not null" json:"high"`
+	Low       float64        `gorm:"type:decimal(20,8);not null" json:"low"`
+	Close     float64        `gorm:"type:decimal(20,8);not null" json:"close"`
+	Volume    float64        `gorm:"type:decimal(30,8);not null" json:"volume"`
+	CloseTime int64          `gorm:"not null" json:"close_time"` // Unix milliseconds
+}
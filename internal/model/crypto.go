@@ -15,6 +15,7 @@ type ExchangePrice struct {
 	Symbol    string         `gorm:"type:varchar(20);not null;index:idx_symbol_exchange_date,unique" json:"symbol"`   // Modified index
 	Exchange  string         `gorm:"type:varchar(20);not null;index:idx_symbol_exchange_date,unique" json:"exchange"` // Modified index
 	Price     float64        `gorm:"type:decimal(20,8);not null" json:"price"`
+	Volume    float64        `gorm:"type:decimal(30,8);not null;default:0" json:"volume"`      // 24h volume reported with the price
 	Timestamp int64          `gorm:"not null;index" json:"timestamp"`                          // Unix timestamp of the price
 	Date      time.Time      `gorm:"type:date;not null;index:idx_symbol_exchange_date,unique"` // New: Date part of Timestamp for daily unique key
 }
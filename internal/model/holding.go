@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Holding is an asset a user holds outside the exchange accounts klineio reads, e.g., in a hardware wallet.
+// A user has at most one holding per asset and venue.
+type Holding struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+	UserID    string         `gorm:"type:varchar(64);not null;uniqueIndex:idx_holding_user_asset_venue" json:"user_id"`
+	Asset     string         `gorm:"type:varchar(20);not null;uniqueIndex:idx_holding_user_asset_venue" json:"asset"` // e.g., BTC
+	Venue     string         `gorm:"type:varchar(20);not null;uniqueIndex:idx_holding_user_asset_venue" json:"venue"` // e.g., LEDGER; an exchange name prices it there first
+	Quantity  float64        `gorm:"type:decimal(30,8);not null" json:"quantity"`
+	CostBasis float64        `gorm:"type:decimal(20,8);not null;default:0" json:"cost_basis"` // Average cost per unit in USDT, 0 if unknown
+}
@@ -0,0 +1,39 @@
+package model
+
+// Job run triggers.
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual"
+)
+
+// Job run statuses.
+const (
+	JobStatusPending   = "pending" // Triggered through the API, waiting for a task server to pick it up
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+	JobStatusTimedOut  = "timed_out"
+)
+
+// JobRun records one execution of a scheduled job.
+type JobRun struct {
+	ID         uint   `gorm:"primarykey" json:"id"`
+	Name       string `gorm:"type:varchar(100);not null;index:idx_job_run_name" json:"name"`
+	Trigger    string `gorm:"type:varchar(20);not null" json:"trigger"`
+	Status     string `gorm:"type:varchar(20);not null;index" json:"status"`
+	Instance   string `gorm:"type:varchar(100)" json:"instance"` // Task server that ran the job
+	CreatedAt  int64  `gorm:"autoCreateTime:milli" json:"created_at"`
+	StartedAt  int64  `json:"started_at"`  // Unix milliseconds, 0 while pending
+	FinishedAt int64  `json:"finished_at"` // Unix milliseconds, 0 until the run ends
+	DurationMs int64  `json:"duration_ms"`
+	Succeeded  int64  `json:"succeeded"` // Items processed, e.g. symbols
+	Failed     int64  `json:"failed"`
+	Error      string `gorm:"type:text" json:"error"`
+}
+
+// JobState holds the state of a job shared by every task server, such as whether it is paused.
+type JobState struct {
+	Name      string `gorm:"type:varchar(100);primaryKey" json:"name"`
+	Paused    bool   `gorm:"not null" json:"paused"`
+	UpdatedAt int64  `gorm:"autoUpdateTime:milli" json:"updated_at"`
+}
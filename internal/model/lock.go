@@ -0,0 +1,9 @@
+package model
+
+// JobLock is a named lock held by one task server instance until ExpiresAt, so that a job runs on
+// only one instance at a time. Expired locks are taken over by the next instance that asks.
+type JobLock struct {
+	Name      string `gorm:"type:varchar(100);primaryKey" json:"name"`
+	Owner     string `gorm:"type:varchar(100);not null" json:"owner"` // Instance holding the lock
+	ExpiresAt int64  `gorm:"not null" json:"expires_at"`              // Unix milliseconds
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OrderBookSnapshot records the liquidity near the mid price of one symbol on one exchange.
+// Depth values are in the quote asset, e.g., USDT.
+type OrderBookSnapshot struct {
+	ID            uint           `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+	Symbol        string         `gorm:"type:varchar(20);not null;index:idx_book_symbol_time" json:"symbol"` // Canonical symbol, e.g., BTCUSDT
+	Exchange      string         `gorm:"type:varchar(20);not null;index:idx_book_symbol_time" json:"exchange"`
+	MidPrice      float64        `gorm:"type:decimal(20,8);not null" json:"mid_price"`
+	Spread        float64        `gorm:"type:decimal(20,8);not null" json:"spread"`         // Best ask - best bid
+	SpreadPercent float64        `gorm:"type:decimal(10,4);not null" json:"spread_percent"` // Spread relative to the mid price, in percent
+	BidDepth1     float64        `gorm:"type:decimal(30,8);not null" json:"bid_depth_1"`    // Bids within 1% below mid
+	AskDepth1     float64        `gorm:"type:decimal(30,8);not null" json:"ask_depth_1"`    // Asks within 1% above mid
+	BidDepth2     float64        `gorm:"type:decimal(30,8);not null" json:"bid_depth_2"`    // Bids within 2% below mid
+	AskDepth2     float64        `gorm:"type:decimal(30,8);not null" json:"ask_depth_2"`    // Asks within 2% above mid
+	Timestamp     int64          `gorm:"not null;index:idx_book_symbol_time" json:"timestamp"`
+}
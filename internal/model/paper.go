@@ -0,0 +1,78 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Paper trade sides and close reasons.
+const (
+	PaperSideBuy  = "buy"
+	PaperSideSell = "sell"
+
+	PaperReasonTakeProfit = "take_profit"
+	PaperReasonStopLoss   = "stop_loss"
+	PaperReasonMaxHold    = "max_hold"
+)
+
+// PaperAccount holds the virtual cash of a paper trading strategy, one row per strategy.
+type PaperAccount struct {
+	ID                 uint           `gorm:"primarykey" json:"id"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+	Strategy           string         `gorm:"type:varchar(64);not null;uniqueIndex" json:"strategy"`
+	InitialCash        float64        `gorm:"type:decimal(30,8);not null" json:"initial_cash"` // In USDT
+	Cash               float64        `gorm:"type:decimal(30,8);not null" json:"cash"`
+	PeakEquity         float64        `gorm:"type:decimal(30,8);not null;default:0" json:"peak_equity"`
+	MaxDrawdownPercent float64        `gorm:"type:decimal(10,4);not null;default:0" json:"max_drawdown_percent"` // Largest fall of the equity curve from its peak
+}
+
+// PaperPosition is an open position of a paper trading strategy, at most one per strategy and market.
+type PaperPosition struct {
+	ID         uint           `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+	Strategy   string         `gorm:"type:varchar(64);not null;uniqueIndex:idx_paper_position" json:"strategy"`
+	Exchange   string         `gorm:"type:varchar(20);not null;uniqueIndex:idx_paper_position" json:"exchange"`
+	Symbol     string         `gorm:"type:varchar(20);not null;uniqueIndex:idx_paper_position" json:"symbol"`
+	Quantity   float64        `gorm:"type:decimal(30,12);not null" json:"quantity"`
+	EntryPrice float64        `gorm:"type:decimal(20,8);not null" json:"entry_price"` // Fill price including slippage
+	Cost       float64        `gorm:"type:decimal(30,8);not null" json:"cost"`        // USDT spent including fees
+	LastPrice  float64        `gorm:"type:decimal(20,8);not null" json:"last_price"`  // Latest market price seen
+	OpenedAt   int64          `gorm:"not null" json:"opened_at"`                      // Unix milliseconds
+}
+
+// PaperTrade is a virtual fill of a paper trading strategy.
+type PaperTrade struct {
+	ID          uint           `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+	Strategy    string         `gorm:"type:varchar(64);not null;index:idx_paper_trade_strategy_ts" json:"strategy"`
+	Exchange    string         `gorm:"type:varchar(20);not null" json:"exchange"`
+	Symbol      string         `gorm:"type:varchar(20);not null" json:"symbol"`
+	Side        string         `gorm:"type:varchar(4);not null" json:"side"` // PaperSideBuy or PaperSideSell
+	Quantity    float64        `gorm:"type:decimal(30,12);not null" json:"quantity"`
+	Price       float64        `gorm:"type:decimal(20,8);not null" json:"price"`                                      // Fill price including slippage
+	MarketPrice float64        `gorm:"type:decimal(20,8);not null" json:"market_price"`                               // Ticker price at the time of the fill
+	Fee         float64        `gorm:"type:decimal(30,8);not null" json:"fee"`                                        // In USDT
+	RealizedPnL float64        `gorm:"column:realized_pnl;type:decimal(30,8);not null;default:0" json:"realized_pnl"` // Sells only, net of fees
+	Reason      string         `gorm:"type:varchar(64);not null" json:"reason"`                                       // Signal that opened the position, or why it was closed
+	Timestamp   int64          `gorm:"not null;index:idx_paper_trade_strategy_ts" json:"timestamp"`                   // Unix milliseconds
+}
+
+// PaperEquity is a point of a paper trading strategy's equity curve.
+type PaperEquity struct {
+	ID             uint           `gorm:"primarykey" json:"id"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+	Strategy       string         `gorm:"type:varchar(64);not null;index:idx_paper_equity_strategy_ts" json:"strategy"`
+	Cash           float64        `gorm:"type:decimal(30,8);not null" json:"cash"`
+	PositionsValue float64        `gorm:"type:decimal(30,8);not null" json:"positions_value"`
+	Equity         float64        `gorm:"type:decimal(30,8);not null" json:"equity"`
+	Timestamp      int64          `gorm:"not null;index:idx_paper_equity_strategy_ts" json:"timestamp"` // Unix milliseconds
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PortfolioSnapshot records the total value of a user's portfolio, one row per user and day.
+// Later valuations on the same day overwrite it.
+type PortfolioSnapshot struct {
+	ID         uint           `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+	UserID     string         `gorm:"type:varchar(64);not null;uniqueIndex:idx_portfolio_user_date" json:"user_id"`
+	Date       time.Time      `gorm:"type:date;not null;uniqueIndex:idx_portfolio_user_date" json:"date"`
+	TotalValue float64        `gorm:"type:decimal(30,8);not null" json:"total_value"` // In USDT
+	Timestamp  int64          `gorm:"not null" json:"timestamp"`                      // Unix milliseconds of the valuation
+}
+
+// PortfolioPosition records the value of one asset held at one venue within a daily portfolio snapshot.
+type PortfolioPosition struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+	UserID    string         `gorm:"type:varchar(64);not null;index:idx_position_user_date" json:"user_id"`
+	Date      time.Time      `gorm:"type:date;not null;index:idx_position_user_date" json:"date"`
+	Asset     string         `gorm:"type:varchar(20);not null" json:"asset"` // e.g., BTC
+	Venue     string         `gorm:"type:varchar(20);not null" json:"venue"` // Exchange name, or where a manual holding is kept
+	Quantity  float64        `gorm:"type:decimal(30,8);not null" json:"quantity"`
+	Price     float64        `gorm:"type:decimal(20,8);not null" json:"price"` // In USDT, 0 if no price is known
+	Value     float64        `gorm:"type:decimal(30,8);not null" json:"value"`
+}
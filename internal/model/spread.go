@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PriceSpread records the price difference of one instrument between two exchanges at a point in time.
+type PriceSpread struct {
+	ID            uint           `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+	Symbol        string         `gorm:"type:varchar(20);not null;index:idx_spread_symbol_time" json:"symbol"` // Canonical symbol, e.g., BTCUSDT
+	ExchangeA     string         `gorm:"type:varchar(20);not null" json:"exchange_a"`
+	ExchangeB     string         `gorm:"type:varchar(20);not null" json:"exchange_b"`
+	PriceA        float64        `gorm:"type:decimal(20,8);not null" json:"price_a"`
+	PriceB        float64        `gorm:"type:decimal(20,8);not null" json:"price_b"`
+	Spread        float64        `gorm:"type:decimal(20,8);not null" json:"spread"`         // PriceA - PriceB
+	SpreadPercent float64        `gorm:"type:decimal(10,4);not null" json:"spread_percent"` // Spread relative to the lower price, in percent
+	Timestamp     int64          `gorm:"not null;index:idx_spread_symbol_time" json:"timestamp"`
+}
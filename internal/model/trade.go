@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LargeTrade records a public trade whose notional exceeded the storage threshold.
+type LargeTrade struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+	Symbol    string         `gorm:"type:varchar(20);not null;uniqueIndex:idx_trade_key;index:idx_trade_symbol_time" json:"symbol"` // Canonical symbol, e.g., BTCUSDT
+	Exchange  string         `gorm:"type:varchar(20);not null;uniqueIndex:idx_trade_key" json:"exchange"`
+	TradeID   int64          `gorm:"not null;uniqueIndex:idx_trade_key" json:"trade_id"` // Exchange trade ID; the aggregate trade ID on Binance
+	Price     float64        `gorm:"type:decimal(20,8);not null" json:"price"`
+	Quantity  float64        `gorm:"type:decimal(30,8);not null" json:"quantity"`
+	Notional  float64        `gorm:"type:decimal(30,8);not null" json:"notional"` // Price * Quantity in the quote asset
+	Side      string         `gorm:"type:varchar(4);not null" json:"side"`        // Taker side: buy or sell
+	Timestamp int64          `gorm:"not null;index:idx_trade_symbol_time" json:"timestamp"`
+}
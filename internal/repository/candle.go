@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"klineio/internal/model"
+	"klineio/pkg/log"
+)
+
+type CandleRepository interface {
+	UpsertCandles(ctx context.Context, candles []*model.Candle) error
+	ListCandles(ctx context.Context, symbol, exchange, interval string, from, to int64) ([]*model.Candle, error)
+	ListSymbols(ctx context.Context, exchange, interval string) ([]string, error)
+	DeleteCandlesBefore(ctx context.Context, interval string, before int64) (int64, error)
+}
+
+type candleRepository struct {
+	repo   *Repository
+	logger *log.Logger
+}
+
+func NewCandleRepository(
+	repo *Repository,
+	logger *log.Logger,
+) CandleRepository {
+	return &candleRepository{repo: repo, logger: logger}
+}
+
+func (r *candleRepository) DB(ctx context.Context) *gorm.DB {
+	return r.repo.DB(ctx).Model(&model.Candle{})
+}
+
+// UpsertCandles stores candles, overwriting any previously stored candle with the same key.
+func (r *candleRepository) UpsertCandles(ctx context.Context, candles []*model.Candle) error {
+	if len(candles) == 0 {
+		return nil
+	}
+	err := r.DB(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "symbol"}, {Name: "exchange"}, {Name: "interval"}, {Name: "open_time"}},
+		DoUpdates: clause.AssignmentColumns([]string{"open", "high", "low", "close", "volume", "close_time", "updated_at"}),
+	}).Create(candles).Error
+	if err != nil {
+		return fmt.Errorf("failed to upsert candles: %w", err)
+	}
+	return nil
+}
+
+// ListCandles returns stored candles whose open time is in [from, to) Unix milliseconds, oldest first.
+func (r *candleRepository) ListCandles(ctx context.Context, symbol, exchange, interval string, from, to int64) ([]*model.Candle, error) {
+	var candles []*model.Candle
+	// A map condition lets GORM quote "interval", which is a reserved word in MySQL.
+	err := r.DB(ctx).Where(map[string]interface{}{"symbol": symbol, "exchange": exchange, "interval": interval}).
+		Where("open_time >= ? AND open_time < ?", from, to).
+		Order("open_time ASC").Find(&candles).Error
+	if err != nil {
+		return nil, err
+	}
+	return candles, nil
+}
+
+// ListSymbols returns the symbols with candles of the given interval stored for exchange.
+func (r *candleRepository) ListSymbols(ctx context.Context, exchange, interval string) ([]string, error) {
+	var symbols []string
+	err := r.DB(ctx).Where(map[string]interface{}{"exchange": exchange, "interval": interval}).
+		Distinct().Order("symbol ASC").Pluck("symbol", &symbols).Error
+	if err != nil {
+		return nil, err
+	}
+	return symbols, nil
+}
+
+// DeleteCandlesBefore permanently deletes the candles of the given interval opened before the Unix millisecond timestamp
+// and returns how many were deleted.
+func (r *candleRepository) DeleteCandlesBefore(ctx context.Context, interval string, before int64) (int64, error) {
+	result := r.repo.DB(ctx).Unscoped().Where(map[string]interface{}{"interval": interval}).
+		Where("open_time < ?", before).Delete(&model.Candle{})
+	return result.RowsAffected, result.Error
+}
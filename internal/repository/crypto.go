@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"time"
 
@@ -18,6 +19,10 @@ type ExchangePriceRepository interface {
 	GetLatestExchangePriceBySymbolAndExchange(ctx context.Context, symbol, exchange string) (*model.ExchangePrice, error)
 	GetAveragePriceForLastNDays(ctx context.Context, symbol, exchange string, days int) (float64, error)
 	UpsertExchangePrice(ctx context.Context, price *model.ExchangePrice) error
+	ListExchangePrices(ctx context.Context, symbol, exchange string, since time.Time) ([]*model.ExchangePrice, error)
+	ListExchangePricesSince(ctx context.Context, since time.Time) ([]*model.ExchangePrice, error)
+	DeleteExchangePricesBefore(ctx context.Context, before time.Time) (int64, error)
+	GetLatestTimestamp(ctx context.Context) (int64, error)
 }
 
 type exchangePriceRepository struct {
@@ -91,8 +96,8 @@ func (r *exchangePriceRepository) UpsertExchangePrice(ctx context.Context, price
 	price.Date = actualTimestamp.Truncate(24 * time.Hour) // Use Truncate on time.Time
 
 	err := r.DB(ctx).Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "symbol"}, {Name: "exchange"}, {Name: "date"}},  // Conflict target: symbol, exchange, date
-		DoUpdates: clause.AssignmentColumns([]string{"price", "timestamp", "updated_at"}), // Update price, timestamp, and updated_at on conflict
+		Columns:   []clause.Column{{Name: "symbol"}, {Name: "exchange"}, {Name: "date"}},            // Conflict target: symbol, exchange, date
+		DoUpdates: clause.AssignmentColumns([]string{"price", "volume", "timestamp", "updated_at"}), // Update price, volume, timestamp, and updated_at on conflict
 	}).Create(price).Error
 
 	if err != nil {
@@ -108,3 +113,39 @@ func (r *exchangePriceRepository) UpsertExchangePrice(ctx context.Context, price
 	}
 	return nil
 }
+
+// ListExchangePrices returns the daily price records of a symbol on an exchange since the given date, oldest first.
+func (r *exchangePriceRepository) ListExchangePrices(ctx context.Context, symbol, exchange string, since time.Time) ([]*model.ExchangePrice, error) {
+	var prices []*model.ExchangePrice
+	err := r.DB(ctx).Where("symbol = ? AND exchange = ? AND date >= ?", symbol, exchange, since.UTC().Truncate(24*time.Hour)).
+		Order("date ASC").Find(&prices).Error
+	if err != nil {
+		return nil, err
+	}
+	return prices, nil
+}
+
+// ListExchangePricesSince returns the daily price records of every symbol on every exchange since the given date.
+func (r *exchangePriceRepository) ListExchangePricesSince(ctx context.Context, since time.Time) ([]*model.ExchangePrice, error) {
+	var prices []*model.ExchangePrice
+	err := r.DB(ctx).Where("date >= ?", since.UTC().Truncate(24*time.Hour)).Order("date ASC").Find(&prices).Error
+	if err != nil {
+		return nil, err
+	}
+	return prices, nil
+}
+
+// DeleteExchangePricesBefore permanently deletes the daily price records dated before the given date and returns how many were deleted.
+func (r *exchangePriceRepository) DeleteExchangePricesBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.repo.DB(ctx).Unscoped().Where("date < ?", before.UTC().Truncate(24*time.Hour)).Delete(&model.ExchangePrice{})
+	return result.RowsAffected, result.Error
+}
+
+// GetLatestTimestamp returns the timestamp, in Unix milliseconds, of the most recently stored price on any exchange, or 0 if there is none.
+func (r *exchangePriceRepository) GetLatestTimestamp(ctx context.Context) (int64, error) {
+	var latest sql.NullInt64
+	if err := r.DB(ctx).Select("MAX(timestamp)").Row().Scan(&latest); err != nil {
+		return 0, err
+	}
+	return latest.Int64, nil
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	v1 "klineio/api/v1"
+	"klineio/internal/model"
+	"klineio/pkg/log"
+)
+
+type HoldingRepository interface {
+	Create(ctx context.Context, holding *model.Holding) error
+	Update(ctx context.Context, holding *model.Holding) error
+	Delete(ctx context.Context, userID string, id uint) error
+	GetByID(ctx context.Context, userID string, id uint) (*model.Holding, error)
+	GetByAsset(ctx context.Context, userID, asset, venue string) (*model.Holding, error)
+	ListByUser(ctx context.Context, userID string) ([]*model.Holding, error)
+	ListUserIDs(ctx context.Context) ([]string, error)
+	Upsert(ctx context.Context, holdings []*model.Holding) error
+}
+
+type holdingRepository struct {
+	repo   *Repository
+	logger *log.Logger
+}
+
+func NewHoldingRepository(
+	repo *Repository,
+	logger *log.Logger,
+) HoldingRepository {
+	return &holdingRepository{repo: repo, logger: logger}
+}
+
+func (r *holdingRepository) DB(ctx context.Context) *gorm.DB {
+	return r.repo.DB(ctx).Model(&model.Holding{})
+}
+
+func (r *holdingRepository) Create(ctx context.Context, holding *model.Holding) error {
+	return r.repo.DB(ctx).Create(holding).Error
+}
+
+func (r *holdingRepository) Update(ctx context.Context, holding *model.Holding) error {
+	return r.repo.DB(ctx).Save(holding).Error
+}
+
+// Delete removes the holding permanently, so that the asset and venue can be added again.
+func (r *holdingRepository) Delete(ctx context.Context, userID string, id uint) error {
+	result := r.repo.DB(ctx).Unscoped().Where("user_id = ? AND id = ?", userID, id).Delete(&model.Holding{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return v1.ErrNotFound
+	}
+	return nil
+}
+
+func (r *holdingRepository) GetByID(ctx context.Context, userID string, id uint) (*model.Holding, error) {
+	var holding model.Holding
+	if err := r.DB(ctx).Where("user_id = ? AND id = ?", userID, id).First(&holding).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	return &holding, nil
+}
+
+// GetByAsset returns the user's holding of asset at venue, or nil if there is none.
+func (r *holdingRepository) GetByAsset(ctx context.Context, userID, asset, venue string) (*model.Holding, error) {
+	var holding model.Holding
+	if err := r.DB(ctx).Where("user_id = ? AND asset = ? AND venue = ?", userID, asset, venue).First(&holding).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &holding, nil
+}
+
+func (r *holdingRepository) ListByUser(ctx context.Context, userID string) ([]*model.Holding, error) {
+	var holdings []*model.Holding
+	if err := r.DB(ctx).Where("user_id = ?", userID).Order("asset ASC, venue ASC").Find(&holdings).Error; err != nil {
+		return nil, err
+	}
+	return holdings, nil
+}
+
+// ListUserIDs returns the users that have at least one holding.
+func (r *holdingRepository) ListUserIDs(ctx context.Context) ([]string, error) {
+	var userIDs []string
+	if err := r.DB(ctx).Distinct().Order("user_id ASC").Pluck("user_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+// Upsert creates the holdings, replacing the quantity and cost basis of those that already exist.
+func (r *holdingRepository) Upsert(ctx context.Context, holdings []*model.Holding) error {
+	if len(holdings) == 0 {
+		return nil
+	}
+	err := r.DB(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "asset"}, {Name: "venue"}},
+		DoUpdates: clause.AssignmentColumns([]string{"quantity", "cost_basis", "updated_at"}),
+	}).Create(holdings).Error
+	if err != nil {
+		return fmt.Errorf("failed to upsert holdings: %w", err)
+	}
+	return nil
+}
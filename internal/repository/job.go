@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"klineio/internal/model"
+	"klineio/pkg/log"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type JobRepository interface {
+	CreateRun(ctx context.Context, run *model.JobRun) error
+	// ClaimRun moves a pending run to running for instance, and reports whether this call claimed it.
+	ClaimRun(ctx context.Context, run *model.JobRun) (bool, error)
+	FinishRun(ctx context.Context, run *model.JobRun) error
+	ListRuns(ctx context.Context, name string, limit int) ([]*model.JobRun, error)
+	ListPendingRuns(ctx context.Context) ([]*model.JobRun, error)
+	GetLastRun(ctx context.Context, name string) (*model.JobRun, error)
+	GetLastSucceededRun(ctx context.Context, name string) (*model.JobRun, error)
+	IsPaused(ctx context.Context, name string) (bool, error)
+	SetPaused(ctx context.Context, name string, paused bool) error
+}
+
+type jobRepository struct {
+	repo   *Repository
+	logger *log.Logger
+}
+
+func NewJobRepository(
+	repo *Repository,
+	logger *log.Logger,
+) JobRepository {
+	return &jobRepository{repo: repo, logger: logger}
+}
+
+func (r *jobRepository) CreateRun(ctx context.Context, run *model.JobRun) error {
+	return r.repo.DB(ctx).Create(run).Error
+}
+
+func (r *jobRepository) ClaimRun(ctx context.Context, run *model.JobRun) (bool, error) {
+	result := r.repo.DB(ctx).Model(&model.JobRun{}).Where("id = ? AND status = ?", run.ID, model.JobStatusPending).
+		Updates(map[string]interface{}{"status": model.JobStatusRunning, "instance": run.Instance, "started_at": run.StartedAt})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	run.Status = model.JobStatusRunning
+	return true, nil
+}
+
+func (r *jobRepository) FinishRun(ctx context.Context, run *model.JobRun) error {
+	return r.repo.DB(ctx).Model(run).Select("status", "finished_at", "duration_ms", "succeeded", "failed", "error").Updates(run).Error
+}
+
+// ListRuns returns up to limit of the most recent runs of the job, newest first.
+func (r *jobRepository) ListRuns(ctx context.Context, name string, limit int) ([]*model.JobRun, error) {
+	var runs []*model.JobRun
+	if err := r.repo.DB(ctx).Where("name = ?", name).Order("id DESC").Limit(limit).Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// ListPendingRuns returns the runs triggered through the API that no task server has picked up, oldest first.
+func (r *jobRepository) ListPendingRuns(ctx context.Context) ([]*model.JobRun, error) {
+	var runs []*model.JobRun
+	if err := r.repo.DB(ctx).Where("status = ?", model.JobStatusPending).Order("id ASC").Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// GetLastRun returns the most recent run of the job, or nil if it has never run.
+func (r *jobRepository) GetLastRun(ctx context.Context, name string) (*model.JobRun, error) {
+	return r.lastRun(r.repo.DB(ctx).Where("name = ?", name))
+}
+
+// GetLastSucceededRun returns the most recent successful run of the job, or nil if it has never succeeded.
+func (r *jobRepository) GetLastSucceededRun(ctx context.Context, name string) (*model.JobRun, error) {
+	return r.lastRun(r.repo.DB(ctx).Where("name = ? AND status = ?", name, model.JobStatusSucceeded))
+}
+
+func (r *jobRepository) lastRun(query *gorm.DB) (*model.JobRun, error) {
+	var run model.JobRun
+	err := query.Order("id DESC").First(&run).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *jobRepository) IsPaused(ctx context.Context, name string) (bool, error) {
+	var states []model.JobState
+	if err := r.repo.DB(ctx).Where("name = ?", name).Limit(1).Find(&states).Error; err != nil {
+		return false, err
+	}
+	return len(states) > 0 && states[0].Paused, nil
+}
+
+func (r *jobRepository) SetPaused(ctx context.Context, name string, paused bool) error {
+	return r.repo.DB(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"paused", "updated_at"}),
+	}).Create(&model.JobState{Name: name, Paused: paused}).Error
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"klineio/internal/model"
+	"klineio/pkg/log"
+
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func TestJobRepository(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&model.JobRun{}, &model.JobState{}); err != nil {
+		t.Fatal(err)
+	}
+	logger := &log.Logger{Logger: zap.NewNop()}
+	repo := NewJobRepository(NewRepository(logger, db), logger)
+	ctx := context.Background()
+
+	if last, err := repo.GetLastRun(ctx, "digest"); err != nil || last != nil {
+		t.Fatalf("expected no last run, got %v, %v", last, err)
+	}
+
+	pending := &model.JobRun{Name: "digest", Trigger: model.JobTriggerManual, Status: model.JobStatusPending}
+	if err := repo.CreateRun(ctx, pending); err != nil {
+		t.Fatal(err)
+	}
+	runs, err := repo.ListPendingRuns(ctx)
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("expected one pending run, got %d, %v", len(runs), err)
+	}
+
+	// Only one of two instances polling the same pending run claims it.
+	first, second := *runs[0], *runs[0]
+	first.Instance, second.Instance = "a", "b"
+	if claimed, err := repo.ClaimRun(ctx, &first); err != nil || !claimed {
+		t.Fatalf("expected the first claim to succeed, got %v, %v", claimed, err)
+	}
+	if claimed, err := repo.ClaimRun(ctx, &second); err != nil || claimed {
+		t.Fatalf("expected the second claim to fail, got %v, %v", claimed, err)
+	}
+	if runs, _ := repo.ListPendingRuns(ctx); len(runs) != 0 {
+		t.Errorf("expected no pending runs after the claim, got %d", len(runs))
+	}
+
+	first.Status = model.JobStatusSucceeded
+	first.Succeeded = 3
+	if err := repo.FinishRun(ctx, &first); err != nil {
+		t.Fatal(err)
+	}
+	last, err := repo.GetLastRun(ctx, "digest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.Status != model.JobStatusSucceeded || last.Instance != "a" || last.Succeeded != 3 {
+		t.Errorf("unexpected last run: %+v", last)
+	}
+	if err := repo.CreateRun(ctx, &model.JobRun{Name: "digest", Trigger: model.JobTriggerSchedule, Status: model.JobStatusFailed}); err != nil {
+		t.Fatal(err)
+	}
+	if succeeded, err := repo.GetLastSucceededRun(ctx, "digest"); err != nil || succeeded == nil || succeeded.ID != first.ID {
+		t.Errorf("expected the claimed run as the last successful one, got %+v, %v", succeeded, err)
+	}
+
+	for _, paused := range []bool{true, false} {
+		if err := repo.SetPaused(ctx, "digest", paused); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := repo.IsPaused(ctx, "digest"); err != nil || got != paused {
+			t.Errorf("expected paused %v, got %v, %v", paused, got, err)
+		}
+	}
+	if got, _ := repo.IsPaused(ctx, "retention"); got {
+		t.Error("a job without state should not be paused")
+	}
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"klineio/internal/model"
+	"klineio/pkg/log"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+)
+
+// ErrLockHeld is returned by Locker.TryLock when another instance holds the lock.
+var ErrLockHeld = errors.New("lock is held by another instance")
+
+// Lock is a lock acquired from a Locker.
+type Lock interface {
+	Unlock(ctx context.Context) error
+}
+
+// Locker grants named locks that are exclusive across every instance sharing the same backend.
+type Locker interface {
+	// TryLock acquires key for at most ttl without waiting, or returns ErrLockHeld.
+	TryLock(ctx context.Context, key string, ttl time.Duration) (Lock, error)
+}
+
+// NewLocker returns the Locker configured by tasks.lock.driver: db (default), redis or none.
+// The db driver creates its table if needed, as the task server does not run migrations; the redis
+// driver connects with the data.redis settings.
+func NewLocker(conf *viper.Viper, repo *Repository, logger *log.Logger) (Locker, func(), error) {
+	owner := InstanceID()
+	prefix := conf.GetString("tasks.lock.key_prefix")
+	if prefix == "" {
+		prefix = "klineio:lock:"
+	}
+
+	switch driver := conf.GetString("tasks.lock.driver"); driver {
+	case "", "db":
+		if err := repo.db.AutoMigrate(&model.JobLock{}); err != nil {
+			return nil, nil, fmt.Errorf("failed to migrate job locks: %w", err)
+		}
+		logger.Info("Using database job locks", zap.String("owner", owner))
+		return &dbLocker{repo: repo, owner: owner, prefix: prefix}, func() {}, nil
+	case "redis":
+		rdb := NewRedis(conf)
+		logger.Info("Using Redis job locks", zap.String("owner", owner))
+		return &redisLocker{rdb: rdb, owner: owner, prefix: prefix}, func() { rdb.Close() }, nil
+	case "none":
+		return noopLocker{}, func() {}, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported tasks.lock.driver: %s", driver)
+	}
+}
+
+var instanceID = func() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b))
+}()
+
+// InstanceID identifies this process among the instances sharing the database or lock backend.
+func InstanceID() string {
+	return instanceID
+}
+
+// dbLocker keeps locks in the job_locks table.
+type dbLocker struct {
+	repo   *Repository
+	owner  string
+	prefix string
+}
+
+func (l *dbLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (Lock, error) {
+	name := l.prefix + key
+	now := time.Now()
+	lock := &model.JobLock{Name: name, Owner: l.owner, ExpiresAt: now.Add(ttl).UnixMilli()}
+
+	result := l.repo.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(lock)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to create job lock: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		// Take the lock over if it has expired
+		result = l.repo.DB(ctx).Model(&model.JobLock{}).Where("name = ? AND expires_at < ?", name, now.UnixMilli()).
+			Updates(map[string]interface{}{"owner": l.owner, "expires_at": lock.ExpiresAt})
+		if result.Error != nil {
+			return nil, fmt.Errorf("failed to take over job lock: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil, ErrLockHeld
+		}
+	}
+	return &dbLock{locker: l, name: name}, nil
+}
+
+type dbLock struct {
+	locker *dbLocker
+	name   string
+}
+
+func (l *dbLock) Unlock(ctx context.Context) error {
+	return l.locker.repo.DB(ctx).Where("name = ? AND owner = ?", l.name, l.locker.owner).Delete(&model.JobLock{}).Error
+}
+
+// redisLocker keeps locks as Redis keys that expire with the lock.
+type redisLocker struct {
+	rdb    *redis.Client
+	owner  string
+	prefix string
+}
+
+// unlockScript deletes the lock only if this instance still owns it.
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+func (l *redisLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (Lock, error) {
+	name := l.prefix + key
+	ok, err := l.rdb.SetNX(ctx, name, l.owner, ttl).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to set job lock: %w", err)
+	}
+	if !ok {
+		return nil, ErrLockHeld
+	}
+	return &redisLock{locker: l, name: name}, nil
+}
+
+type redisLock struct {
+	locker *redisLocker
+	name   string
+}
+
+func (l *redisLock) Unlock(ctx context.Context) error {
+	return unlockScript.Run(ctx, l.locker.rdb, []string{l.name}, l.locker.owner).Err()
+}
+
+// noopLocker grants every lock, for deployments with a single task server.
+type noopLocker struct{}
+
+func (noopLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (Lock, error) {
+	return noopLock{}, nil
+}
+
+type noopLock struct{}
+
+func (noopLock) Unlock(ctx context.Context) error { return nil }
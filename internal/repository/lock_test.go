@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"klineio/pkg/log"
+
+	"github.com/glebarez/sqlite"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func TestDBLocker(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := &log.Logger{Logger: zap.NewNop()}
+	repo := NewRepository(logger, db)
+	a, _, err := NewLocker(viper.New(), repo, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every locker of a process shares its instance ID, so stand in for another instance.
+	b := &dbLocker{repo: repo, owner: "other-instance", prefix: "klineio:lock:"}
+	ctx := context.Background()
+
+	lock, err := a.TryLock(ctx, "job", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.TryLock(ctx, "job", time.Minute); !errors.Is(err, ErrLockHeld) {
+		t.Errorf("expected ErrLockHeld while another instance holds the lock, got %v", err)
+	}
+	if _, err := b.TryLock(ctx, "other", time.Minute); err != nil {
+		t.Errorf("locks with different keys should not conflict: %v", err)
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// An expired lock is taken over, and the previous owner's unlock leaves the new lock alone.
+	stale, err := b.TryLock(ctx, "job", -time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.TryLock(ctx, "job", time.Minute); err != nil {
+		t.Fatalf("expected to take over the expired lock: %v", err)
+	}
+	stale.Unlock(ctx)
+	if _, err := b.TryLock(ctx, "job", time.Minute); !errors.Is(err, ErrLockHeld) {
+		t.Errorf("a stale unlock released the new owner's lock: %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"klineio/internal/model"
+	"klineio/pkg/log"
+)
+
+type OrderBookRepository interface {
+	CreateSnapshots(ctx context.Context, snapshots []*model.OrderBookSnapshot) error
+	ListSnapshots(ctx context.Context, symbol, exchange string, since int64) ([]*model.OrderBookSnapshot, error)
+	DeleteSnapshotsBefore(ctx context.Context, before int64) (int64, error)
+}
+
+type orderBookRepository struct {
+	repo   *Repository
+	logger *log.Logger
+}
+
+func NewOrderBookRepository(
+	repo *Repository,
+	logger *log.Logger,
+) OrderBookRepository {
+	return &orderBookRepository{repo: repo, logger: logger}
+}
+
+func (r *orderBookRepository) DB(ctx context.Context) *gorm.DB {
+	return r.repo.DB(ctx).Model(&model.OrderBookSnapshot{})
+}
+
+// CreateSnapshots stores a batch of order book snapshots.
+func (r *orderBookRepository) CreateSnapshots(ctx context.Context, snapshots []*model.OrderBookSnapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+	if err := r.DB(ctx).Create(snapshots).Error; err != nil {
+		return fmt.Errorf("failed to create order book snapshots: %w", err)
+	}
+	return nil
+}
+
+// ListSnapshots returns the snapshots of a symbol on an exchange since the given Unix millisecond timestamp, oldest first.
+func (r *orderBookRepository) ListSnapshots(ctx context.Context, symbol, exchange string, since int64) ([]*model.OrderBookSnapshot, error) {
+	var snapshots []*model.OrderBookSnapshot
+	err := r.DB(ctx).Where("symbol = ? AND exchange = ? AND timestamp >= ?", symbol, exchange, since).Order("timestamp ASC").Find(&snapshots).Error
+	if err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// DeleteSnapshotsBefore permanently deletes the snapshots taken before the Unix millisecond timestamp and returns how many were deleted.
+func (r *orderBookRepository) DeleteSnapshotsBefore(ctx context.Context, before int64) (int64, error) {
+	result := r.repo.DB(ctx).Unscoped().Where("timestamp < ?", before).Delete(&model.OrderBookSnapshot{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"klineio/internal/model"
+	"klineio/pkg/log"
+)
+
+// PaperTradeStats aggregates the trade log of a paper trading strategy.
+type PaperTradeStats struct {
+	Trades      int64
+	Closed      int64 // Sells, i.e., round trips
+	Wins        int64 // Sells with a positive realized PnL
+	Fees        float64
+	RealizedPnL float64 `gorm:"column:realized_pnl"`
+}
+
+type PaperTradingRepository interface {
+	GetAccount(ctx context.Context, strategy string) (*model.PaperAccount, error)
+	CreateAccount(ctx context.Context, account *model.PaperAccount) error
+	ListPositions(ctx context.Context, strategy string) ([]*model.PaperPosition, error)
+	UpdatePositions(ctx context.Context, positions []*model.PaperPosition) error
+	OpenPosition(ctx context.Context, account *model.PaperAccount, trade *model.PaperTrade, position *model.PaperPosition) error
+	ClosePosition(ctx context.Context, account *model.PaperAccount, trade *model.PaperTrade, position *model.PaperPosition) error
+	GetLastTrade(ctx context.Context, strategy, exchange, symbol string) (*model.PaperTrade, error)
+	ListTrades(ctx context.Context, strategy string, limit int) ([]*model.PaperTrade, error)
+	GetTradeStats(ctx context.Context, strategy string) (*PaperTradeStats, error)
+	RecordEquity(ctx context.Context, account *model.PaperAccount, point *model.PaperEquity) error
+	ListEquity(ctx context.Context, strategy string, since int64) ([]*model.PaperEquity, error)
+}
+
+type paperTradingRepository struct {
+	repo   *Repository
+	logger *log.Logger
+}
+
+func NewPaperTradingRepository(
+	repo *Repository,
+	logger *log.Logger,
+) PaperTradingRepository {
+	return &paperTradingRepository{repo: repo, logger: logger}
+}
+
+// GetAccount returns the strategy's account, or nil if it has not traded yet.
+func (r *paperTradingRepository) GetAccount(ctx context.Context, strategy string) (*model.PaperAccount, error) {
+	var account model.PaperAccount
+	if err := r.repo.DB(ctx).Where("strategy = ?", strategy).First(&account).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *paperTradingRepository) CreateAccount(ctx context.Context, account *model.PaperAccount) error {
+	return r.repo.DB(ctx).Create(account).Error
+}
+
+// ListPositions returns the strategy's open positions, oldest first.
+func (r *paperTradingRepository) ListPositions(ctx context.Context, strategy string) ([]*model.PaperPosition, error) {
+	var positions []*model.PaperPosition
+	if err := r.repo.DB(ctx).Where("strategy = ?", strategy).Order("opened_at ASC").Find(&positions).Error; err != nil {
+		return nil, err
+	}
+	return positions, nil
+}
+
+// UpdatePositions stores the last prices of positions.
+func (r *paperTradingRepository) UpdatePositions(ctx context.Context, positions []*model.PaperPosition) error {
+	return r.repo.Transaction(ctx, func(ctx context.Context) error {
+		for _, p := range positions {
+			if err := r.repo.DB(ctx).Model(p).Update("last_price", p.LastPrice).Error; err != nil {
+				return fmt.Errorf("failed to update paper position: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// OpenPosition stores a buy: the account's remaining cash, the trade and the new position.
+func (r *paperTradingRepository) OpenPosition(ctx context.Context, account *model.PaperAccount, trade *model.PaperTrade, position *model.PaperPosition) error {
+	return r.repo.Transaction(ctx, func(ctx context.Context) error {
+		if err := r.repo.DB(ctx).Model(account).Update("cash", account.Cash).Error; err != nil {
+			return fmt.Errorf("failed to update paper account: %w", err)
+		}
+		if err := r.repo.DB(ctx).Create(trade).Error; err != nil {
+			return fmt.Errorf("failed to create paper trade: %w", err)
+		}
+		if err := r.repo.DB(ctx).Create(position).Error; err != nil {
+			return fmt.Errorf("failed to create paper position: %w", err)
+		}
+		return nil
+	})
+}
+
+// ClosePosition stores a sell: the account's cash, the trade, and removes the position.
+func (r *paperTradingRepository) ClosePosition(ctx context.Context, account *model.PaperAccount, trade *model.PaperTrade, position *model.PaperPosition) error {
+	return r.repo.Transaction(ctx, func(ctx context.Context) error {
+		if err := r.repo.DB(ctx).Model(account).Update("cash", account.Cash).Error; err != nil {
+			return fmt.Errorf("failed to update paper account: %w", err)
+		}
+		if err := r.repo.DB(ctx).Create(trade).Error; err != nil {
+			return fmt.Errorf("failed to create paper trade: %w", err)
+		}
+		// Delete permanently so that the market can be entered again.
+		if err := r.repo.DB(ctx).Unscoped().Delete(position).Error; err != nil {
+			return fmt.Errorf("failed to delete paper position: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetLastTrade returns the strategy's most recent trade in a market, or nil if there is none.
+func (r *paperTradingRepository) GetLastTrade(ctx context.Context, strategy, exchange, symbol string) (*model.PaperTrade, error) {
+	var trade model.PaperTrade
+	err := r.repo.DB(ctx).Where("strategy = ? AND exchange = ? AND symbol = ?", strategy, exchange, symbol).
+		Order("timestamp DESC").First(&trade).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &trade, nil
+}
+
+// ListTrades returns up to limit of the strategy's most recent trades, newest first.
+func (r *paperTradingRepository) ListTrades(ctx context.Context, strategy string, limit int) ([]*model.PaperTrade, error) {
+	var trades []*model.PaperTrade
+	if err := r.repo.DB(ctx).Where("strategy = ?", strategy).Order("timestamp DESC, id DESC").Limit(limit).Find(&trades).Error; err != nil {
+		return nil, err
+	}
+	return trades, nil
+}
+
+func (r *paperTradingRepository) GetTradeStats(ctx context.Context, strategy string) (*PaperTradeStats, error) {
+	var stats PaperTradeStats
+	err := r.repo.DB(ctx).Model(&model.PaperTrade{}).Where("strategy = ?", strategy).Select(
+		"COUNT(*) AS trades, "+
+			"COALESCE(SUM(CASE WHEN side = ? THEN 1 ELSE 0 END), 0) AS closed, "+
+			"COALESCE(SUM(CASE WHEN side = ? AND realized_pnl > 0 THEN 1 ELSE 0 END), 0) AS wins, "+
+			"COALESCE(SUM(fee), 0) AS fees, "+
+			"COALESCE(SUM(realized_pnl), 0) AS realized_pnl",
+		model.PaperSideSell, model.PaperSideSell,
+	).Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// RecordEquity appends a point to the equity curve and stores the account's peak and maximum drawdown.
+func (r *paperTradingRepository) RecordEquity(ctx context.Context, account *model.PaperAccount, point *model.PaperEquity) error {
+	return r.repo.Transaction(ctx, func(ctx context.Context) error {
+		err := r.repo.DB(ctx).Model(account).Updates(map[string]interface{}{
+			"peak_equity":          account.PeakEquity,
+			"max_drawdown_percent": account.MaxDrawdownPercent,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to update paper account: %w", err)
+		}
+		if err := r.repo.DB(ctx).Create(point).Error; err != nil {
+			return fmt.Errorf("failed to create paper equity: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListEquity returns the strategy's equity curve since the given Unix milliseconds, oldest first.
+func (r *paperTradingRepository) ListEquity(ctx context.Context, strategy string, since int64) ([]*model.PaperEquity, error) {
+	var points []*model.PaperEquity
+	if err := r.repo.DB(ctx).Where("strategy = ? AND timestamp >= ?", strategy, since).Order("timestamp ASC").Find(&points).Error; err != nil {
+		return nil, err
+	}
+	return points, nil
+}
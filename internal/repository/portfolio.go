@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"klineio/internal/model"
+	"klineio/pkg/log"
+)
+
+type PortfolioRepository interface {
+	SaveSnapshot(ctx context.Context, snapshot *model.PortfolioSnapshot, positions []*model.PortfolioPosition) error
+	ListSnapshots(ctx context.Context, userID string, since time.Time) ([]*model.PortfolioSnapshot, error)
+	ListPositions(ctx context.Context, userID string, date time.Time) ([]*model.PortfolioPosition, error)
+}
+
+type portfolioRepository struct {
+	repo   *Repository
+	logger *log.Logger
+}
+
+func NewPortfolioRepository(
+	repo *Repository,
+	logger *log.Logger,
+) PortfolioRepository {
+	return &portfolioRepository{repo: repo, logger: logger}
+}
+
+func (r *portfolioRepository) DB(ctx context.Context) *gorm.DB {
+	return r.repo.DB(ctx).Model(&model.PortfolioSnapshot{})
+}
+
+// SaveSnapshot upserts the user's snapshot for its date and replaces the positions stored for that date.
+func (r *portfolioRepository) SaveSnapshot(ctx context.Context, snapshot *model.PortfolioSnapshot, positions []*model.PortfolioPosition) error {
+	return r.repo.Transaction(ctx, func(ctx context.Context) error {
+		err := r.DB(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "date"}},
+			DoUpdates: clause.AssignmentColumns([]string{"total_value", "timestamp", "updated_at"}),
+		}).Create(snapshot).Error
+		if err != nil {
+			return fmt.Errorf("failed to upsert portfolio snapshot: %w", err)
+		}
+
+		err = r.repo.DB(ctx).Unscoped().Where("user_id = ? AND date = ?", snapshot.UserID, snapshot.Date).Delete(&model.PortfolioPosition{}).Error
+		if err != nil {
+			return fmt.Errorf("failed to delete portfolio positions: %w", err)
+		}
+		if len(positions) == 0 {
+			return nil
+		}
+		if err := r.repo.DB(ctx).Create(positions).Error; err != nil {
+			return fmt.Errorf("failed to create portfolio positions: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListSnapshots returns the user's daily snapshots since the given date, oldest first.
+func (r *portfolioRepository) ListSnapshots(ctx context.Context, userID string, since time.Time) ([]*model.PortfolioSnapshot, error) {
+	var snapshots []*model.PortfolioSnapshot
+	err := r.DB(ctx).Where("user_id = ? AND date >= ?", userID, since).Order("date ASC").Find(&snapshots).Error
+	if err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// ListPositions returns the positions stored with the user's snapshot of the given date.
+func (r *portfolioRepository) ListPositions(ctx context.Context, userID string, date time.Time) ([]*model.PortfolioPosition, error) {
+	var positions []*model.PortfolioPosition
+	err := r.repo.DB(ctx).Where("user_id = ? AND date = ?", userID, date).Order("value DESC").Find(&positions).Error
+	if err != nil {
+		return nil, err
+	}
+	return positions, nil
+}
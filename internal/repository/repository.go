@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"klineio/pkg/log"
+	"klineio/pkg/metrics"
+	"klineio/pkg/tracing"
 	"klineio/pkg/zapgorm2"
 	"time"
 
@@ -101,6 +103,13 @@ func NewDB(conf *viper.Viper, l *log.Logger) *gorm.DB {
 	if err != nil {
 		panic(err)
 	}
+	// Trace every query as a child of the span in its context; values stay out of the recorded statements
+	if err := db.Use(tracing.GormPlugin{System: driver}); err != nil {
+		panic(err)
+	}
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		panic(err)
+	}
 	db = db.Debug()
 
 	// Connection Pool config
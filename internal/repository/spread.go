@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"klineio/internal/model"
+	"klineio/pkg/log"
+)
+
+type PriceSpreadRepository interface {
+	CreatePriceSpreads(ctx context.Context, spreads []*model.PriceSpread) error
+	ListPriceSpreads(ctx context.Context, symbol string, since int64) ([]*model.PriceSpread, error)
+	DeletePriceSpreadsBefore(ctx context.Context, before int64) (int64, error)
+}
+
+type priceSpreadRepository struct {
+	repo   *Repository
+	logger *log.Logger
+}
+
+func NewPriceSpreadRepository(
+	repo *Repository,
+	logger *log.Logger,
+) PriceSpreadRepository {
+	return &priceSpreadRepository{repo: repo, logger: logger}
+}
+
+func (r *priceSpreadRepository) DB(ctx context.Context) *gorm.DB {
+	return r.repo.DB(ctx).Model(&model.PriceSpread{})
+}
+
+// CreatePriceSpreads stores a batch of spread observations.
+func (r *priceSpreadRepository) CreatePriceSpreads(ctx context.Context, spreads []*model.PriceSpread) error {
+	if len(spreads) == 0 {
+		return nil
+	}
+	if err := r.DB(ctx).Create(spreads).Error; err != nil {
+		return fmt.Errorf("failed to create price spreads: %w", err)
+	}
+	return nil
+}
+
+// ListPriceSpreads returns the spread history of a symbol since the given Unix millisecond timestamp, oldest first.
+func (r *priceSpreadRepository) ListPriceSpreads(ctx context.Context, symbol string, since int64) ([]*model.PriceSpread, error) {
+	var spreads []*model.PriceSpread
+	err := r.DB(ctx).Where("symbol = ? AND timestamp >= ?", symbol, since).Order("timestamp ASC").Find(&spreads).Error
+	if err != nil {
+		return nil, err
+	}
+	return spreads, nil
+}
+
+// DeletePriceSpreadsBefore permanently deletes the spreads recorded before the Unix millisecond timestamp and returns how many were deleted.
+func (r *priceSpreadRepository) DeletePriceSpreadsBefore(ctx context.Context, before int64) (int64, error) {
+	result := r.repo.DB(ctx).Unscoped().Where("timestamp < ?", before).Delete(&model.PriceSpread{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"klineio/internal/model"
+	"klineio/pkg/log"
+)
+
+type TradeRepository interface {
+	CreateLargeTrades(ctx context.Context, trades []*model.LargeTrade) error
+	ListLargeTrades(ctx context.Context, symbol string, since int64) ([]*model.LargeTrade, error)
+	DeleteLargeTradesBefore(ctx context.Context, before int64) (int64, error)
+}
+
+type tradeRepository struct {
+	repo   *Repository
+	logger *log.Logger
+}
+
+func NewTradeRepository(
+	repo *Repository,
+	logger *log.Logger,
+) TradeRepository {
+	return &tradeRepository{repo: repo, logger: logger}
+}
+
+func (r *tradeRepository) DB(ctx context.Context) *gorm.DB {
+	return r.repo.DB(ctx).Model(&model.LargeTrade{})
+}
+
+// CreateLargeTrades stores trades, skipping any already stored under the same exchange, symbol and trade ID.
+func (r *tradeRepository) CreateLargeTrades(ctx context.Context, trades []*model.LargeTrade) error {
+	if len(trades) == 0 {
+		return nil
+	}
+	err := r.DB(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "symbol"}, {Name: "exchange"}, {Name: "trade_id"}},
+		DoNothing: true,
+	}).Create(trades).Error
+	if err != nil {
+		return fmt.Errorf("failed to create large trades: %w", err)
+	}
+	return nil
+}
+
+// ListLargeTrades returns the large trades of a symbol on every exchange since the given Unix millisecond timestamp, oldest first.
+func (r *tradeRepository) ListLargeTrades(ctx context.Context, symbol string, since int64) ([]*model.LargeTrade, error) {
+	var trades []*model.LargeTrade
+	err := r.DB(ctx).Where("symbol = ? AND timestamp >= ?", symbol, since).Order("timestamp ASC").Find(&trades).Error
+	if err != nil {
+		return nil, err
+	}
+	return trades, nil
+}
+
+// DeleteLargeTradesBefore permanently deletes the trades executed before the Unix millisecond timestamp and returns how many were deleted.
+func (r *tradeRepository) DeleteLargeTradesBefore(ctx context.Context, before int64) (int64, error) {
+	result := r.repo.DB(ctx).Unscoped().Where("timestamp < ?", before).Delete(&model.LargeTrade{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+
+	"klineio/internal/handler"
+	"klineio/pkg/log"
+	"klineio/pkg/metrics"
+	"klineio/pkg/server/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// AdminServer serves the operational endpoints, /metrics, /healthz and /readyz, on admin.port so that processes
+// without the HTTP API, like the task binary, expose them too. It does nothing if admin.port is unset.
+type AdminServer struct {
+	*http.Server
+	logger *log.Logger
+	port   int
+}
+
+func NewAdminServer(logger *log.Logger, conf *viper.Viper, healthHandler *handler.HealthHandler) *AdminServer {
+	engine := gin.New()
+	engine.Use(gin.Recovery())
+	s := http.NewServer(
+		engine,
+		logger,
+		http.WithServerHost(conf.GetString("admin.host")),
+		http.WithServerPort(conf.GetInt("admin.port")),
+	)
+	s.GET("/metrics", gin.WrapH(metrics.Handler()))
+	s.GET("/healthz", healthHandler.Healthz)
+	s.GET("/readyz", healthHandler.Readyz)
+	return &AdminServer{Server: s, logger: logger, port: conf.GetInt("admin.port")}
+}
+
+func (a *AdminServer) Start(ctx context.Context) error {
+	if a.port == 0 {
+		return nil
+	}
+	a.logger.Info("Admin server start", zap.Int("port", a.port))
+	return a.Server.Start(ctx)
+}
+
+func (a *AdminServer) Stop(ctx context.Context) error {
+	if a.port == 0 {
+		return nil
+	}
+	return a.Server.Stop(ctx)
+}
@@ -8,8 +8,10 @@ import (
 	"klineio/internal/middleware"
 	"klineio/pkg/jwt"
 	"klineio/pkg/log"
+	"klineio/pkg/metrics"
 	"klineio/pkg/server/http"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -19,6 +21,11 @@ func NewHTTPServer(
 	conf *viper.Viper,
 	jwt *jwt.JWT,
 	userHandler *handler.UserHandler,
+	portfolioHandler *handler.PortfolioHandler,
+	holdingHandler *handler.HoldingHandler,
+	paperTradingHandler *handler.PaperTradingHandler,
+	jobHandler *handler.JobHandler,
+	healthHandler *handler.HealthHandler,
 ) *http.Server {
 	if conf.GetString("env") == "prod" {
 		gin.SetMode(gin.ReleaseMode)
@@ -38,8 +45,12 @@ func NewHTTPServer(
 		ginSwagger.DefaultModelsExpandDepth(-1),
 		ginSwagger.PersistAuthorization(true),
 	))
+	s.GET("/metrics", gin.WrapH(metrics.Handler()))
+	s.GET("/healthz", healthHandler.Healthz)
+	s.GET("/readyz", healthHandler.Readyz)
 
 	s.Use(
+		otelgin.Middleware("klineio"), // After the probes and /metrics, so that they are not traced
 		middleware.CORSMiddleware(),
 		middleware.ResponseLogMiddleware(logger),
 		middleware.RequestLogMiddleware(logger),
@@ -70,6 +81,27 @@ func NewHTTPServer(
 		strictAuthRouter := v1.Group("/").Use(middleware.StrictAuth(jwt, logger))
 		{
 			strictAuthRouter.PUT("/user", userHandler.UpdateProfile)
+			strictAuthRouter.GET("/portfolio", portfolioHandler.GetPortfolio)
+			strictAuthRouter.GET("/portfolio/snapshots", portfolioHandler.ListSnapshots)
+			strictAuthRouter.GET("/holdings", holdingHandler.ListHoldings)
+			strictAuthRouter.POST("/holdings", holdingHandler.CreateHolding)
+			strictAuthRouter.POST("/holdings/import", holdingHandler.ImportHoldings)
+			strictAuthRouter.PUT("/holdings/:id", holdingHandler.UpdateHolding)
+			strictAuthRouter.DELETE("/holdings/:id", holdingHandler.DeleteHolding)
+			strictAuthRouter.GET("/paper/strategies", paperTradingHandler.ListStrategies)
+			strictAuthRouter.GET("/paper/strategies/:name", paperTradingHandler.GetStrategy)
+			strictAuthRouter.GET("/paper/strategies/:name/trades", paperTradingHandler.ListTrades)
+			strictAuthRouter.GET("/paper/strategies/:name/equity", paperTradingHandler.ListEquity)
+			strictAuthRouter.GET("/jobs", jobHandler.ListJobs)
+			strictAuthRouter.GET("/jobs/:name/runs", jobHandler.ListRuns)
+		}
+
+		// Admin routing group, for the users in security.admin_user_ids
+		adminRouter := v1.Group("/").Use(middleware.StrictAuth(jwt, logger), middleware.AdminAuth(conf, logger))
+		{
+			adminRouter.POST("/jobs/:name/trigger", jobHandler.TriggerJob)
+			adminRouter.POST("/jobs/:name/pause", jobHandler.PauseJob)
+			adminRouter.POST("/jobs/:name/resume", jobHandler.ResumeJob)
 		}
 	}
 
@@ -6,27 +6,25 @@ import (
 	"klineio/pkg/log"
 )
 
+// JobServer runs the background job workers.
 type JobServer struct {
-	log     *log.Logger
-	userJob job.UserJob
+	log      *log.Logger
+	alertJob *job.AlertJob
 }
 
 func NewJobServer(
 	log *log.Logger,
-	userJob job.UserJob,
+	alertJob *job.AlertJob,
 ) *JobServer {
 	return &JobServer{
-		log:     log,
-		userJob: userJob,
+		log:      log,
+		alertJob: alertJob,
 	}
 }
 
 func (j *JobServer) Start(ctx context.Context) error {
-	// Tips: If you want job to start as a separate process, just refer to the task implementation and adjust the code accordingly.
-
-	// eg: kafka consumer
-	err := j.userJob.KafkaConsumer(ctx)
-	return err
+	// Deliver the alerts the monitors publish to the broker
+	return j.alertJob.Consume(ctx)
 }
 func (j *JobServer) Stop(ctx context.Context) error {
 	return nil
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"klineio/pkg/server"
+	"klineio/pkg/server/http"
+)
+
+// Roles a process can run. A small deployment runs them all in one process, a large one splits them
+// across replicas, e.g. several http instances and a single task instance.
+const (
+	RoleHTTP   = "http"   // The HTTP API
+	RoleTask   = "task"   // The scheduler running the monitoring jobs
+	RoleWorker = "worker" // The background job workers
+)
+
+// Roles is the set of roles this process runs.
+type Roles map[string]bool
+
+// ParseRoles parses a comma separated list of roles, such as "http,task,worker".
+func ParseRoles(s string) (Roles, error) {
+	roles := make(Roles)
+	for _, role := range strings.Split(s, ",") {
+		role = strings.ToLower(strings.TrimSpace(role))
+		switch role {
+		case "":
+			continue
+		case RoleHTTP, RoleTask, RoleWorker:
+			roles[role] = true
+		default:
+			return nil, fmt.Errorf("unknown role %q, expected %s, %s or %s", role, RoleHTTP, RoleTask, RoleWorker)
+		}
+	}
+	if len(roles) == 0 {
+		return nil, fmt.Errorf("no roles given")
+	}
+	return roles, nil
+}
+
+// String lists the roles in a fixed order.
+func (r Roles) String() string {
+	var names []string
+	for _, role := range []string{RoleHTTP, RoleTask, RoleWorker} {
+		if r[role] {
+			names = append(names, role)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// Servers returns the admin server and the servers of the roles, in the order of String.
+func (r Roles) Servers(adminServer *AdminServer, httpServer *http.Server, taskServer *TaskServer, jobServer *JobServer) []server.Server {
+	servers := []server.Server{adminServer}
+	if r[RoleHTTP] {
+		servers = append(servers, httpServer)
+	}
+	if r[RoleTask] {
+		servers = append(servers, taskServer)
+	}
+	if r[RoleWorker] {
+		servers = append(servers, jobServer)
+	}
+	return servers
+}
@@ -0,0 +1,25 @@
+package server
+
+import "testing"
+
+func TestParseRoles(t *testing.T) {
+	roles, err := ParseRoles(" worker, HTTP,task,,http")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := roles.String(); got != "http,task,worker" {
+		t.Errorf("expected http,task,worker, got %s", got)
+	}
+
+	taskServer := &TaskServer{}
+	servers := Roles{RoleTask: true}.Servers(nil, nil, taskServer, nil)
+	if len(servers) != 2 || servers[1] != taskServer {
+		t.Errorf("expected the admin and task servers, got %v", servers)
+	}
+
+	for _, s := range []string{"", " , ", "http,cron"} {
+		if _, err := ParseRoles(s); err == nil {
+			t.Errorf("expected an error for %q", s)
+		}
+	}
+}
@@ -2,13 +2,22 @@ package server
 
 import (
 	"context"
+	"errors"
 	"klineio/internal/job"
+	"klineio/internal/model"
+	"klineio/internal/repository"
 	"klineio/internal/task"
 	"klineio/pkg/log"
+	"klineio/pkg/metrics"
+	"klineio/pkg/runstats"
+	"klineio/pkg/tracing"
+	"sync"
 	"time"
 
 	"github.com/go-co-op/gocron"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -16,23 +25,77 @@ type TaskServer struct {
 	log             *log.Logger
 	conf            *viper.Viper // Add conf field
 	scheduler       *gocron.Scheduler
+	locker          repository.Locker
+	jobRepo         repository.JobRepository
+	running         sync.Map // Names of the jobs running in this process
 	userTask        task.UserTask
 	priceMonitorJob *job.PriceMonitorJob // Add PriceMonitorJob
+	orderBookJob    *job.OrderBookJob
+	tradeJob        *job.TradeJob
+	portfolioJob    *job.PortfolioJob
+	paperTradingJob *job.PaperTradingJob
+	digestJob       *job.DigestJob
+	backfillJob     *job.BackfillJob
+	retentionJob    *job.RetentionJob
+	watchdogJob     *job.WatchdogJob
 }
 
 func NewTaskServer(
 	log *log.Logger,
 	conf *viper.Viper, // Add conf parameter
+	locker repository.Locker,
+	jobRepo repository.JobRepository,
 	userTask task.UserTask,
 	priceMonitorJob *job.PriceMonitorJob, // Add priceMonitorJob as a parameter
+	orderBookJob *job.OrderBookJob,
+	tradeJob *job.TradeJob,
+	portfolioJob *job.PortfolioJob,
+	paperTradingJob *job.PaperTradingJob,
+	digestJob *job.DigestJob,
+	backfillJob *job.BackfillJob,
+	retentionJob *job.RetentionJob,
+	watchdogJob *job.WatchdogJob,
 ) *TaskServer {
 	return &TaskServer{
 		log:             log,
 		conf:            conf, // Assign conf
+		locker:          locker,
+		jobRepo:         jobRepo,
 		userTask:        userTask,
 		priceMonitorJob: priceMonitorJob, // Assign priceMonitorJob
+		orderBookJob:    orderBookJob,
+		tradeJob:        tradeJob,
+		portfolioJob:    portfolioJob,
+		paperTradingJob: paperTradingJob,
+		digestJob:       digestJob,
+		backfillJob:     backfillJob,
+		retentionJob:    retentionJob,
+		watchdogJob:     watchdogJob,
 	}
 }
+
+// runners maps the name of every job to its Run method.
+func (t *TaskServer) runners() map[string]func(ctx context.Context) error {
+	return map[string]func(ctx context.Context) error{
+		task.JobPriceMonitor: t.priceMonitorJob.Run,
+		task.JobOrderBook:    t.orderBookJob.Run,
+		task.JobTrades:       t.tradeJob.Run,
+		task.JobPortfolio:    t.portfolioJob.Run,
+		task.JobPaperTrading: t.paperTradingJob.Run,
+		task.JobDailyDigest:  t.digestJob.Run,
+		task.JobBackfill:     t.backfillJob.Run,
+		task.JobRetention:    t.retentionJob.Run,
+		task.JobWatchdog:     t.watchdogJob.Run,
+	}
+}
+
+// triggerPollInterval is how often the database is checked for runs triggered through the API.
+const triggerPollInterval = 5 * time.Second
+
+// lockTTLMargin keeps a singleton job's lock past its timeout, for a job that is slow to stop once
+// its context ends and for the work a run does after that, such as storing what it collected.
+const lockTTLMargin = time.Minute
+
 func (t *TaskServer) Start(ctx context.Context) error {
 	gocron.SetPanicHandler(func(jobName string, recoverData interface{}) {
 		t.log.Error("TaskServer Panic", zap.String("job", jobName), zap.Any("recover", recoverData))
@@ -40,21 +103,30 @@ func (t *TaskServer) Start(ctx context.Context) error {
 
 	// Initialize a new scheduler
 	s := gocron.NewScheduler(time.UTC)
+	t.scheduler = s
 
-	// Use the configured timeout for the context passed to jobs
-	jobTimeout := time.Duration(t.conf.GetInt("price_monitor.timeout_seconds")) * time.Second
-
-	// Schedule the PriceMonitorJob to run every 5 minutes
-	_, err := s.Every(5).Minutes().Do(func() {
-		jobCtx, cancel := context.WithTimeout(context.Background(), jobTimeout)
-		defer cancel()
-
-		err := t.priceMonitorJob.Run(jobCtx)
+	runners := t.runners()
+	schedules := make(map[string]task.Schedule)
+	for _, def := range task.Definitions(t.conf) {
+		sched, err := task.LoadSchedule(t.conf, def.Name, def.Defaults)
 		if err != nil {
-			t.log.WithContext(jobCtx).Error("PriceMonitorJob error", zap.Error(err))
+			return err
 		}
-	})
+		schedules[def.Name] = sched
+		if !sched.Enabled {
+			t.log.Info("Task disabled", zap.String("job", def.Name))
+			continue
+		}
+		if err := t.schedule(s, def.Name, sched, runners[def.Name]); err != nil {
+			return err
+		}
+		t.log.Info("Task scheduled", zap.String("job", def.Name), zap.Stringer("schedule", sched), zap.Duration("timeout", sched.Timeout))
+	}
 
+	// Pick up ad-hoc runs, including those of disabled or paused jobs
+	_, err := s.Every(triggerPollInterval).Name("triggers").Do(func() {
+		t.runTriggered(schedules, runners)
+	})
 	if err != nil {
 		return err
 	}
@@ -67,8 +139,166 @@ func (t *TaskServer) Start(ctx context.Context) error {
 	s.Stop()
 	return nil
 }
+
+// schedule adds run to s as name.
+func (t *TaskServer) schedule(s *gocron.Scheduler, name string, sched task.Schedule, run func(ctx context.Context) error) error {
+	if sched.Cron != "" {
+		s = s.Cron(sched.CronSpec())
+		if sched.RunOnStartup {
+			s = s.StartImmediately()
+		}
+	} else {
+		s = s.Every(sched.Interval)
+		if !sched.RunOnStartup {
+			s = s.WaitForSchedule()
+		}
+	}
+
+	_, err := s.Name(name).DoWithJobDetails(func(job gocron.Job) {
+		// gocron sets the last run to the time the run was due
+		metrics.ObserveSchedulerLag(name, time.Since(job.LastRun()))
+		t.execute(name, sched, run, nil)
+	})
+	return err
+}
+
+// runTriggered executes the pending runs triggered through the API.
+func (t *TaskServer) runTriggered(schedules map[string]task.Schedule, runners map[string]func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), triggerPollInterval)
+	pending, err := t.jobRepo.ListPendingRuns(ctx)
+	cancel()
+	if err != nil {
+		t.log.Error("Failed to list triggered task runs", zap.Error(err))
+		return
+	}
+	for _, run := range pending {
+		runner, ok := runners[run.Name]
+		if !ok {
+			continue
+		}
+		go t.execute(run.Name, schedules[run.Name], runner, run)
+	}
+}
+
+// execute runs a job once with a context bounded by the schedule's timeout and records the run in job_runs.
+// triggered is the pending run to claim for an ad-hoc run, or nil for a scheduled one, which is skipped while
+// the job is paused. A singleton job is skipped while it is still running in this process, or while another
+// instance holds its lock; a triggered run then stays pending until it can run.
+func (t *TaskServer) execute(name string, sched task.Schedule, run func(ctx context.Context) error, triggered *model.JobRun) {
+	if sched.Singleton {
+		if _, running := t.running.LoadOrStore(name, true); running {
+			if triggered == nil {
+				t.log.Warn("Skipping task run, the previous run is still in progress", zap.String("job", name))
+			}
+			return
+		}
+		defer t.running.Delete(name)
+	}
+
+	jobCtx, cancel := context.WithTimeout(context.Background(), sched.Timeout)
+	defer cancel()
+
+	if triggered == nil {
+		// Pausing must not depend on the job tables being migrated, so a failed check does not stop the job
+		paused, err := t.jobRepo.IsPaused(jobCtx, name)
+		if err != nil {
+			t.log.Warn("Failed to check whether the task is paused", zap.String("job", name), zap.Error(err))
+		}
+		if paused {
+			t.log.Info("Skipping task run, the task is paused", zap.String("job", name))
+			return
+		}
+	}
+
+	if sched.Singleton {
+		// The lock is released when the run returns; the TTL only matters if the instance dies meanwhile
+		lock, err := t.locker.TryLock(jobCtx, "job:"+name, sched.Timeout+lockTTLMargin)
+		if errors.Is(err, repository.ErrLockHeld) {
+			if triggered == nil {
+				t.log.Info("Skipping task run, another instance is running it", zap.String("job", name))
+			}
+			return
+		}
+		if err != nil {
+			t.log.Error("Failed to acquire task lock", zap.String("job", name), zap.Error(err))
+			return
+		}
+		defer func() {
+			unlockCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := lock.Unlock(unlockCtx); err != nil {
+				t.log.Warn("Failed to release task lock", zap.String("job", name), zap.Error(err))
+			}
+		}()
+	}
+
+	started := time.Now()
+	record := triggered
+	if record == nil {
+		record = &model.JobRun{Name: name, Trigger: model.JobTriggerSchedule, Status: model.JobStatusRunning}
+	}
+	// The root span of the run, so that its exchange requests, queries and alerts share one trace
+	jobCtx, span := tracing.Start(jobCtx, "job "+name, trace.WithAttributes(
+		attribute.String("job.name", name),
+		attribute.String("job.trigger", record.Trigger),
+	))
+	defer span.End()
+	record.Instance = repository.InstanceID()
+	record.StartedAt = started.UnixMilli()
+	if triggered != nil {
+		claimed, err := t.jobRepo.ClaimRun(jobCtx, record)
+		if err != nil {
+			t.log.Error("Failed to claim triggered task run", zap.String("job", name), zap.Error(err))
+			return
+		}
+		if !claimed {
+			return
+		}
+	} else if err := t.jobRepo.CreateRun(jobCtx, record); err != nil {
+		t.log.Warn("Failed to record task run", zap.String("job", name), zap.Error(err))
+		record = nil
+	}
+
+	runCtx, counters := runstats.NewContext(jobCtx)
+	err := run(runCtx)
+	if err != nil {
+		t.log.WithContext(jobCtx).Error("Task error", zap.String("job", name), zap.Error(err))
+	}
+
+	finished := time.Now()
+	status := model.JobStatusSucceeded
+	switch {
+	case errors.Is(jobCtx.Err(), context.DeadlineExceeded):
+		status = model.JobStatusTimedOut
+	case err != nil:
+		status = model.JobStatusFailed
+	}
+	metrics.ObserveJobRun(name, status, finished.Sub(started), counters.Succeeded(), counters.Failed())
+	span.SetAttributes(attribute.String("job.status", status))
+	tracing.End(span, err)
+	if record == nil {
+		return
+	}
+
+	record.Status = status
+	record.FinishedAt = finished.UnixMilli()
+	record.DurationMs = finished.Sub(started).Milliseconds()
+	record.Succeeded = counters.Succeeded()
+	record.Failed = counters.Failed()
+	if err != nil {
+		record.Error = err.Error()
+	}
+	finishCtx, cancelFinish := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFinish()
+	if err := t.jobRepo.FinishRun(finishCtx, record); err != nil {
+		t.log.Warn("Failed to record task run result", zap.String("job", name), zap.Error(err))
+	}
+}
+
 func (t *TaskServer) Stop(ctx context.Context) error {
-	t.scheduler.Stop()
+	if t.scheduler != nil {
+		t.scheduler.Stop()
+	}
 	t.log.Info("TaskServer stop...")
 	return nil
 }
@@ -0,0 +1,198 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"klineio/internal/model"
+	"klineio/internal/repository"
+	"klineio/internal/task"
+	"klineio/pkg/log"
+	"klineio/pkg/runstats"
+
+	"go.uber.org/zap"
+)
+
+// fakeLocker grants locks unless held is set, in which case another instance holds every lock.
+type fakeLocker struct {
+	held bool
+}
+
+func (l *fakeLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (repository.Lock, error) {
+	if l.held {
+		return nil, repository.ErrLockHeld
+	}
+	return fakeLock{}, nil
+}
+
+type fakeLock struct{}
+
+func (fakeLock) Unlock(ctx context.Context) error { return nil }
+
+// fakeJobRepo keeps job runs in memory.
+type fakeJobRepo struct {
+	mu     sync.Mutex
+	runs   []*model.JobRun
+	paused map[string]bool
+}
+
+func (r *fakeJobRepo) CreateRun(ctx context.Context, run *model.JobRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run.ID = uint(len(r.runs) + 1)
+	r.runs = append(r.runs, run)
+	return nil
+}
+
+func (r *fakeJobRepo) ClaimRun(ctx context.Context, run *model.JobRun) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if run.Status != model.JobStatusPending {
+		return false, nil
+	}
+	run.Status = model.JobStatusRunning
+	return true, nil
+}
+
+func (r *fakeJobRepo) FinishRun(ctx context.Context, run *model.JobRun) error { return nil }
+
+func (r *fakeJobRepo) ListRuns(ctx context.Context, name string, limit int) ([]*model.JobRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*model.JobRun(nil), r.runs...), nil
+}
+
+func (r *fakeJobRepo) ListPendingRuns(ctx context.Context) ([]*model.JobRun, error) { return nil, nil }
+
+func (r *fakeJobRepo) GetLastRun(ctx context.Context, name string) (*model.JobRun, error) {
+	return nil, nil
+}
+
+func (r *fakeJobRepo) GetLastSucceededRun(ctx context.Context, name string) (*model.JobRun, error) {
+	return nil, nil
+}
+
+func (r *fakeJobRepo) IsPaused(ctx context.Context, name string) (bool, error) {
+	return r.paused[name], nil
+}
+
+func (r *fakeJobRepo) SetPaused(ctx context.Context, name string, paused bool) error {
+	r.paused[name] = paused
+	return nil
+}
+
+func newTestTaskServer(locker repository.Locker) (*TaskServer, *fakeJobRepo) {
+	jobRepo := &fakeJobRepo{paused: make(map[string]bool)}
+	return &TaskServer{log: &log.Logger{Logger: zap.NewNop()}, locker: locker, jobRepo: jobRepo}, jobRepo
+}
+
+func TestTaskServerExecuteSingleton(t *testing.T) {
+	locker := &fakeLocker{}
+	ts, _ := newTestTaskServer(locker)
+	sched := task.Schedule{Timeout: time.Second, Singleton: true}
+
+	var runs int32
+	release := make(chan struct{})
+	slow := func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		<-release
+		return nil
+	}
+
+	// A second run while the first is in progress is skipped rather than queued.
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ts.execute("slow", sched, slow, nil)
+	}()
+	for atomic.LoadInt32(&runs) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	ts.execute("slow", sched, slow, nil)
+	close(release)
+	wg.Wait()
+	if got := atomic.LoadInt32(&runs); got != 1 {
+		t.Errorf("expected the overlapping run to be skipped, got %d runs", got)
+	}
+
+	// A finished run releases the job.
+	ts.execute("slow", sched, slow, nil)
+	if got := atomic.LoadInt32(&runs); got != 2 {
+		t.Errorf("expected a second run after the first finished, got %d runs", got)
+	}
+
+	// Nothing runs while another instance holds the lock.
+	locker.held = true
+	ts.execute("slow", sched, slow, nil)
+	if got := atomic.LoadInt32(&runs); got != 2 {
+		t.Errorf("expected the run to be skipped while the lock is held, got %d runs", got)
+	}
+	sched.Singleton = false
+	ts.execute("slow", sched, slow, nil)
+	if got := atomic.LoadInt32(&runs); got != 3 {
+		t.Errorf("a job that is not a singleton should ignore the lock, got %d runs", got)
+	}
+}
+
+func TestTaskServerExecuteRecordsRuns(t *testing.T) {
+	ts, jobRepo := newTestTaskServer(&fakeLocker{})
+	sched := task.Schedule{Timeout: 50 * time.Millisecond}
+
+	ts.execute("ok", sched, func(ctx context.Context) error {
+		runstats.Succeeded(ctx)
+		runstats.Succeeded(ctx)
+		runstats.Failed(ctx)
+		return nil
+	}, nil)
+	ts.execute("broken", sched, func(ctx context.Context) error { return errors.New("boom") }, nil)
+	ts.execute("slow", sched, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, nil)
+
+	runs, _ := jobRepo.ListRuns(context.Background(), "", 0)
+	if len(runs) != 3 {
+		t.Fatalf("expected 3 recorded runs, got %d", len(runs))
+	}
+	ok, broken, slow := runs[0], runs[1], runs[2]
+	if ok.Status != model.JobStatusSucceeded || ok.Trigger != model.JobTriggerSchedule || ok.Succeeded != 2 || ok.Failed != 1 {
+		t.Errorf("unexpected successful run: %+v", ok)
+	}
+	if broken.Status != model.JobStatusFailed || broken.Error != "boom" {
+		t.Errorf("unexpected failed run: %+v", broken)
+	}
+	if slow.Status != model.JobStatusTimedOut {
+		t.Errorf("expected the slow run to time out, got %s", slow.Status)
+	}
+	if ok.StartedAt == 0 || ok.FinishedAt < ok.StartedAt || ok.Instance == "" {
+		t.Errorf("expected start, finish and instance to be recorded: %+v", ok)
+	}
+
+	// Scheduled runs are skipped while paused, but a triggered run still goes ahead.
+	jobRepo.SetPaused(context.Background(), "ok", true)
+	var calls int
+	count := func(ctx context.Context) error {
+		calls++
+		return nil
+	}
+	ts.execute("ok", sched, count, nil)
+	if calls != 0 {
+		t.Errorf("expected the scheduled run of a paused job to be skipped")
+	}
+	triggered := &model.JobRun{ID: 99, Name: "ok", Trigger: model.JobTriggerManual, Status: model.JobStatusPending}
+	ts.execute("ok", sched, count, triggered)
+	if calls != 1 || triggered.Status != model.JobStatusSucceeded {
+		t.Errorf("expected the triggered run to run and succeed, got %d calls and status %s", calls, triggered.Status)
+	}
+
+	// A run claimed elsewhere is not run again.
+	ts.execute("ok", sched, count, triggered)
+	if calls != 1 {
+		t.Errorf("expected a claimed run not to run again")
+	}
+}
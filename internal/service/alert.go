@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"klineio/pkg/broker"
+	"klineio/pkg/event"
+	"klineio/pkg/log"
+	"klineio/pkg/metrics"
+	"klineio/pkg/notifier"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// Alert delivery modes selected by alerts.delivery.
+const (
+	AlertDeliveryDirect = "direct" // Monitors send alerts to the notifiers themselves
+	AlertDeliveryBroker = "broker" // Monitors only publish alerts; the alert consumer of a worker sends them
+)
+
+// bestEffortPublishTimeout bounds publishing an event nothing depends on, so that an unreachable
+// broker does not hold up the monitors.
+const bestEffortPublishTimeout = 2 * time.Second
+
+// UserChannel routes the alerts about one user's holdings to their own DingTalk webhook.
+type UserChannel struct {
+	UserID     string `mapstructure:"user_id"`
+	WebhookURL string `mapstructure:"webhook_url"`
+}
+
+// AlertService sends the alerts raised by the monitors. Every alert is published to the broker as an
+// event, and delivered to the notifiers either right away or by the alert consumer, depending on alerts.delivery.
+//
+// Alerts about a user's holdings are kept out of the shared notifiers: they go to the user's channel
+// in alerts.user_channels, or else to alerts.user_webhook_url, and are dropped if neither is set.
+type AlertService struct {
+	broker        broker.Broker
+	notifiers     []notifier.Notifier
+	userNotifiers map[string]notifier.Notifier // Keyed by user ID
+	userDefault   notifier.Notifier            // Per-user alerts of users without a channel of their own
+	logger        *log.Logger
+	viaBroker     bool
+	watchdog      *WatchdogService
+	bestEffort    time.Duration // Timeout of the publishes whose failure is only logged
+}
+
+// NewAlertService creates a new AlertService. Delivery through the broker needs a broker driver and
+// falls back to direct delivery without one.
+func NewAlertService(b broker.Broker, notifiers []notifier.Notifier, watchdog *WatchdogService, logger *log.Logger, conf *viper.Viper) *AlertService {
+	viaBroker := conf.GetString("alerts.delivery") == AlertDeliveryBroker
+	if viaBroker {
+		if driver := conf.GetString("broker.driver"); driver == "" || driver == broker.DriverNone {
+			logger.Warn("alerts.delivery is broker but no broker is configured, delivering alerts directly")
+			viaBroker = false
+		}
+	}
+	s := &AlertService{broker: b, notifiers: notifiers, userNotifiers: make(map[string]notifier.Notifier), logger: logger, viaBroker: viaBroker, watchdog: watchdog, bestEffort: bestEffortPublishTimeout}
+
+	var channels []UserChannel
+	if err := conf.UnmarshalKey("alerts.user_channels", &channels); err != nil {
+		logger.Warn("Invalid alerts.user_channels, ignoring them", zap.Error(err))
+	}
+	for _, c := range channels {
+		if c.UserID == "" || c.WebhookURL == "" {
+			logger.Warn("Ignoring user channel without a user ID or webhook", zap.String("userId", c.UserID))
+			continue
+		}
+		s.userNotifiers[c.UserID] = notifier.NewDingTalkNotifier(c.WebhookURL, logger)
+	}
+	if webhook := conf.GetString("alerts.user_webhook_url"); webhook != "" {
+		s.userDefault = notifier.NewDingTalkNotifier(webhook, logger)
+	}
+	return s
+}
+
+// ViaBroker reports whether alerts are delivered by the alert consumer.
+func (s *AlertService) ViaBroker() bool {
+	return s.viaBroker
+}
+
+// Send publishes a and, unless the alert consumer delivers it, sends it to the notifiers.
+func (s *AlertService) Send(ctx context.Context, a event.Alert) error {
+	metrics.AlertFired(a.Kind, a.Rule)
+	publishCtx := ctx
+	if !s.viaBroker {
+		// The notifiers deliver the alert either way, so the event is only best effort
+		var cancel context.CancelFunc
+		publishCtx, cancel = context.WithTimeout(ctx, s.bestEffort)
+		defer cancel()
+	}
+	err := s.publish(publishCtx, event.TopicAlerts, a.Exchange+":"+a.Symbol, func() (*event.Envelope, error) { return event.NewAlert(a) })
+	if s.viaBroker {
+		if err != nil {
+			return fmt.Errorf("failed to publish alert: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		s.logger.Warn("Failed to publish alert event", zap.Error(err), zap.String("kind", a.Kind))
+	}
+	return s.Deliver(ctx, a)
+}
+
+// Deliver sends a to every notifier, or to the channel of a.UserID for alerts about a user's holdings,
+// and returns the errors of those that failed. The watchdog is told about the outcome, so that failing
+// deliveries are reported on the ops channel.
+func (s *AlertService) Deliver(ctx context.Context, a event.Alert) error {
+	targets := s.notifiers
+	if a.UserID != "" {
+		n := s.userNotifiers[a.UserID]
+		if n == nil {
+			n = s.userDefault
+		}
+		if n == nil {
+			s.logger.Warn("No channel for alerts of user, dropping the alert; set alerts.user_channels or alerts.user_webhook_url",
+				zap.String("kind", a.Kind), zap.String("userId", a.UserID))
+			return nil
+		}
+		targets = []notifier.Notifier{n}
+	}
+
+	var errs []error
+	for _, n := range targets {
+		if err := n.SendMarkdownMessage(ctx, a.Title, a.Text); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	err := errors.Join(errs...)
+	s.watchdog.Delivery(ctx, err)
+	return err
+}
+
+// PublishPrice publishes a price snapshot. Failures are logged, as prices are published on a best-effort basis.
+func (s *AlertService) PublishPrice(ctx context.Context, p event.PriceSnapshot) {
+	ctx, cancel := context.WithTimeout(ctx, s.bestEffort)
+	defer cancel()
+	err := s.publish(ctx, event.TopicPrices, p.Exchange+":"+p.Symbol, func() (*event.Envelope, error) { return event.NewPriceSnapshot(p) })
+	if err != nil {
+		s.logger.Warn("Failed to publish price snapshot", zap.Error(err), zap.String("exchange", p.Exchange), zap.String("symbol", p.Symbol))
+	}
+}
+
+func (s *AlertService) publish(ctx context.Context, topic, key string, build func() (*event.Envelope, error)) error {
+	if _, ok := s.broker.(broker.Nop); ok || s.broker == nil {
+		return nil
+	}
+	e, err := build()
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return s.broker.Publish(ctx, topic, key, value)
+}
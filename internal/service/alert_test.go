@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"klineio/pkg/broker"
+	"klineio/pkg/event"
+	"klineio/pkg/log"
+	"klineio/pkg/notifier"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// countingNotifier counts the messages it is sent and fails them with err.
+type countingNotifier struct {
+	sent int
+	err  error
+}
+
+func (n *countingNotifier) SendMarkdownMessage(ctx context.Context, title, text string) error {
+	n.sent++
+	return n.err
+}
+
+func TestAlertServiceDelivery(t *testing.T) {
+	logger := &log.Logger{Logger: zap.NewNop()}
+	ok, failing := &countingNotifier{}, &countingNotifier{err: errors.New("webhook down")}
+	notifiers := []notifier.Notifier{ok, failing}
+	alert := event.Alert{Kind: event.KindPriceDrop, Exchange: "BINANCE", Symbol: "BTCUSDT"}
+
+	// Direct delivery sends to every notifier and reports the failures.
+	conf := viper.New()
+	s := NewAlertService(broker.Nop{}, notifiers, nil, logger, conf)
+	if err := s.Send(context.Background(), alert); err == nil {
+		t.Error("expected the failing notifier's error")
+	}
+	if ok.sent != 1 || failing.sent != 1 {
+		t.Errorf("expected both notifiers to be sent the alert, got %d and %d", ok.sent, failing.sent)
+	}
+
+	// Broker delivery without a broker falls back to direct delivery.
+	conf.Set("alerts.delivery", AlertDeliveryBroker)
+	if NewAlertService(broker.Nop{}, notifiers, nil, logger, conf).ViaBroker() {
+		t.Error("expected direct delivery without a broker")
+	}
+
+	// With a broker, alerts are only published.
+	conf.Set("broker.driver", broker.DriverMemory)
+	s = NewAlertService(broker.NewMemory(), notifiers, nil, logger, conf)
+	if err := s.Send(context.Background(), alert); err != nil {
+		t.Fatal(err)
+	}
+	if ok.sent != 1 {
+		t.Errorf("expected the alert to be left to the consumer, got %d deliveries", ok.sent)
+	}
+}
+
+func TestAlertServiceUserChannels(t *testing.T) {
+	logger := &log.Logger{Logger: zap.NewNop()}
+	shared, own, fallback := &countingNotifier{}, &countingNotifier{}, &countingNotifier{}
+	conf := viper.New()
+	conf.Set("alerts.user_channels", []map[string]interface{}{{"user_id": "aB3", "webhook_url": "http://127.0.0.1:1/own"}})
+	s := NewAlertService(broker.Nop{}, []notifier.Notifier{shared}, nil, logger, conf)
+	if s.userNotifiers["aB3"] == nil {
+		t.Fatal("expected a channel for user aB3")
+	}
+	s.userNotifiers["aB3"] = own
+	ctx := context.Background()
+
+	// Without a default channel, alerts of users without their own are dropped rather than sent to the shared group.
+	s.Deliver(ctx, event.Alert{Kind: event.KindBelowCostBasis, UserID: "ab3"})
+	s.Deliver(ctx, event.Alert{Kind: event.KindBelowCostBasis, UserID: "aB3"})
+	s.userDefault = fallback
+	s.Deliver(ctx, event.Alert{Kind: event.KindPortfolioDrawdown, UserID: "u2"})
+	s.Deliver(ctx, event.Alert{Kind: event.KindPriceDrop, Exchange: "BINANCE", Symbol: "BTCUSDT"})
+
+	if shared.sent != 1 || own.sent != 1 || fallback.sent != 1 {
+		t.Errorf("expected one alert on each channel, got shared %d, own %d, fallback %d", shared.sent, own.sent, fallback.sent)
+	}
+}
+
+// stuckBroker blocks every publish until its context ends, like an unreachable Kafka.
+type stuckBroker struct {
+	broker.Nop
+}
+
+func (stuckBroker) Publish(ctx context.Context, topic, key string, value []byte) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestAlertServiceBestEffortPublish(t *testing.T) {
+	logger := &log.Logger{Logger: zap.NewNop()}
+	n := &countingNotifier{}
+	s := NewAlertService(stuckBroker{}, []notifier.Notifier{n}, nil, logger, viper.New())
+	s.bestEffort = 10 * time.Millisecond
+
+	// Neither price snapshots nor the events of directly delivered alerts wait for the broker.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.PublishPrice(context.Background(), event.PriceSnapshot{Exchange: "BINANCE", Symbol: "BTCUSDT"})
+		if err := s.Send(context.Background(), event.Alert{Kind: event.KindPriceDrop}); err != nil {
+			t.Error(err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("publishing blocked on the broker")
+	}
+	if n.sent != 1 {
+		t.Errorf("expected the alert to be delivered, got %d deliveries", n.sent)
+	}
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"klineio/internal/model"
+	"klineio/internal/repository"
+	"klineio/pkg/event"
+	"klineio/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// AnomalyKind identifies what kind of statistical anomaly was detected.
+type AnomalyKind string
+
+const (
+	AnomalyReturn AnomalyKind = "return" // Daily log return far outside its usual range
+	AnomalyVolume AnomalyKind = "volume" // 24h volume far above or below its usual level
+)
+
+// AnomalyConfig controls the statistical anomaly detector.
+type AnomalyConfig struct {
+	WindowDays   int     // Number of prior days the latest observation is compared against
+	MinHistory   int     // Minimum prior observations required before anything is flagged
+	ReturnZScore float64 // Flag returns whose |z-score| is at least this many standard deviations
+	VolumeZScore float64 // Flag volumes whose |z-score| is at least this many standard deviations
+}
+
+// Anomaly is one flagged observation.
+type Anomaly struct {
+	Kind   AnomalyKind
+	Date   time.Time
+	Value  float64 // The return or volume that was flagged
+	Mean   float64 // Mean of the comparison window
+	StdDev float64 // Sample standard deviation of the comparison window
+	ZScore float64
+}
+
+// DetectAnomalies compares the latest daily record in history (oldest first) against the preceding window.
+// Returns are measured as log returns between consecutive records; volumes are compared on a log scale
+// so that a single spike does not dominate the window.
+func DetectAnomalies(history []*model.ExchangePrice, cfg AnomalyConfig) []Anomaly {
+	if len(history) < 2 {
+		return nil
+	}
+	latest := history[len(history)-1]
+
+	var returns, volumes []float64
+	for i := 1; i < len(history); i++ {
+		prev, cur := history[i-1].Price, history[i].Price
+		if prev > 0 && cur > 0 {
+			returns = append(returns, math.Log(cur/prev))
+		} else {
+			returns = append(returns, math.NaN())
+		}
+	}
+	for _, p := range history {
+		if p.Volume > 0 {
+			volumes = append(volumes, math.Log(p.Volume))
+		} else {
+			volumes = append(volumes, math.NaN())
+		}
+	}
+
+	var anomalies []Anomaly
+	if a, ok := zScoreOfLast(returns, cfg); ok && cfg.ReturnZScore > 0 && math.Abs(a.ZScore) >= cfg.ReturnZScore {
+		a.Kind, a.Date = AnomalyReturn, latest.Date
+		anomalies = append(anomalies, a)
+	}
+	if a, ok := zScoreOfLast(volumes, cfg); ok && cfg.VolumeZScore > 0 && math.Abs(a.ZScore) >= cfg.VolumeZScore {
+		a.Kind, a.Date = AnomalyVolume, latest.Date
+		a.Value, a.Mean = latest.Volume, math.Exp(a.Mean)
+		anomalies = append(anomalies, a)
+	}
+	return anomalies
+}
+
+// zScoreOfLast scores the last value of series against up to WindowDays values before it, skipping NaNs.
+func zScoreOfLast(series []float64, cfg AnomalyConfig) (Anomaly, bool) {
+	if len(series) < 2 || math.IsNaN(series[len(series)-1]) {
+		return Anomaly{}, false
+	}
+	last := series[len(series)-1]
+	prior := series[:len(series)-1]
+	if cfg.WindowDays > 0 && len(prior) > cfg.WindowDays {
+		prior = prior[len(prior)-cfg.WindowDays:]
+	}
+
+	var values []float64
+	for _, v := range prior {
+		if !math.IsNaN(v) {
+			values = append(values, v)
+		}
+	}
+	if len(values) < max(cfg.MinHistory, 2) {
+		return Anomaly{}, false
+	}
+
+	var mean float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	sd := math.Sqrt(sq / float64(len(values)-1))
+	if sd == 0 {
+		return Anomaly{}, false
+	}
+	return Anomaly{Value: last, Mean: mean, StdDev: sd, ZScore: (last - mean) / sd}, true
+}
+
+// AnomalyMonitorService flags unusual price and volume moves per symbol from stored daily history.
+type AnomalyMonitorService struct {
+	priceRepo repository.ExchangePriceRepository
+	alerts    *AlertService
+	logger    *log.Logger
+	enabled   bool
+	config    AnomalyConfig
+
+	mu      sync.Mutex
+	alerted map[string]time.Time // Date of the last alert, keyed by kind:exchange:symbol
+}
+
+// NewAnomalyMonitorService creates a new AnomalyMonitorService.
+func NewAnomalyMonitorService(
+	priceRepo repository.ExchangePriceRepository,
+	alerts *AlertService,
+	logger *log.Logger,
+	conf *viper.Viper,
+) *AnomalyMonitorService {
+	return &AnomalyMonitorService{
+		priceRepo: priceRepo,
+		alerts:    alerts,
+		logger:    logger,
+		enabled:   conf.GetBool("anomaly_monitor.enabled"),
+		config: AnomalyConfig{
+			WindowDays:   defaultInt(conf.GetInt("anomaly_monitor.window_days"), 30),
+			MinHistory:   defaultInt(conf.GetInt("anomaly_monitor.min_history"), 10),
+			ReturnZScore: conf.GetFloat64("anomaly_monitor.return_z_score"),
+			VolumeZScore: conf.GetFloat64("anomaly_monitor.volume_z_score"),
+		},
+		alerted: make(map[string]time.Time),
+	}
+}
+
+// Check loads the stored history of symbol and alerts on any anomaly in the latest record.
+// Each kind of anomaly is alerted at most once per day per symbol.
+func (s *AnomalyMonitorService) Check(ctx context.Context, exchangeName, symbol string) error {
+	if !s.enabled {
+		return nil
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, -(s.config.WindowDays + 1))
+	history, err := s.priceRepo.ListExchangePrices(ctx, symbol, exchangeName, since)
+	if err != nil {
+		return fmt.Errorf("failed to list price history: %w", err)
+	}
+
+	for _, a := range DetectAnomalies(history, s.config) {
+		if !s.markAlerted(a, exchangeName, symbol) {
+			continue
+		}
+
+		title := "异常波动警报！"
+		var detail string
+		switch a.Kind {
+		case AnomalyReturn:
+			detail = fmt.Sprintf("- **日收益率**: %.2f%% (均值 %.2f%%，标准差 %.2f%%)\n", (math.Exp(a.Value)-1)*100, a.Mean*100, a.StdDev*100)
+		case AnomalyVolume:
+			detail = fmt.Sprintf("- **24h 成交量**: %.2f (近期几何均值 %.2f)\n", a.Value, a.Mean)
+		}
+		text := fmt.Sprintf("### %s (%s) 异常波动警报！\n\n", symbol, exchangeName) +
+			detail +
+			fmt.Sprintf("- **Z 分数**: %.2f\n", a.ZScore) +
+			fmt.Sprintf("- **来源**: 异常检测")
+
+		s.logger.Info("Sending anomaly alert",
+			zap.String("symbol", symbol),
+			zap.String("exchange", exchangeName),
+			zap.String("kind", string(a.Kind)),
+			zap.Float64("zScore", a.ZScore))
+
+		alert := event.Alert{Kind: event.KindAnomaly, Rule: string(a.Kind), Exchange: exchangeName, Symbol: symbol, Value: a.ZScore, Title: title, Text: text}
+		if err := s.alerts.Send(ctx, alert); err != nil {
+			s.logger.Error("Failed to send anomaly alert", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (s *AnomalyMonitorService) markAlerted(a Anomaly, exchangeName, symbol string) bool {
+	key := string(a.Kind) + ":" + overrideKey(exchangeName, symbol)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if last, ok := s.alerted[key]; ok && !a.Date.After(last) {
+		return false
+	}
+	s.alerted[key] = a.Date
+	return true
+}
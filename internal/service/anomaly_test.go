@@ -0,0 +1,42 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"klineio/internal/model"
+)
+
+func priceHistory(prices, volumes []float64) []*model.ExchangePrice {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	history := make([]*model.ExchangePrice, len(prices))
+	for i := range prices {
+		history[i] = &model.ExchangePrice{Price: prices[i], Volume: volumes[i], Date: base.AddDate(0, 0, i)}
+	}
+	return history
+}
+
+func TestDetectAnomalies(t *testing.T) {
+	cfg := AnomalyConfig{WindowDays: 30, MinHistory: 5, ReturnZScore: 3, VolumeZScore: 3}
+
+	prices := []float64{100, 101, 100, 101, 100, 101, 100, 101, 100, 101}
+	volumes := []float64{10, 11, 10, 11, 10, 11, 10, 11, 10, 11}
+	if got := DetectAnomalies(priceHistory(prices, volumes), cfg); len(got) != 0 {
+		t.Errorf("expected no anomalies for a quiet series, got %+v", got)
+	}
+
+	crash := append(append([]float64{}, prices...), 80)
+	spike := append(append([]float64{}, volumes...), 100)
+	got := DetectAnomalies(priceHistory(crash, spike), cfg)
+	if len(got) != 2 || got[0].Kind != AnomalyReturn || got[1].Kind != AnomalyVolume {
+		t.Fatalf("expected return and volume anomalies, got %+v", got)
+	}
+	if got[0].ZScore >= 0 || got[1].ZScore <= 0 {
+		t.Errorf("unexpected z-score signs: %+v", got)
+	}
+
+	cfg.MinHistory = 20
+	if got := DetectAnomalies(priceHistory(crash, spike), cfg); len(got) != 0 {
+		t.Errorf("expected nothing flagged without enough history, got %+v", got)
+	}
+}
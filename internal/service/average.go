@@ -0,0 +1,165 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"klineio/pkg/exchange"
+	"klineio/pkg/indicator"
+)
+
+// AverageMethod identifies how the reference price is derived from K-lines.
+type AverageMethod string
+
+const (
+	AverageSMA     AverageMethod = "sma"     // Simple arithmetic mean of close prices
+	AverageEMA     AverageMethod = "ema"     // Exponential moving average of close prices
+	AverageVWAP    AverageMethod = "vwap"    // Volume-weighted average of typical prices
+	AverageMedian  AverageMethod = "median"  // Median of close prices
+	AverageTypical AverageMethod = "typical" // Mean of (high+low+close)/3
+)
+
+// ParseAverageMethod converts a config value into an AverageMethod. An empty value yields SMA.
+func ParseAverageMethod(s string) (AverageMethod, error) {
+	switch m := AverageMethod(strings.ToLower(strings.TrimSpace(s))); m {
+	case "":
+		return AverageSMA, nil
+	case AverageSMA, AverageEMA, AverageVWAP, AverageMedian, AverageTypical:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unsupported average method: %s", s)
+	}
+}
+
+// AverageConfig describes the reference average a symbol is compared against.
+type AverageConfig struct {
+	Method            AverageMethod
+	Interval          exchange.Interval // K-line interval, e.g., "1d"
+	Lookback          int               // Number of K-lines to average over
+	ExcludeInProgress bool              // Drop the K-line that has not closed yet
+}
+
+// Bars returns how many closed K-lines CalculateAverage needs. The EMA gets a second lookback of
+// history to warm up, as it starts from the SMA of its first period values.
+func (c AverageConfig) Bars() int {
+	if c.Method == AverageEMA {
+		return 2 * c.Lookback
+	}
+	return c.Lookback
+}
+
+// averageOverride is the YAML shape of a per-symbol entry under price_monitor.overrides.
+type averageOverride struct {
+	Symbol            string `mapstructure:"symbol"`
+	Exchange          string `mapstructure:"exchange"`
+	Method            string `mapstructure:"method"`
+	Interval          string `mapstructure:"interval"`
+	Lookback          int    `mapstructure:"lookback"`
+	ExcludeInProgress *bool  `mapstructure:"exclude_in_progress"`
+}
+
+// merge returns base with the fields set in the override applied on top.
+func (o averageOverride) merge(base AverageConfig) (AverageConfig, error) {
+	if o.Method != "" {
+		method, err := ParseAverageMethod(o.Method)
+		if err != nil {
+			return base, err
+		}
+		base.Method = method
+	}
+	if o.Interval != "" {
+		interval, err := exchange.ParseInterval(o.Interval)
+		if err != nil {
+			return base, err
+		}
+		base.Interval = interval
+	}
+	if o.Lookback > 0 {
+		base.Lookback = o.Lookback
+	}
+	if o.ExcludeInProgress != nil {
+		base.ExcludeInProgress = *o.ExcludeInProgress
+	}
+	return base, nil
+}
+
+// overrideKey builds the lookup key for per-symbol average overrides.
+func overrideKey(exchangeName, symbol string) string {
+	return strings.ToUpper(exchangeName) + ":" + strings.ToUpper(symbol)
+}
+
+// PrepareKlines sorts K-lines oldest first and, if requested, drops the bar that is still open at now.
+func PrepareKlines(klines []exchange.Kline, interval exchange.Interval, excludeInProgress bool, now time.Time) []exchange.Kline {
+	sorted := make([]exchange.Kline, len(klines))
+	copy(sorted, klines)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].OpenTime.Before(sorted[j].OpenTime)
+	})
+
+	if !excludeInProgress || len(sorted) == 0 {
+		return sorted
+	}
+	if last := sorted[len(sorted)-1]; !interval.CloseTime(last.OpenTime).Before(now) {
+		sorted = sorted[:len(sorted)-1]
+	}
+	return sorted
+}
+
+// CalculateAverage computes the reference price of klines (oldest first) using cfg.Method over the last
+// cfg.Lookback of them, or all of them if Lookback is unset. The EMA has a period of cfg.Lookback and is
+// fed every K-line, so the ones before the lookback warm it up; see Bars.
+func CalculateAverage(klines []exchange.Kline, cfg AverageConfig) float64 {
+	if len(klines) == 0 {
+		return 0
+	}
+	period := len(klines)
+	if cfg.Lookback > 0 && cfg.Lookback < period {
+		period = cfg.Lookback
+	}
+	if cfg.Method == AverageEMA {
+		ema := indicator.NewEMA(period)
+		for _, k := range klines {
+			ema.Update(k)
+		}
+		return ema.Value()
+	}
+	klines = klines[len(klines)-period:]
+
+	switch cfg.Method {
+	case AverageVWAP:
+		var pv, vol float64
+		for _, k := range klines {
+			pv += (k.High + k.Low + k.Close) / 3 * k.Volume
+			vol += k.Volume
+		}
+		if vol == 0 {
+			return CalculateAverage(klines, AverageConfig{Method: AverageTypical})
+		}
+		return pv / vol
+	case AverageMedian:
+		closes := make([]float64, len(klines))
+		for i, k := range klines {
+			closes[i] = k.Close
+		}
+		sort.Float64s(closes)
+		mid := len(closes) / 2
+		if len(closes)%2 == 0 {
+			return (closes[mid-1] + closes[mid]) / 2
+		}
+		return closes[mid]
+	case AverageTypical:
+		var sum float64
+		for _, k := range klines {
+			sum += (k.High + k.Low + k.Close) / 3
+		}
+		return sum / float64(len(klines))
+	default:
+		var sum float64
+		for _, k := range klines {
+			sum += k.Close
+		}
+		return sum / float64(len(klines))
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"klineio/pkg/exchange"
+)
+
+func testKlines() []exchange.Kline {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	closes := []float64{10, 12, 11, 15}
+	volumes := []float64{1, 2, 3, 4}
+	klines := make([]exchange.Kline, len(closes))
+	for i, c := range closes {
+		klines[i] = exchange.Kline{
+			OpenTime: base.AddDate(0, 0, i),
+			Open:     c,
+			High:     c + 1,
+			Low:      c - 1,
+			Close:    c,
+			Volume:   volumes[i],
+		}
+	}
+	return klines
+}
+
+func TestCalculateAverage(t *testing.T) {
+	klines := testKlines()
+	tests := []struct {
+		method   AverageMethod
+		lookback int
+		want     float64
+	}{
+		{AverageSMA, 0, 12},
+		{AverageSMA, 2, 13},
+		{AverageTypical, 0, 12},
+		{AverageMedian, 0, 11.5},
+		{AverageVWAP, 0, (10*1 + 12*2 + 11*3 + 15*4) / 10.0},
+		// Period 2, seeded with the SMA of the first two closes, 11, then fed 11 and 15
+		{AverageEMA, 2, 2.0/3*15 + 1.0/3*11},
+		// Without warm-up bars the EMA is the SMA
+		{AverageEMA, 0, 12},
+	}
+	for _, tt := range tests {
+		if got := CalculateAverage(klines, AverageConfig{Method: tt.method, Lookback: tt.lookback}); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("CalculateAverage(%s, %d) = %v, want %v", tt.method, tt.lookback, got, tt.want)
+		}
+	}
+	if got := CalculateAverage(nil, AverageConfig{Method: AverageSMA}); got != 0 {
+		t.Errorf("CalculateAverage(nil) = %v, want 0", got)
+	}
+}
+
+func TestPrepareKlines(t *testing.T) {
+	klines := testKlines()
+	reversed := []exchange.Kline{klines[3], klines[2], klines[1], klines[0]}
+
+	// The last bar opened on Jan 4 and is still open at noon that day.
+	now := time.Date(2024, 1, 4, 12, 0, 0, 0, time.UTC)
+	got := PrepareKlines(reversed, "1d", true, now)
+	if len(got) != 3 || !got[0].OpenTime.Equal(klines[0].OpenTime) || !got[2].OpenTime.Equal(klines[2].OpenTime) {
+		t.Errorf("PrepareKlines did not sort and drop the open bar: %+v", got)
+	}
+
+	if got := PrepareKlines(reversed, "1d", false, now); len(got) != 4 {
+		t.Errorf("PrepareKlines dropped a bar with excludeInProgress=false")
+	}
+	if got := PrepareKlines(reversed, "1d", true, now.AddDate(0, 0, 1)); len(got) != 4 {
+		t.Errorf("PrepareKlines dropped a closed bar")
+	}
+}
+
+func TestParseAverageMethod(t *testing.T) {
+	if m, err := ParseAverageMethod(""); err != nil || m != AverageSMA {
+		t.Errorf("empty method should default to sma, got %s %v", m, err)
+	}
+	if m, err := ParseAverageMethod("VWAP"); err != nil || m != AverageVWAP {
+		t.Errorf("expected vwap, got %s %v", m, err)
+	}
+	if _, err := ParseAverageMethod("wma"); err == nil {
+		t.Errorf("expected error for unsupported method")
+	}
+}
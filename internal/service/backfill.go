@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"klineio/internal/repository"
+	"klineio/pkg/exchange"
+	"klineio/pkg/log"
+	"klineio/pkg/runstats"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// BackfillService refetches recent 1m candles of every stored symbol, filling the gaps left by missed
+// monitor runs or downtime, as far back as a single request reaches.
+type BackfillService struct {
+	klineService    *KlineService
+	candleRepo      repository.CandleRepository
+	exchangeClients map[string]exchange.ExchangeClient
+	logger          *log.Logger
+	limit           int
+	apiRequestDelay time.Duration
+}
+
+// NewBackfillService creates a new BackfillService.
+func NewBackfillService(
+	klineService *KlineService,
+	candleRepo repository.CandleRepository,
+	binanceClient *exchange.BinanceClient,
+	okexClient *exchange.OKEXClient,
+	logger *log.Logger,
+	conf *viper.Viper,
+) *BackfillService {
+	return &BackfillService{
+		klineService: klineService,
+		candleRepo:   candleRepo,
+		exchangeClients: map[string]exchange.ExchangeClient{
+			"BINANCE": binanceClient,
+			"OKEX":    okexClient,
+		},
+		logger:          logger,
+		limit:           defaultInt(conf.GetInt("backfill.limit"), 300),
+		apiRequestDelay: time.Duration(conf.GetInt("price_monitor.api_request_delay_ms")) * time.Millisecond,
+	}
+}
+
+// Run backfills every symbol with stored candles. It is a no-op unless kline_store.enabled is set.
+func (s *BackfillService) Run(ctx context.Context) error {
+	if !s.klineService.enabled {
+		return nil
+	}
+	for exchangeName, client := range s.exchangeClients {
+		symbols, err := s.candleRepo.ListSymbols(ctx, exchangeName, BaseInterval.String())
+		if err != nil {
+			s.logger.Error("Failed to list stored symbols", zap.Error(err), zap.String("exchange", exchangeName))
+			continue
+		}
+		var stored int
+		for _, symbol := range symbols {
+			klines, err := client.GetKlines(ctx, symbol, BaseInterval, s.limit)
+			if err != nil {
+				s.logger.Error("Failed to get klines for backfill", zap.Error(err), zap.String("exchange", exchangeName), zap.String("symbol", symbol))
+				runstats.Failed(ctx)
+			} else if err := s.klineService.StoreKlines(ctx, exchangeName, symbol, BaseInterval, PrepareKlines(klines, BaseInterval, true, time.Now())); err != nil {
+				s.logger.Error("Failed to store backfilled klines", zap.Error(err), zap.String("exchange", exchangeName), zap.String("symbol", symbol))
+				runstats.Failed(ctx)
+			} else {
+				stored++
+				runstats.Succeeded(ctx)
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(s.apiRequestDelay):
+			}
+		}
+		s.logger.Info("Backfilled candles", zap.String("exchange", exchangeName), zap.Int("symbols", stored), zap.Int("failed", len(symbols)-stored))
+	}
+	return nil
+}
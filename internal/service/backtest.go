@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"klineio/pkg/exchange"
+	"klineio/pkg/resample"
+)
+
+// BacktestHorizons are the periods forward returns are measured over.
+var BacktestHorizons = []time.Duration{time.Hour, 24 * time.Hour, 7 * 24 * time.Hour}
+
+// SignalPriceDrop names price drop alerts in backtest results. Indicator rule alerts are named after the rule.
+const SignalPriceDrop = "price_drop"
+
+// defaultBacktestStep matches the price monitor schedule.
+const defaultBacktestStep = 5 * time.Minute
+
+// BacktestOptions controls a backtest run.
+type BacktestOptions struct {
+	From     time.Time
+	To       time.Time
+	Step     time.Duration // Interval between simulated price monitor runs, 5m by default
+	Cooldown time.Duration // Minimum time between alerts of one signal for a symbol; 0 alerts on every run like the live monitor
+}
+
+// BacktestAlert is an alert the monitor would have sent.
+type BacktestAlert struct {
+	Time     time.Time
+	Exchange string
+	Symbol   string
+	Signal   string // SignalPriceDrop or the rule name
+	Price    float64
+	Message  string
+	Returns  []float64 // Percent change after each of BacktestHorizons, NaN where the history ends earlier
+}
+
+// HorizonStats summarizes the forward returns of a signal over one horizon.
+type HorizonStats struct {
+	Horizon time.Duration
+	Samples int // Alerts whose history reaches the horizon
+	Mean    float64
+	Median  float64
+	HitRate float64 // Percent of samples followed by a rise
+}
+
+// BacktestSummary summarizes the alerts of one signal.
+type BacktestSummary struct {
+	Signal   string
+	Alerts   int
+	Symbols  int
+	Horizons []HorizonStats
+}
+
+// Backtester replays stored candles through the price monitor's alert checks.
+type Backtester struct {
+	klineService *KlineService
+	settings     AlertSettings
+}
+
+// NewBacktester creates a new Backtester for the given alert settings.
+func NewBacktester(klineService *KlineService, settings AlertSettings) *Backtester {
+	return &Backtester{klineService: klineService, settings: settings}
+}
+
+// Run replays the stored 1m candles of symbol on exchangeName through the price drop check and
+// the indicator rules that apply to it.
+func (b *Backtester) Run(ctx context.Context, exchangeName, symbol string, opts BacktestOptions) ([]BacktestAlert, error) {
+	// Load enough history before From to fill every lookback, and after To for the forward returns.
+	avgCfg := b.settings.AverageFor(exchangeName, symbol)
+	warmup := time.Duration(avgCfg.Bars()+1) * avgCfg.Interval.Duration()
+	for _, rule := range b.settings.Rules {
+		if rule.Matches(exchangeName, symbol) {
+			warmup = max(warmup, time.Duration(rule.Lookback+1)*rule.Bar.Duration())
+		}
+	}
+	to := opts.To.Add(BacktestHorizons[len(BacktestHorizons)-1])
+
+	minutes, err := b.klineService.GetKlines(ctx, exchangeName, symbol, BaseInterval, opts.From.Add(-warmup), to)
+	if err != nil {
+		return nil, err
+	}
+	return Replay(minutes, exchangeName, symbol, b.settings, opts, b.klineService.options)
+}
+
+// Replay runs the price drop check every opts.Step and the indicator rules on every closed bar over minutes,
+// 1m candles sorted oldest first, and returns the alerts raised in [opts.From, opts.To) oldest first.
+// Bars are aligned as configured in resampleOpts.
+func Replay(minutes []exchange.Kline, exchangeName, symbol string, settings AlertSettings, opts BacktestOptions, resampleOpts resample.Options) ([]BacktestAlert, error) {
+	if len(minutes) == 0 {
+		return nil, nil
+	}
+	step := opts.Step
+	if step <= 0 {
+		step = defaultBacktestStep
+	}
+	resampleOpts.DropPartial = true
+
+	var alerts []BacktestAlert
+	lastAlert := make(map[string]time.Time)
+	emit := func(at time.Time, signal string, price float64, message string) {
+		if last, ok := lastAlert[signal]; ok && at.Sub(last) < opts.Cooldown {
+			return
+		}
+		lastAlert[signal] = at
+		alerts = append(alerts, BacktestAlert{Time: at, Exchange: exchangeName, Symbol: symbol, Signal: signal, Price: price, Message: message})
+	}
+
+	// Price drop: compare the latest price with the reference average at every simulated run.
+	avgCfg := settings.AverageFor(exchangeName, symbol)
+	if avgCfg.Interval.IsCalendar() {
+		return nil, fmt.Errorf("%w: cannot backtest the average over calendar interval %s", exchange.ErrUnsupportedInterval, avgCfg.Interval)
+	}
+	period := avgCfg.Interval.Duration()
+	bars, err := resample.Klines(minutes, period, resampleOpts)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		next    int             // First minute not closed at the current run
+		closed  int             // Bars closed at the current run
+		partial *exchange.Kline // Bar of the latest closed minute, built from the minutes closed so far
+	)
+	for t := opts.From.Truncate(step); t.Before(opts.To); t = t.Add(step) {
+		for ; next < len(minutes) && minutes[next].CloseTime.Before(t); next++ {
+			m := minutes[next]
+			start := resample.BucketStart(m.OpenTime, period, resampleOpts.Location)
+			if partial == nil || !partial.OpenTime.Equal(start) {
+				partial = &exchange.Kline{OpenTime: start, Open: m.Open, High: m.High, Low: m.Low, Close: m.Close, Volume: m.Volume}
+			} else {
+				partial.High = max(partial.High, m.High)
+				partial.Low = min(partial.Low, m.Low)
+				partial.Close = m.Close
+				partial.Volume += m.Volume
+			}
+		}
+		for ; closed < len(bars) && bars[closed].CloseTime.Before(t); closed++ {
+		}
+		if t.Before(opts.From) || next == 0 {
+			continue
+		}
+		// The live monitor gets a fresh price on every run; skip runs that fall into a gap in the history.
+		latest := minutes[next-1]
+		if t.Sub(latest.CloseTime) > step+BaseInterval.Duration() {
+			continue
+		}
+
+		window := bars[:closed:closed]
+		if !avgCfg.ExcludeInProgress && partial.OpenTime.Equal(resample.BucketStart(t, period, resampleOpts.Location)) {
+			window = append(window, *partial)
+		}
+		if len(window) < avgCfg.Lookback {
+			continue
+		}
+		window = window[max(0, len(window)-avgCfg.Bars()):]
+		average := CalculateAverage(window, avgCfg)
+		if drop, fired := PriceDrop(latest.Close, average, settings.Threshold); fired {
+			emit(t, SignalPriceDrop, latest.Close, fmt.Sprintf("%.2f%% below the %d x %s %s average %.4f",
+				drop, avgCfg.Lookback, avgCfg.Interval, strings.ToUpper(string(avgCfg.Method)), average))
+		}
+	}
+
+	// Indicator rules: evaluate every closed bar, alerting when the bar closes.
+	for _, rule := range settings.Rules {
+		if !rule.Matches(exchangeName, symbol) {
+			continue
+		}
+		if rule.Bar.IsCalendar() {
+			return nil, fmt.Errorf("%w: cannot backtest rule %s on calendar interval %s", exchange.ErrUnsupportedInterval, rule.Name, rule.Bar)
+		}
+		bars, err := resample.Klines(minutes, rule.Bar.Duration(), resampleOpts)
+		if err != nil {
+			return nil, err
+		}
+		for i := rule.Lookback - 1; i < len(bars); i++ {
+			at := bars[i].CloseTime.Add(time.Millisecond)
+			if at.Before(opts.From) || !at.Before(opts.To) {
+				continue
+			}
+			if sig := rule.Evaluate(bars[i-rule.Lookback+1 : i+1]); sig != nil {
+				emit(at, rule.Name, sig.Close, sig.Message)
+			}
+		}
+	}
+
+	for i := range alerts {
+		alerts[i].Returns = make([]float64, len(BacktestHorizons))
+		for j, horizon := range BacktestHorizons {
+			alerts[i].Returns[j] = math.NaN()
+			if price, ok := priceAt(minutes, alerts[i].Time.Add(horizon)); ok && alerts[i].Price > 0 {
+				alerts[i].Returns[j] = (price/alerts[i].Price - 1) * 100
+			}
+		}
+	}
+	sort.SliceStable(alerts, func(i, j int) bool { return alerts[i].Time.Before(alerts[j].Time) })
+	return alerts, nil
+}
+
+// priceAt returns the close of the last minute closed by t, provided the history reaches t.
+func priceAt(minutes []exchange.Kline, t time.Time) (float64, bool) {
+	i := sort.Search(len(minutes), func(i int) bool { return !minutes[i].CloseTime.Before(t) })
+	if i == 0 || i == len(minutes) {
+		return 0, false
+	}
+	return minutes[i-1].Close, true
+}
+
+// SummarizeBacktest groups alerts by signal and summarizes their forward returns, ordered by signal.
+func SummarizeBacktest(alerts []BacktestAlert) []BacktestSummary {
+	bySignal := make(map[string][]BacktestAlert)
+	for _, a := range alerts {
+		bySignal[a.Signal] = append(bySignal[a.Signal], a)
+	}
+
+	summaries := make([]BacktestSummary, 0, len(bySignal))
+	for signal, group := range bySignal {
+		symbols := make(map[string]bool)
+		for _, a := range group {
+			symbols[overrideKey(a.Exchange, a.Symbol)] = true
+		}
+		summary := BacktestSummary{Signal: signal, Alerts: len(group), Symbols: len(symbols)}
+		for j, horizon := range BacktestHorizons {
+			var returns []float64
+			for _, a := range group {
+				if j < len(a.Returns) && !math.IsNaN(a.Returns[j]) {
+					returns = append(returns, a.Returns[j])
+				}
+			}
+			summary.Horizons = append(summary.Horizons, horizonStats(horizon, returns))
+		}
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Signal < summaries[j].Signal })
+	return summaries
+}
+
+func horizonStats(horizon time.Duration, returns []float64) HorizonStats {
+	stats := HorizonStats{Horizon: horizon, Samples: len(returns), Mean: math.NaN(), Median: math.NaN(), HitRate: math.NaN()}
+	if len(returns) == 0 {
+		return stats
+	}
+	sort.Float64s(returns)
+	var sum float64
+	var hits int
+	for _, r := range returns {
+		sum += r
+		if r > 0 {
+			hits++
+		}
+	}
+	stats.Mean = sum / float64(len(returns))
+	mid := len(returns) / 2
+	stats.Median = returns[mid]
+	if len(returns)%2 == 0 {
+		stats.Median = (returns[mid-1] + returns[mid]) / 2
+	}
+	stats.HitRate = float64(hits) / float64(len(returns)) * 100
+	return stats
+}
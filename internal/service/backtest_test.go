@@ -0,0 +1,99 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"klineio/pkg/exchange"
+	"klineio/pkg/resample"
+)
+
+// backtestMinutes returns 1m candles over 14 days: 100 for six days, 80 for two hours, then 95.
+func backtestMinutes(start time.Time) []exchange.Kline {
+	var minutes []exchange.Kline
+	for i := 0; i < 14*24*60; i++ {
+		open := start.Add(time.Duration(i) * time.Minute)
+		price := 100.0
+		switch {
+		case i >= 6*24*60+120:
+			price = 95
+		case i >= 6*24*60:
+			price = 80
+		}
+		minutes = append(minutes, exchange.Kline{OpenTime: open, Open: price, High: price, Low: price, Close: price, Volume: 1, CloseTime: open.Add(time.Minute - time.Millisecond)})
+	}
+	return minutes
+}
+
+func TestReplay(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rule, err := NewRule(RuleConfig{Name: "boll", Type: "bollinger", Interval: "1h", Direction: DirectionBearish}, exchange.Interval1d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := AlertSettings{
+		Threshold: 0.1,
+		Average:   AverageConfig{Method: AverageSMA, Interval: exchange.Interval1d, Lookback: 3, ExcludeInProgress: true},
+		Rules:     []*Rule{rule},
+	}
+	from := start.AddDate(0, 0, 6)
+	opts := BacktestOptions{From: from, To: from.AddDate(0, 0, 1), Step: 5 * time.Minute, Cooldown: time.Hour}
+
+	alerts, err := Replay(backtestMinutes(start), "BINANCE", "BTCUSDT", settings, opts, resample.Options{})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+
+	// The drop is seen at 00:05 and, after the cooldown, at 01:05; 95 stays within the threshold.
+	// The Bollinger rule fires on the 00:00 and 01:00 hourly bars, which close at 01:00 and 02:00.
+	var drops, boll []BacktestAlert
+	for _, a := range alerts {
+		switch a.Signal {
+		case SignalPriceDrop:
+			drops = append(drops, a)
+		case "boll":
+			boll = append(boll, a)
+		}
+	}
+	if len(drops) != 2 || !drops[0].Time.Equal(from.Add(5*time.Minute)) || !drops[1].Time.Equal(from.Add(65*time.Minute)) {
+		t.Fatalf("unexpected price drop alerts: %+v", drops)
+	}
+	if len(boll) != 2 || !boll[0].Time.Equal(from.Add(time.Hour)) || !boll[1].Time.Equal(from.Add(2*time.Hour)) {
+		t.Fatalf("unexpected rule alerts: %+v", boll)
+	}
+	if r := drops[0].Returns; r[0] != 0 || r[1] != 18.75 || r[2] != 18.75 {
+		t.Errorf("unexpected forward returns: %v", r)
+	}
+
+	// Without a cooldown every run in the two hours alerts, like the live monitor.
+	opts.Cooldown = 0
+	alerts, _ = Replay(backtestMinutes(start), "BINANCE", "BTCUSDT", AlertSettings{Threshold: 0.1, Average: settings.Average}, opts, resample.Options{})
+	if len(alerts) != 24 {
+		t.Errorf("expected 24 alerts without a cooldown, got %d", len(alerts))
+	}
+}
+
+func TestSummarizeBacktest(t *testing.T) {
+	nan := math.NaN()
+	alerts := []BacktestAlert{
+		{Exchange: "BINANCE", Symbol: "BTCUSDT", Signal: "rsi", Returns: []float64{1, 2, nan}},
+		{Exchange: "BINANCE", Symbol: "BTCUSDT", Signal: "rsi", Returns: []float64{-3, 4, nan}},
+		{Exchange: "OKEX", Symbol: "BTCUSDT", Signal: "rsi", Returns: []float64{2, -1, nan}},
+		{Exchange: "OKEX", Symbol: "ETHUSDT", Signal: SignalPriceDrop, Returns: []float64{5, nan, nan}},
+	}
+	summaries := SummarizeBacktest(alerts)
+	if len(summaries) != 2 || summaries[0].Signal != SignalPriceDrop || summaries[1].Signal != "rsi" {
+		t.Fatalf("unexpected summaries: %+v", summaries)
+	}
+	rsi := summaries[1]
+	if rsi.Alerts != 3 || rsi.Symbols != 2 {
+		t.Errorf("unexpected counts: %+v", rsi)
+	}
+	if h := rsi.Horizons[0]; h.Samples != 3 || h.Mean != 0 || h.Median != 1 || math.Abs(h.HitRate-200.0/3) > 1e-9 {
+		t.Errorf("unexpected 1h stats: %+v", h)
+	}
+	if h := rsi.Horizons[2]; h.Samples != 0 || !math.IsNaN(h.Mean) {
+		t.Errorf("unexpected 7d stats: %+v", h)
+	}
+}
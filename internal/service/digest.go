@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"klineio/internal/model"
+	"klineio/internal/repository"
+	"klineio/pkg/event"
+	"klineio/pkg/log"
+
+	"github.com/spf13/viper"
+)
+
+// Mover is a symbol's change over a day, between two consecutive daily price records.
+type Mover struct {
+	Exchange      string
+	Symbol        string
+	Price         float64
+	ChangePercent float64
+}
+
+// TopMovers compares each symbol's price record dated day with the one dated the day before and returns
+// the n largest gainers and the n largest losers. prices must be sorted oldest first.
+func TopMovers(prices []*model.ExchangePrice, day time.Time, n int) (gainers, losers []Mover) {
+	day = day.UTC().Truncate(24 * time.Hour)
+	previous := make(map[string]*model.ExchangePrice)
+	var movers []Mover
+	for _, p := range prices {
+		key := overrideKey(p.Exchange, p.Symbol)
+		date := p.Date.UTC().Truncate(24 * time.Hour)
+		switch {
+		case date.Equal(day.AddDate(0, 0, -1)):
+			previous[key] = p
+		case date.Equal(day):
+			if prev, ok := previous[key]; ok && prev.Price > 0 {
+				movers = append(movers, Mover{Exchange: p.Exchange, Symbol: p.Symbol, Price: p.Price, ChangePercent: (p.Price/prev.Price - 1) * 100})
+			}
+		}
+	}
+
+	sort.Slice(movers, func(i, j int) bool { return movers[i].ChangePercent > movers[j].ChangePercent })
+	for _, m := range movers {
+		if len(gainers) == n || m.ChangePercent <= 0 {
+			break
+		}
+		gainers = append(gainers, m)
+	}
+	for i := len(movers) - 1; i >= 0; i-- {
+		if len(losers) == n || movers[i].ChangePercent >= 0 {
+			break
+		}
+		losers = append(losers, movers[i])
+	}
+	return gainers, losers
+}
+
+// DigestService sends a daily summary of the biggest movers and the paper trading strategies, and one of
+// the portfolio to its owner.
+type DigestService struct {
+	priceRepo     repository.ExchangePriceRepository
+	portfolioRepo repository.PortfolioRepository
+	paperTrading  *PaperTradingService
+	alerts        *AlertService
+	logger        *log.Logger
+	topN          int
+	userID        string // Portfolio to summarize, portfolio.user_id
+	now           func() time.Time
+}
+
+// NewDigestService creates a new DigestService.
+func NewDigestService(
+	priceRepo repository.ExchangePriceRepository,
+	portfolioRepo repository.PortfolioRepository,
+	paperTrading *PaperTradingService,
+	alerts *AlertService,
+	logger *log.Logger,
+	conf *viper.Viper,
+) *DigestService {
+	return &DigestService{
+		priceRepo:     priceRepo,
+		portfolioRepo: portfolioRepo,
+		paperTrading:  paperTrading,
+		alerts:        alerts,
+		logger:        logger,
+		topN:          defaultInt(conf.GetInt("digest.top_n"), 5),
+		userID:        conf.GetString("portfolio.user_id"),
+		now:           time.Now,
+	}
+}
+
+// Run builds the digest and sends it. The portfolio summary is sent on its own to the channel of
+// portfolio.user_id, as the shared group must not see a user's holdings.
+func (s *DigestService) Run(ctx context.Context) error {
+	text, err := s.Build(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.alerts.Send(ctx, event.Alert{Kind: event.KindDigest, Title: "每日摘要", Text: text}); err != nil {
+		return err
+	}
+
+	text, err = s.BuildPortfolio(ctx)
+	if err != nil || text == "" {
+		return err
+	}
+	return s.alerts.Send(ctx, event.Alert{Kind: event.KindDigest, UserID: s.userID, Title: "组合日报", Text: text})
+}
+
+// Build renders the market digest as DingTalk markdown.
+func (s *DigestService) Build(ctx context.Context) (string, error) {
+	now := s.now().UTC()
+	var b strings.Builder
+	fmt.Fprintf(&b, "### 每日摘要 %s\n\n", now.Format("2006-01-02"))
+
+	// Today's records are still being updated, so the movers are those of the last complete day.
+	day := now.AddDate(0, 0, -1)
+	prices, err := s.priceRepo.ListExchangePricesSince(ctx, day.AddDate(0, 0, -1))
+	if err != nil {
+		return "", fmt.Errorf("failed to list exchange prices: %w", err)
+	}
+	gainers, losers := TopMovers(prices, day, s.topN)
+	writeMovers(&b, "涨幅榜 "+day.Format("01-02"), gainers)
+	writeMovers(&b, "跌幅榜 "+day.Format("01-02"), losers)
+
+	if s.paperTrading.enabled {
+		strategies, err := s.paperTrading.ListPerformance(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to get paper trading performance: %w", err)
+		}
+		if len(strategies) > 0 {
+			b.WriteString("#### 模拟交易\n\n")
+			for _, p := range strategies {
+				fmt.Fprintf(&b, "- **%s**: 权益 %.2f (%+.2f%%)，持仓 %d，胜率 %.0f%%\n", p.Strategy, p.Equity, p.ReturnPercent, len(p.Positions), p.WinRate)
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+// BuildPortfolio renders the value and daily PnL of the portfolio of portfolio.user_id as DingTalk
+// markdown, or returns "" if there is no portfolio to summarize.
+func (s *DigestService) BuildPortfolio(ctx context.Context) (string, error) {
+	if s.userID == "" {
+		return "", nil
+	}
+	now := s.now().UTC()
+	snapshots, err := s.portfolioRepo.ListSnapshots(ctx, s.userID, now.AddDate(0, 0, -1))
+	if err != nil {
+		return "", fmt.Errorf("failed to list portfolio snapshots: %w", err)
+	}
+	n := len(snapshots)
+	if n == 0 {
+		return "", nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "### 组合日报 %s\n\n- **市值**: %.2f USDT\n", now.Format("2006-01-02"), snapshots[n-1].TotalValue)
+	if n > 1 && snapshots[n-2].TotalValue > 0 {
+		change := snapshots[n-1].TotalValue - snapshots[n-2].TotalValue
+		fmt.Fprintf(&b, "- **日盈亏**: %+.2f USDT (%+.2f%%)\n", change, change/snapshots[n-2].TotalValue*100)
+	}
+	return b.String(), nil
+}
+
+func writeMovers(b *strings.Builder, title string, movers []Mover) {
+	if len(movers) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "#### %s\n\n", title)
+	for _, m := range movers {
+		fmt.Fprintf(b, "- **%s** (%s): %.4f (%+.2f%%)\n", m.Symbol, m.Exchange, m.Price, m.ChangePercent)
+	}
+	b.WriteString("\n")
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"klineio/internal/model"
+	"klineio/internal/repository"
+	"klineio/pkg/broker"
+	"klineio/pkg/log"
+	"klineio/pkg/notifier"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+func TestTopMovers(t *testing.T) {
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	price := func(exchange, symbol string, date time.Time, p float64) *model.ExchangePrice {
+		return &model.ExchangePrice{Exchange: exchange, Symbol: symbol, Date: date, Price: p}
+	}
+	yesterday := day.AddDate(0, 0, -1)
+	prices := []*model.ExchangePrice{
+		price("BINANCE", "BTCUSDT", yesterday, 100),
+		price("BINANCE", "ETHUSDT", yesterday, 100),
+		price("OKEX", "ETHUSDT", yesterday, 100),
+		price("BINANCE", "SOLUSDT", yesterday, 100),
+		price("BINANCE", "BTCUSDT", day, 110),
+		price("BINANCE", "ETHUSDT", day, 80),
+		price("OKEX", "ETHUSDT", day, 95),
+		price("BINANCE", "SOLUSDT", day, 125),
+		price("BINANCE", "NEWUSDT", day, 1), // No record to compare with
+	}
+
+	gainers, losers := TopMovers(prices, day.Add(9*time.Hour), 1)
+	if len(gainers) != 1 || gainers[0].Symbol != "SOLUSDT" || gainers[0].ChangePercent != 25 {
+		t.Errorf("unexpected gainers: %+v", gainers)
+	}
+	if len(losers) != 1 || losers[0].Symbol != "ETHUSDT" || losers[0].Exchange != "BINANCE" || math.Abs(losers[0].ChangePercent+20) > 1e-9 {
+		t.Errorf("unexpected losers: %+v", losers)
+	}
+
+	gainers, losers = TopMovers(prices, day, 5)
+	if len(gainers) != 2 || len(losers) != 2 {
+		t.Errorf("expected 2 gainers and 2 losers, got %+v and %+v", gainers, losers)
+	}
+}
+
+// digestPriceRepo serves the price records the digest compares.
+type digestPriceRepo struct {
+	repository.ExchangePriceRepository
+	prices []*model.ExchangePrice
+}
+
+func (r *digestPriceRepo) ListExchangePricesSince(ctx context.Context, since time.Time) ([]*model.ExchangePrice, error) {
+	return r.prices, nil
+}
+
+// digestPortfolioRepo serves the portfolio snapshots the digest summarizes.
+type digestPortfolioRepo struct {
+	repository.PortfolioRepository
+	snapshots []*model.PortfolioSnapshot
+}
+
+func (r *digestPortfolioRepo) ListSnapshots(ctx context.Context, userID string, since time.Time) ([]*model.PortfolioSnapshot, error) {
+	return r.snapshots, nil
+}
+
+func TestDigestPortfolioSentToOwner(t *testing.T) {
+	logger := &log.Logger{Logger: zap.NewNop()}
+	shared, own := &countingNotifier{}, &countingNotifier{}
+	alerts := NewAlertService(broker.Nop{}, []notifier.Notifier{shared}, nil, logger, viper.New())
+	alerts.userNotifiers["u1"] = own
+	s := &DigestService{
+		priceRepo:     &digestPriceRepo{},
+		portfolioRepo: &digestPortfolioRepo{snapshots: []*model.PortfolioSnapshot{{TotalValue: 1000}, {TotalValue: 1100}}},
+		paperTrading:  &PaperTradingService{},
+		alerts:        alerts,
+		logger:        logger,
+		topN:          5,
+		userID:        "u1",
+		now:           time.Now,
+	}
+
+	if err := s.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if shared.sent != 1 || own.sent != 1 {
+		t.Errorf("expected one message to the shared group and one to the owner, got %d and %d", shared.sent, own.sent)
+	}
+	text, err := s.BuildPortfolio(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "1100.00 USDT") || !strings.Contains(text, "+100.00 USDT (+10.00%)") {
+		t.Errorf("unexpected portfolio summary: %s", text)
+	}
+	if market, _ := s.Build(context.Background()); strings.Contains(market, "市值") {
+		t.Errorf("market digest contains the portfolio: %s", market)
+	}
+}
+
+func TestDigestMoversCompareCompleteDays(t *testing.T) {
+	now := time.Date(2024, 1, 3, 1, 0, 0, 0, time.UTC)
+	price := func(date time.Time, p float64) *model.ExchangePrice {
+		return &model.ExchangePrice{Exchange: "BINANCE", Symbol: "BTCUSDT", Date: date, Price: p}
+	}
+	s := &DigestService{
+		priceRepo: &digestPriceRepo{prices: []*model.ExchangePrice{
+			price(now.AddDate(0, 0, -2), 100),
+			price(now.AddDate(0, 0, -1), 110),
+			price(now, 55), // An hour into the day
+		}},
+		paperTrading: &PaperTradingService{},
+		topN:         5,
+		now:          func() time.Time { return now },
+	}
+
+	text, err := s.Build(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "涨幅榜 01-02") || !strings.Contains(text, "+10.00%") || strings.Contains(text, "跌幅榜") {
+		t.Errorf("expected BTCUSDT up 10%% on 01-02, got: %s", text)
+	}
+}
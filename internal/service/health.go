@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	v1 "klineio/api/v1"
+	"klineio/internal/repository"
+	"klineio/internal/task"
+	"klineio/pkg/exchange"
+	"klineio/pkg/log"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// Components checked by HealthService.
+const (
+	HealthDB       = "db"
+	HealthRedis    = "redis"
+	HealthMongo    = "mongo"
+	HealthNotifier = "notifier"
+	HealthMonitor  = "monitor"
+)
+
+// healthCheck checks one component; it returns a detail for the report, or an error if the component is down.
+type healthCheck func(ctx context.Context) (detail string, err error)
+
+// errSkipped is returned by checks of components that are not configured.
+var errSkipped = fmt.Errorf("not configured")
+
+// HealthService checks the components klineio depends on for /healthz and /readyz.
+type HealthService struct {
+	repo      *repository.Repository
+	jobRepo   repository.JobRepository
+	exchanges map[string]exchange.Pinger
+	rdb       *redis.Client
+	mongo     *mongo.Client
+	webhook   string
+	monitor   task.Schedule
+	maxAge    time.Duration // Longest time since the last successful monitor run before it is stale
+	timeout   time.Duration
+	critical  map[string]bool
+	logger    *log.Logger
+}
+
+// NewHealthService creates a HealthService. Redis and Mongo are checked only if data.redis.addr and
+// data.mongo.uri are set; only the components in health.critical, the database by default, make
+// the report down rather than degraded.
+func NewHealthService(
+	repo *repository.Repository,
+	jobRepo repository.JobRepository,
+	binanceClient *exchange.BinanceClient,
+	okexClient *exchange.OKEXClient,
+	logger *log.Logger,
+	conf *viper.Viper,
+) (*HealthService, func()) {
+	s := &HealthService{
+		repo:    repo,
+		jobRepo: jobRepo,
+		exchanges: map[string]exchange.Pinger{
+			"BINANCE": binanceClient,
+			"OKEX":    okexClient,
+		},
+		webhook:  conf.GetString("dingtalk.webhook_url"),
+		maxAge:   conf.GetDuration("health.monitor_max_age"),
+		timeout:  conf.GetDuration("health.timeout"),
+		critical: make(map[string]bool),
+		logger:   logger,
+	}
+	if s.timeout <= 0 {
+		s.timeout = 3 * time.Second
+	}
+	critical := conf.GetStringSlice("health.critical")
+	if !conf.IsSet("health.critical") {
+		critical = []string{HealthDB}
+	}
+	for _, name := range critical {
+		s.critical[name] = true
+	}
+
+	monitor, ok, err := task.Lookup(conf, task.JobPriceMonitor)
+	if err != nil || !ok {
+		logger.Warn("Invalid price monitor schedule, not checking its last run", zap.Error(err))
+	} else {
+		s.monitor = monitor
+	}
+	if s.maxAge <= 0 {
+		// Allow two missed runs; a cron schedule has no fixed period to go by
+		s.maxAge = time.Hour
+		if s.monitor.Cron == "" && s.monitor.Interval > 0 {
+			s.maxAge = 3 * s.monitor.Interval
+		}
+	}
+
+	// Neither client connects until it is used, so an unreachable server fails the check, not the start
+	if addr := conf.GetString("data.redis.addr"); addr != "" {
+		s.rdb = redis.NewClient(&redis.Options{
+			Addr:     addr,
+			Password: conf.GetString("data.redis.password"),
+			DB:       conf.GetInt("data.redis.db"),
+		})
+	}
+	if uri := conf.GetString("data.mongo.uri"); uri != "" {
+		client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(uri))
+		if err != nil {
+			logger.Warn("Invalid Mongo URI, not checking Mongo", zap.Error(err))
+		} else {
+			s.mongo = client
+		}
+	}
+
+	return s, func() {
+		if s.rdb != nil {
+			s.rdb.Close()
+		}
+		if s.mongo != nil {
+			s.mongo.Disconnect(context.Background())
+		}
+	}
+}
+
+// Liveness checks what the process cannot recover from on its own: the database and a stuck monitor.
+func (s *HealthService) Liveness(ctx context.Context) v1.HealthResponse {
+	return s.run(ctx, map[string]healthCheck{
+		HealthDB:      s.checkDB,
+		HealthMonitor: s.checkMonitor,
+	})
+}
+
+// Readiness checks every component, including the exchanges and the notifier.
+func (s *HealthService) Readiness(ctx context.Context) v1.HealthResponse {
+	checks := map[string]healthCheck{
+		HealthDB:       s.checkDB,
+		HealthRedis:    s.checkRedis,
+		HealthMongo:    s.checkMongo,
+		HealthNotifier: s.checkNotifier,
+		HealthMonitor:  s.checkMonitor,
+	}
+	for name, pinger := range s.exchanges {
+		checks["exchange:"+name] = func(ctx context.Context) (string, error) {
+			return "", pinger.Ping(ctx)
+		}
+	}
+	return s.run(ctx, checks)
+}
+
+// run runs checks in parallel, each with health.timeout, and builds the report.
+func (s *HealthService) run(ctx context.Context, checks map[string]healthCheck) v1.HealthResponse {
+	report := v1.HealthResponse{
+		Status:     v1.HealthUp,
+		CheckedAt:  time.Now().UnixMilli(),
+		Components: make(map[string]v1.ComponentHealth, len(checks)),
+	}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, s.timeout)
+			defer cancel()
+
+			start := time.Now()
+			detail, err := check(ctx)
+			c := v1.ComponentHealth{
+				Status:    v1.HealthUp,
+				Critical:  s.critical[name],
+				LatencyMs: time.Since(start).Milliseconds(),
+				Detail:    detail,
+			}
+			switch {
+			case err == errSkipped:
+				c.Status = v1.HealthSkipped
+			case err != nil:
+				c.Status = v1.HealthDown
+				c.Error = err.Error()
+				s.logger.WithContext(ctx).Warn("Health check failed", zap.String("component", name), zap.Error(err))
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[name] = c
+			if c.Status != v1.HealthDown {
+				return
+			}
+			if c.Critical {
+				report.Status = v1.HealthDown
+			} else if report.Status == v1.HealthUp {
+				report.Status = v1.HealthDegraded
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+func (s *HealthService) checkDB(ctx context.Context) (string, error) {
+	db, err := s.repo.DB(ctx).DB()
+	if err != nil {
+		return "", err
+	}
+	return "", db.PingContext(ctx)
+}
+
+func (s *HealthService) checkRedis(ctx context.Context) (string, error) {
+	if s.rdb == nil {
+		return "", errSkipped
+	}
+	return "", s.rdb.Ping(ctx).Err()
+}
+
+func (s *HealthService) checkMongo(ctx context.Context) (string, error) {
+	if s.mongo == nil {
+		return "", errSkipped
+	}
+	return "", s.mongo.Ping(ctx, nil)
+}
+
+// checkNotifier only checks the configuration, as sending a message would notify someone.
+func (s *HealthService) checkNotifier(ctx context.Context) (string, error) {
+	if s.webhook == "" {
+		return "", fmt.Errorf("dingtalk.webhook_url is not set")
+	}
+	u, err := url.Parse(s.webhook)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("dingtalk.webhook_url is not a valid URL")
+	}
+	return "dingtalk", nil
+}
+
+// checkMonitor reports the monitor down if it has not succeeded within health.monitor_max_age.
+func (s *HealthService) checkMonitor(ctx context.Context) (string, error) {
+	if !s.monitor.Enabled {
+		return "", errSkipped
+	}
+	run, err := s.jobRepo.GetLastSucceededRun(ctx, task.JobPriceMonitor)
+	if err != nil {
+		return "", err
+	}
+	if run == nil {
+		return "", fmt.Errorf("no successful run yet")
+	}
+	age := time.Since(time.UnixMilli(run.FinishedAt)).Truncate(time.Second)
+	detail := fmt.Sprintf("last successful run %s ago", age)
+	if age > s.maxAge {
+		return detail, fmt.Errorf("last successful run is older than %s", s.maxAge)
+	}
+	return detail, nil
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	v1 "klineio/api/v1"
+	"klineio/internal/model"
+	"klineio/internal/repository"
+	"klineio/internal/task"
+	"klineio/pkg/exchange"
+	"klineio/pkg/log"
+
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+type fakePinger struct{ err error }
+
+func (p fakePinger) Ping(ctx context.Context) error { return p.err }
+
+func TestHealthService(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&model.JobRun{}, &model.JobState{}); err != nil {
+		t.Fatal(err)
+	}
+	logger := &log.Logger{Logger: zap.NewNop()}
+	repo := repository.NewRepository(logger, db)
+	jobRepo := repository.NewJobRepository(repo, logger)
+	svc := &HealthService{
+		repo:    repo,
+		jobRepo: jobRepo,
+		exchanges: map[string]exchange.Pinger{
+			"BINANCE": fakePinger{},
+			"OKEX":    fakePinger{err: errors.New("connection refused")},
+		},
+		webhook:  "https://oapi.dingtalk.com/robot/send?access_token=x",
+		monitor:  task.Schedule{Enabled: true, Interval: 5 * time.Minute},
+		maxAge:   15 * time.Minute,
+		timeout:  time.Second,
+		critical: map[string]bool{HealthDB: true},
+		logger:   logger,
+	}
+	ctx := context.Background()
+
+	// Without a successful run the monitor is down, but it is not critical
+	report := svc.Liveness(ctx)
+	if report.Status != v1.HealthDegraded || report.Components[HealthMonitor].Status != v1.HealthDown {
+		t.Fatalf("expected a degraded report with the monitor down, got %+v", report)
+	}
+
+	finished := time.Now().Add(-time.Minute).UnixMilli()
+	if err := jobRepo.CreateRun(ctx, &model.JobRun{Name: task.JobPriceMonitor, Status: model.JobStatusSucceeded, StartedAt: finished, FinishedAt: finished}); err != nil {
+		t.Fatal(err)
+	}
+	if report := svc.Liveness(ctx); report.Status != v1.HealthUp {
+		t.Errorf("expected the liveness report up, got %+v", report)
+	}
+
+	report = svc.Readiness(ctx)
+	if report.Status != v1.HealthDegraded {
+		t.Errorf("expected the readiness report degraded by OKEX, got %s", report.Status)
+	}
+	want := map[string]string{
+		HealthDB:           v1.HealthUp,
+		HealthRedis:        v1.HealthSkipped,
+		HealthMongo:        v1.HealthSkipped,
+		HealthNotifier:     v1.HealthUp,
+		HealthMonitor:      v1.HealthUp,
+		"exchange:BINANCE": v1.HealthUp,
+		"exchange:OKEX":    v1.HealthDown,
+	}
+	for name, status := range want {
+		if got := report.Components[name]; got.Status != status {
+			t.Errorf("expected %s %s, got %+v", name, status, got)
+		}
+	}
+	if !report.Components[HealthDB].Critical || report.Components["exchange:OKEX"].Error == "" {
+		t.Errorf("unexpected components: %+v", report.Components)
+	}
+
+	// A critical component being down takes the whole report down
+	sqlDB, _ := db.DB()
+	sqlDB.Close()
+	if report := svc.Liveness(ctx); report.Status != v1.HealthDown || report.Components[HealthDB].Status != v1.HealthDown {
+		t.Errorf("expected the report down with the database closed, got %+v", report)
+	}
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	v1 "klineio/api/v1"
+	"klineio/internal/model"
+	"klineio/internal/repository"
+)
+
+// defaultVenue is the venue of holdings entered without one.
+const defaultVenue = "MANUAL"
+
+// ErrInvalidHoldingsCSV is returned by ParseHoldingsCSV for malformed files.
+var ErrInvalidHoldingsCSV = errors.New("invalid holdings CSV")
+
+type HoldingService interface {
+	List(ctx context.Context, userId string) ([]v1.Holding, error)
+	Create(ctx context.Context, userId string, req *v1.CreateHoldingRequest) (*v1.Holding, error)
+	Update(ctx context.Context, userId string, id uint, req *v1.UpdateHoldingRequest) (*v1.Holding, error)
+	Delete(ctx context.Context, userId string, id uint) error
+	Import(ctx context.Context, userId string, r io.Reader) (int, error)
+}
+
+func NewHoldingService(
+	service *Service,
+	holdingRepo repository.HoldingRepository,
+) HoldingService {
+	return &holdingService{
+		holdingRepo: holdingRepo,
+		Service:     service,
+	}
+}
+
+type holdingService struct {
+	holdingRepo repository.HoldingRepository
+	*Service
+}
+
+func toHoldingResponse(h *model.Holding) *v1.Holding {
+	return &v1.Holding{
+		Id:        h.ID,
+		Asset:     h.Asset,
+		Venue:     h.Venue,
+		Quantity:  h.Quantity,
+		CostBasis: h.CostBasis,
+		UpdatedAt: h.UpdatedAt.UnixMilli(),
+	}
+}
+
+// normalizeVenue upper-cases venue and defaults it to defaultVenue.
+func normalizeVenue(venue string) string {
+	venue = strings.ToUpper(strings.TrimSpace(venue))
+	if venue == "" {
+		return defaultVenue
+	}
+	return venue
+}
+
+func (s *holdingService) List(ctx context.Context, userId string) ([]v1.Holding, error) {
+	holdings, err := s.holdingRepo.ListByUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]v1.Holding, len(holdings))
+	for i, h := range holdings {
+		out[i] = *toHoldingResponse(h)
+	}
+	return out, nil
+}
+
+func (s *holdingService) Create(ctx context.Context, userId string, req *v1.CreateHoldingRequest) (*v1.Holding, error) {
+	holding := &model.Holding{
+		UserID:    userId,
+		Asset:     strings.ToUpper(strings.TrimSpace(req.Asset)),
+		Venue:     normalizeVenue(req.Venue),
+		Quantity:  req.Quantity,
+		CostBasis: req.CostBasis,
+	}
+	existing, err := s.holdingRepo.GetByAsset(ctx, userId, holding.Asset, holding.Venue)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, v1.ErrHoldingExists
+	}
+	if err := s.holdingRepo.Create(ctx, holding); err != nil {
+		return nil, err
+	}
+	return toHoldingResponse(holding), nil
+}
+
+func (s *holdingService) Update(ctx context.Context, userId string, id uint, req *v1.UpdateHoldingRequest) (*v1.Holding, error) {
+	holding, err := s.holdingRepo.GetByID(ctx, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	holding.Quantity = req.Quantity
+	holding.CostBasis = req.CostBasis
+	if err := s.holdingRepo.Update(ctx, holding); err != nil {
+		return nil, err
+	}
+	return toHoldingResponse(holding), nil
+}
+
+func (s *holdingService) Delete(ctx context.Context, userId string, id uint) error {
+	return s.holdingRepo.Delete(ctx, userId, id)
+}
+
+// Import creates or replaces the holdings listed in a CSV file and returns how many were imported.
+func (s *holdingService) Import(ctx context.Context, userId string, r io.Reader) (int, error) {
+	holdings, err := ParseHoldingsCSV(r)
+	if err != nil {
+		return 0, err
+	}
+	for _, h := range holdings {
+		h.UserID = userId
+	}
+	if err := s.holdingRepo.Upsert(ctx, holdings); err != nil {
+		return 0, err
+	}
+	return len(holdings), nil
+}
+
+// ParseHoldingsCSV reads holdings from a CSV file with a header row. The asset and quantity columns are
+// required; cost_basis and venue are optional. Column names are case-insensitive and may appear in any order.
+func ParseHoldingsCSV(r io.Reader) ([]*model.Holding, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read header: %v", ErrInvalidHoldingsCSV, err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		// Spreadsheet exports often start with a byte order mark
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range []string{"asset", "quantity"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: missing column %s", ErrInvalidHoldingsCSV, name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var holdings []*model.Holding
+	seen := make(map[string]int)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidHoldingsCSV, err)
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		h := &model.Holding{
+			Asset: strings.ToUpper(field(record, "asset")),
+			Venue: normalizeVenue(field(record, "venue")),
+		}
+		if h.Asset == "" || len(h.Asset) > 20 || len(h.Venue) > 20 {
+			return nil, fmt.Errorf("%w: line %d: invalid asset or venue", ErrInvalidHoldingsCSV, line)
+		}
+		h.Quantity, err = strconv.ParseFloat(field(record, "quantity"), 64)
+		if err != nil || h.Quantity <= 0 || math.IsNaN(h.Quantity) || math.IsInf(h.Quantity, 0) {
+			return nil, fmt.Errorf("%w: line %d: quantity must be a positive number", ErrInvalidHoldingsCSV, line)
+		}
+		if cost := field(record, "cost_basis"); cost != "" {
+			h.CostBasis, err = strconv.ParseFloat(cost, 64)
+			if err != nil || h.CostBasis < 0 || math.IsNaN(h.CostBasis) || math.IsInf(h.CostBasis, 0) {
+				return nil, fmt.Errorf("%w: line %d: cost_basis must be a non-negative number", ErrInvalidHoldingsCSV, line)
+			}
+		}
+		key := overrideKey(h.Venue, h.Asset)
+		if prev, ok := seen[key]; ok {
+			return nil, fmt.Errorf("%w: line %d: %s at %s is already listed on line %d", ErrInvalidHoldingsCSV, line, h.Asset, h.Venue, prev)
+		}
+		seen[key] = line
+		holdings = append(holdings, h)
+	}
+	if len(holdings) == 0 {
+		return nil, fmt.Errorf("%w: no holdings", ErrInvalidHoldingsCSV)
+	}
+	return holdings, nil
+}
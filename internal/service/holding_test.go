@@ -0,0 +1,46 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseHoldingsCSV(t *testing.T) {
+	csv := "\ufeffVenue,Asset,Quantity,Cost_Basis\n" +
+		"ledger,btc,0.5,30000\n" +
+		"\n" +
+		",eth,2,\n"
+	holdings, err := ParseHoldingsCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("ParseHoldingsCSV: %v", err)
+	}
+	if len(holdings) != 2 {
+		t.Fatalf("expected 2 holdings, got %d", len(holdings))
+	}
+	if h := holdings[0]; h.Asset != "BTC" || h.Venue != "LEDGER" || h.Quantity != 0.5 || h.CostBasis != 30000 {
+		t.Errorf("unexpected first holding: %+v", h)
+	}
+	if h := holdings[1]; h.Asset != "ETH" || h.Venue != defaultVenue || h.CostBasis != 0 {
+		t.Errorf("unexpected second holding: %+v", h)
+	}
+}
+
+func TestParseHoldingsCSVErrors(t *testing.T) {
+	for name, csv := range map[string]string{
+		"missing column":    "asset,cost_basis\nBTC,1\n",
+		"bad quantity":      "asset,quantity\nBTC,abc\n",
+		"negative quantity": "asset,quantity\nBTC,-1\n",
+		"negative cost":     "asset,quantity,cost_basis\nBTC,1,-5\n",
+		"NaN quantity":      "asset,quantity\nBTC,NaN\n",
+		"infinite quantity": "asset,quantity\nBTC,+Inf\n",
+		"NaN cost":          "asset,quantity,cost_basis\nBTC,1,nan\n",
+		"infinite cost":     "asset,quantity,cost_basis\nBTC,1,Inf\n",
+		"duplicate":         "asset,quantity\nBTC,1\nbtc,2\n",
+		"empty":             "asset,quantity\n",
+	} {
+		if _, err := ParseHoldingsCSV(strings.NewReader(csv)); !errors.Is(err, ErrInvalidHoldingsCSV) {
+			t.Errorf("%s: expected ErrInvalidHoldingsCSV, got %v", name, err)
+		}
+	}
+}
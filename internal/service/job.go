@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	v1 "klineio/api/v1"
+	"klineio/internal/model"
+	"klineio/internal/repository"
+	"klineio/internal/task"
+
+	"github.com/spf13/viper"
+)
+
+// JobService manages the task server's jobs through the job_runs and job_states tables, which every
+// task server reads, so it works whether or not the scheduler runs in this process.
+type JobService interface {
+	List(ctx context.Context) ([]v1.Job, error)
+	ListRuns(ctx context.Context, name string, limit int) ([]v1.JobRun, error)
+	// Trigger queues an ad-hoc run, which the next task server to poll picks up even if the job is paused.
+	Trigger(ctx context.Context, name string) (*v1.JobRun, error)
+	Pause(ctx context.Context, name string) error
+	Resume(ctx context.Context, name string) error
+}
+
+func NewJobService(
+	service *Service,
+	jobRepo repository.JobRepository,
+	conf *viper.Viper,
+) JobService {
+	return &jobService{
+		jobRepo: jobRepo,
+		conf:    conf,
+		Service: service,
+	}
+}
+
+type jobService struct {
+	jobRepo repository.JobRepository
+	conf    *viper.Viper
+	*Service
+}
+
+func toJobRunResponse(r *model.JobRun) *v1.JobRun {
+	return &v1.JobRun{
+		ID:         r.ID,
+		Name:       r.Name,
+		Trigger:    r.Trigger,
+		Status:     r.Status,
+		Instance:   r.Instance,
+		CreatedAt:  r.CreatedAt,
+		StartedAt:  r.StartedAt,
+		FinishedAt: r.FinishedAt,
+		DurationMs: r.DurationMs,
+		Succeeded:  r.Succeeded,
+		Failed:     r.Failed,
+		Error:      r.Error,
+	}
+}
+
+// lookup returns v1.ErrNotFound for names that are not jobs.
+func (s *jobService) lookup(name string) (task.Schedule, error) {
+	sched, ok, err := task.Lookup(s.conf, name)
+	if !ok {
+		return sched, v1.ErrNotFound
+	}
+	return sched, err
+}
+
+func (s *jobService) List(ctx context.Context) ([]v1.Job, error) {
+	defs := task.Definitions(s.conf)
+	jobs := make([]v1.Job, 0, len(defs))
+	for _, def := range defs {
+		sched, err := task.LoadSchedule(s.conf, def.Name, def.Defaults)
+		if err != nil {
+			return nil, err
+		}
+		paused, err := s.jobRepo.IsPaused(ctx, def.Name)
+		if err != nil {
+			return nil, err
+		}
+		job := v1.Job{
+			Name:         def.Name,
+			Enabled:      sched.Enabled,
+			Paused:       paused,
+			Schedule:     sched.String(),
+			TimeoutMs:    sched.Timeout.Milliseconds(),
+			RunOnStartup: sched.RunOnStartup,
+			Singleton:    sched.Singleton,
+		}
+		last, err := s.jobRepo.GetLastRun(ctx, def.Name)
+		if err != nil {
+			return nil, err
+		}
+		if last != nil {
+			job.LastRun = toJobRunResponse(last)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+func (s *jobService) ListRuns(ctx context.Context, name string, limit int) ([]v1.JobRun, error) {
+	if _, err := s.lookup(name); err != nil {
+		return nil, err
+	}
+	runs, err := s.jobRepo.ListRuns(ctx, name, limit)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]v1.JobRun, len(runs))
+	for i, r := range runs {
+		out[i] = *toJobRunResponse(r)
+	}
+	return out, nil
+}
+
+func (s *jobService) Trigger(ctx context.Context, name string) (*v1.JobRun, error) {
+	if _, err := s.lookup(name); err != nil {
+		return nil, err
+	}
+	run := &model.JobRun{Name: name, Trigger: model.JobTriggerManual, Status: model.JobStatusPending, CreatedAt: time.Now().UnixMilli()}
+	if err := s.jobRepo.CreateRun(ctx, run); err != nil {
+		return nil, err
+	}
+	return toJobRunResponse(run), nil
+}
+
+func (s *jobService) Pause(ctx context.Context, name string) error {
+	if _, err := s.lookup(name); err != nil {
+		return err
+	}
+	return s.jobRepo.SetPaused(ctx, name, true)
+}
+
+func (s *jobService) Resume(ctx context.Context, name string) error {
+	if _, err := s.lookup(name); err != nil {
+		return err
+	}
+	return s.jobRepo.SetPaused(ctx, name, false)
+}
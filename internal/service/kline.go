@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"klineio/internal/model"
+	"klineio/internal/repository"
+	"klineio/pkg/exchange"
+	"klineio/pkg/log"
+	"klineio/pkg/resample"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// BaseInterval is the interval of the candles stored for resampling.
+const BaseInterval = exchange.Interval1m
+
+// KlineService stores 1m candles and builds any higher timeframe from them,
+// independently of which intervals an exchange supports natively.
+type KlineService struct {
+	candleRepo repository.CandleRepository
+	priceRepo  repository.ExchangePriceRepository
+	logger     *log.Logger
+	enabled    bool
+	fetchLimit int
+	options    resample.Options
+}
+
+// NewKlineService creates a new KlineService.
+func NewKlineService(
+	candleRepo repository.CandleRepository,
+	priceRepo repository.ExchangePriceRepository,
+	logger *log.Logger,
+	conf *viper.Viper,
+) *KlineService {
+	loc := time.UTC
+	if tz := conf.GetString("kline_store.timezone"); tz != "" {
+		l, err := time.LoadLocation(tz)
+		if err != nil {
+			logger.Warn("Invalid kline_store.timezone, using UTC", zap.Error(err), zap.String("timezone", tz))
+		} else {
+			loc = l
+		}
+	}
+
+	return &KlineService{
+		candleRepo: candleRepo,
+		priceRepo:  priceRepo,
+		logger:     logger,
+		enabled:    conf.GetBool("kline_store.enabled"),
+		fetchLimit: defaultInt(conf.GetInt("kline_store.fetch_limit"), 10),
+		options: resample.Options{
+			Location:    loc,
+			FillGaps:    conf.GetBool("kline_store.fill_gaps"),
+			DropPartial: true,
+		},
+	}
+}
+
+// Collect fetches the most recent closed 1m candles for symbol and stores them.
+// It is a no-op unless kline_store.enabled is set.
+func (s *KlineService) Collect(ctx context.Context, client exchange.ExchangeClient, exchangeName, symbol string) error {
+	if !s.enabled {
+		return nil
+	}
+	klines, err := client.GetKlines(ctx, symbol, BaseInterval, s.fetchLimit)
+	if err != nil {
+		return fmt.Errorf("failed to get %s klines: %w", BaseInterval, err)
+	}
+	klines = PrepareKlines(klines, BaseInterval, true, time.Now())
+	return s.StoreKlines(ctx, exchangeName, symbol, BaseInterval, klines)
+}
+
+// StoreKlines upserts klines of the given interval.
+func (s *KlineService) StoreKlines(ctx context.Context, exchangeName, symbol string, interval exchange.Interval, klines []exchange.Kline) error {
+	candles := make([]*model.Candle, len(klines))
+	for i, k := range klines {
+		candles[i] = &model.Candle{
+			Symbol:    exchange.CanonicalSymbol(symbol),
+			Exchange:  exchangeName,
+			Interval:  interval.String(),
+			OpenTime:  k.OpenTime.UnixMilli(),
+			Open:      k.Open,
+			High:      k.High,
+			Low:       k.Low,
+			Close:     k.Close,
+			Volume:    k.Volume,
+			CloseTime: k.CloseTime.UnixMilli(),
+		}
+	}
+	return s.candleRepo.UpsertCandles(ctx, candles)
+}
+
+// GetKlines builds interval candles in [from, to) from the stored 1m candles.
+// The last candle is omitted if the stored data does not cover it completely.
+func (s *KlineService) GetKlines(ctx context.Context, exchangeName, symbol string, interval exchange.Interval, from, to time.Time) ([]exchange.Kline, error) {
+	if interval.IsCalendar() {
+		return nil, fmt.Errorf("%w: cannot resample calendar interval %s", exchange.ErrUnsupportedInterval, interval)
+	}
+	period := interval.Duration()
+	from = resample.BucketStart(from, period, s.options.Location)
+
+	candles, err := s.candleRepo.ListCandles(ctx, exchange.CanonicalSymbol(symbol), exchangeName, BaseInterval.String(), from.UnixMilli(), to.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list candles: %w", err)
+	}
+	src := make([]exchange.Kline, len(candles))
+	for i, c := range candles {
+		src[i] = exchange.Kline{
+			OpenTime:  time.UnixMilli(c.OpenTime),
+			Open:      c.Open,
+			High:      c.High,
+			Low:       c.Low,
+			Close:     c.Close,
+			Volume:    c.Volume,
+			CloseTime: time.UnixMilli(c.CloseTime),
+		}
+	}
+	return resample.Klines(src, period, s.options)
+}
+
+// GetDailyCloses builds daily candles since the given time from the stored ExchangePrice records.
+func (s *KlineService) GetDailyCloses(ctx context.Context, exchangeName, symbol string, since time.Time) ([]exchange.Kline, error) {
+	prices, err := s.priceRepo.ListExchangePrices(ctx, symbol, exchangeName, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prices: %w", err)
+	}
+	ticks := make([]resample.Tick, len(prices))
+	for i, p := range prices {
+		ticks[i] = resample.Tick{Time: time.UnixMilli(p.Timestamp), Price: p.Price, Volume: p.Volume}
+	}
+	return resample.Ticks(ticks, 24*time.Hour, s.options)
+}
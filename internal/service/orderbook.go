@@ -0,0 +1,343 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"klineio/internal/model"
+	"klineio/internal/repository"
+	"klineio/pkg/event"
+	"klineio/pkg/exchange"
+	"klineio/pkg/log"
+	"klineio/pkg/runstats"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// Order book sides.
+const (
+	SideBid = "bid"
+	SideAsk = "ask"
+)
+
+// minWallLevels is the number of levels a side needs within the wall band before walls are looked for.
+const minWallLevels = 5
+
+// snapshotStoreTimeout bounds storing the snapshots taken before a run was stopped.
+const snapshotStoreTimeout = 10 * time.Second
+
+// Wall is a single price level much larger than the levels around it.
+type Wall struct {
+	Side     string
+	Price    float64
+	Notional float64 // In the quote asset
+	Multiple float64 // Notional relative to the median level on the same side
+}
+
+// WallConfig controls FindWalls.
+type WallConfig struct {
+	WithinPercent float64 // Only levels within this distance of the mid price are considered
+	Multiple      float64 // A wall is at least this many times the median level
+	MinNotional   float64 // and at least this large in the quote asset
+}
+
+// OrderBookService snapshots order book depth of the monitored symbols and alerts on thin books and walls.
+type OrderBookService struct {
+	bookRepo        repository.OrderBookRepository
+	universe        *UniverseService
+	exchangeClients map[string]exchange.ExchangeClient
+	alerts          *AlertService
+	logger          *log.Logger
+	enabled         bool
+	depth           int // Price levels fetched per side
+	topNSymbols     int
+	apiRequestDelay time.Duration
+	baseline        time.Duration // History the current depth is compared against
+	minSamples      int           // Snapshots needed in the baseline before alerting
+	dropPercent     float64       // Alert when ±1% depth falls this far below the baseline average
+	wall            WallConfig
+	wallCooldown    time.Duration
+
+	mu         sync.Mutex
+	dry        map[string]bool      // Symbols currently in a liquidity drought, keyed by exchange:symbol
+	wallsFired map[string]time.Time // Last wall alert, keyed by exchange:symbol:side:price
+}
+
+// NewOrderBookService creates a new OrderBookService.
+func NewOrderBookService(
+	bookRepo repository.OrderBookRepository,
+	universe *UniverseService,
+	binanceClient *exchange.BinanceClient,
+	okexClient *exchange.OKEXClient,
+	alerts *AlertService,
+	logger *log.Logger,
+	conf *viper.Viper,
+) *OrderBookService {
+	wallMultiple := conf.GetFloat64("order_book.wall_multiple")
+	if wallMultiple <= 0 {
+		wallMultiple = 10
+	}
+	return &OrderBookService{
+		bookRepo: bookRepo,
+		universe: universe,
+		exchangeClients: map[string]exchange.ExchangeClient{
+			"BINANCE": binanceClient,
+			"OKEX":    okexClient,
+		},
+		alerts:          alerts,
+		logger:          logger,
+		enabled:         conf.GetBool("order_book.enabled"),
+		depth:           defaultInt(conf.GetInt("order_book.depth"), 400),
+		topNSymbols:     defaultInt(conf.GetInt("order_book.top_n_symbols"), conf.GetInt("price_monitor.top_n_symbols")),
+		apiRequestDelay: time.Duration(conf.GetInt("price_monitor.api_request_delay_ms")) * time.Millisecond,
+		baseline:        time.Duration(defaultInt(conf.GetInt("order_book.baseline_hours"), 24)) * time.Hour,
+		minSamples:      defaultInt(conf.GetInt("order_book.min_samples"), 12),
+		dropPercent:     conf.GetFloat64("order_book.drop_percent"),
+		wall: WallConfig{
+			WithinPercent: 2,
+			Multiple:      wallMultiple,
+			MinNotional:   conf.GetFloat64("order_book.wall_min_notional"),
+		},
+		wallCooldown: time.Duration(defaultInt(conf.GetInt("order_book.wall_cooldown_minutes"), 60)) * time.Minute,
+		dry:          make(map[string]bool),
+		wallsFired:   make(map[string]time.Time),
+	}
+}
+
+// NewOrderBookSnapshot summarizes book into the depth within 1% and 2% of the mid price.
+func NewOrderBookSnapshot(exchangeName string, book *exchange.OrderBook) *model.OrderBookSnapshot {
+	mid := book.Mid()
+	snap := &model.OrderBookSnapshot{
+		Symbol:    exchange.CanonicalSymbol(book.Symbol),
+		Exchange:  exchangeName,
+		MidPrice:  mid,
+		Spread:    book.Spread(),
+		Timestamp: book.Timestamp.UnixMilli(),
+	}
+	if mid > 0 {
+		snap.SpreadPercent = snap.Spread / mid * 100
+	}
+	snap.BidDepth1, snap.AskDepth1 = book.Depth(1)
+	snap.BidDepth2, snap.AskDepth2 = book.Depth(2)
+	return snap
+}
+
+// LiquidityDrop compares the ±1% depth of current against the average of history and returns how far,
+// in percent, it has fallen. ok is false if history has fewer than minSamples snapshots.
+func LiquidityDrop(current *model.OrderBookSnapshot, history []*model.OrderBookSnapshot, minSamples int) (drop float64, ok bool) {
+	if len(history) < minSamples || len(history) == 0 {
+		return 0, false
+	}
+	var sum float64
+	for _, h := range history {
+		sum += h.BidDepth1 + h.AskDepth1
+	}
+	avg := sum / float64(len(history))
+	if avg == 0 {
+		return 0, false
+	}
+	return (1 - (current.BidDepth1+current.AskDepth1)/avg) * 100, true
+}
+
+// FindWalls returns the levels within cfg.WithinPercent of the mid price that dwarf the median level on their side.
+func FindWalls(book *exchange.OrderBook, cfg WallConfig) []Wall {
+	mid := book.Mid()
+	if mid == 0 {
+		return nil
+	}
+	low, high := mid*(1-cfg.WithinPercent/100), mid*(1+cfg.WithinPercent/100)
+
+	var walls []Wall
+	find := func(side string, levels []exchange.PriceLevel, inBand func(float64) bool) {
+		var band []exchange.PriceLevel
+		for _, l := range levels {
+			if !inBand(l.Price) {
+				break
+			}
+			band = append(band, l)
+		}
+		if len(band) < minWallLevels {
+			return
+		}
+		notionals := make([]float64, len(band))
+		for i, l := range band {
+			notionals[i] = l.Notional()
+		}
+		sort.Float64s(notionals)
+		median := notionals[len(notionals)/2]
+		if len(notionals)%2 == 0 {
+			median = (notionals[len(notionals)/2-1] + median) / 2
+		}
+		if median == 0 {
+			return
+		}
+		for _, l := range band {
+			n := l.Notional()
+			if n >= cfg.Multiple*median && n >= cfg.MinNotional {
+				walls = append(walls, Wall{Side: side, Price: l.Price, Notional: n, Multiple: n / median})
+			}
+		}
+	}
+	find(SideBid, book.Bids, func(p float64) bool { return p >= low })
+	find(SideAsk, book.Asks, func(p float64) bool { return p <= high })
+	return walls
+}
+
+// Run snapshots the order books of the monitored symbols on every exchange and evaluates the alerts.
+func (s *OrderBookService) Run(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+
+	for exchangeName, client := range s.exchangeClients {
+		tickers, err := s.universe.Select(ctx, client, exchangeName, s.topNSymbols)
+		if err != nil {
+			s.logger.Error("Failed to select symbols for order book snapshots", zap.Error(err), zap.String("exchange", exchangeName))
+			continue
+		}
+
+		snapshots, stopErr := s.snapshotBooks(ctx, client, exchangeName, tickers)
+		if len(snapshots) > 0 {
+			// Alerts for these snapshots have already been sent, so they are stored even when the run was stopped.
+			storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), snapshotStoreTimeout)
+			if err := s.bookRepo.CreateSnapshots(storeCtx, snapshots); err != nil {
+				s.logger.Error("Failed to store order book snapshots", zap.Error(err), zap.String("exchange", exchangeName))
+			}
+			cancel()
+		}
+		if stopErr != nil {
+			return stopErr
+		}
+	}
+	return nil
+}
+
+// snapshotBooks fetches and evaluates the order book of each ticker, returning the snapshots taken
+// and ctx's error if the run was stopped before all tickers were covered.
+func (s *OrderBookService) snapshotBooks(ctx context.Context, client exchange.ExchangeClient, exchangeName string, tickers []exchange.Ticker) ([]*model.OrderBookSnapshot, error) {
+	var snapshots []*model.OrderBookSnapshot
+	for i, ticker := range tickers {
+		select {
+		case <-ctx.Done():
+			return snapshots, ctx.Err()
+		default:
+		}
+
+		book, err := client.GetOrderBook(ctx, ticker.Symbol, s.depth)
+		if err != nil {
+			s.logger.Error("Failed to get order book", zap.Error(err), zap.String("exchange", exchangeName), zap.String("symbol", ticker.Symbol))
+			runstats.Failed(ctx)
+			continue
+		}
+		snap := NewOrderBookSnapshot(exchangeName, book)
+		if snap.MidPrice == 0 {
+			s.logger.Warn("Empty order book", zap.String("exchange", exchangeName), zap.String("symbol", ticker.Symbol))
+			runstats.Failed(ctx)
+			continue
+		}
+		s.checkLiquidity(ctx, snap)
+		s.checkWalls(ctx, exchangeName, book, snap)
+		snapshots = append(snapshots, snap)
+		runstats.Succeeded(ctx)
+
+		if i < len(tickers)-1 {
+			select {
+			case <-ctx.Done():
+				return snapshots, ctx.Err()
+			case <-time.After(s.apiRequestDelay):
+			}
+		}
+	}
+	return snapshots, nil
+}
+
+// checkLiquidity alerts once when the ±1% depth falls dropPercent below its baseline and rearms once it recovers.
+func (s *OrderBookService) checkLiquidity(ctx context.Context, snap *model.OrderBookSnapshot) {
+	if s.dropPercent <= 0 {
+		return
+	}
+	since := time.UnixMilli(snap.Timestamp).Add(-s.baseline).UnixMilli()
+	history, err := s.bookRepo.ListSnapshots(ctx, snap.Symbol, snap.Exchange, since)
+	if err != nil {
+		s.logger.Error("Failed to list order book snapshots", zap.Error(err), zap.String("symbol", snap.Symbol))
+		return
+	}
+	drop, ok := LiquidityDrop(snap, history, s.minSamples)
+	if !ok {
+		return
+	}
+
+	key := snap.Exchange + ":" + snap.Symbol
+	s.mu.Lock()
+	wasDry := s.dry[key]
+	s.dry[key] = drop >= s.dropPercent
+	s.mu.Unlock()
+	if wasDry || drop < s.dropPercent {
+		return
+	}
+
+	title := "流动性枯竭警报！"
+	text := fmt.Sprintf("### %s 流动性枯竭警报！\n\n", snap.Symbol) +
+		fmt.Sprintf("- **交易所**: %s\n", snap.Exchange) +
+		fmt.Sprintf("- **中间价**: %.4f\n", snap.MidPrice) +
+		fmt.Sprintf("- **±1%% 深度**: 买 %.0f / 卖 %.0f\n", snap.BidDepth1, snap.AskDepth1) +
+		fmt.Sprintf("- **较 %s 均值下降**: %.2f%% (阈值: %.2f%%)\n", s.baseline, drop, s.dropPercent) +
+		fmt.Sprintf("- **买卖价差**: %.4f%%\n", snap.SpreadPercent) +
+		fmt.Sprintf("- **来源**: 订单簿监控")
+
+	s.logger.Info("Sending liquidity alert", zap.String("exchange", snap.Exchange), zap.String("symbol", snap.Symbol), zap.Float64("drop", drop))
+	alert := event.Alert{Kind: event.KindLiquidity, Exchange: snap.Exchange, Symbol: snap.Symbol, Value: drop, Threshold: s.dropPercent, Title: title, Text: text}
+	if err := s.alerts.Send(ctx, alert); err != nil {
+		s.logger.Error("Failed to send liquidity alert", zap.Error(err))
+	}
+}
+
+// checkWalls alerts on walls, at most once per price level within the cooldown.
+func (s *OrderBookService) checkWalls(ctx context.Context, exchangeName string, book *exchange.OrderBook, snap *model.OrderBookSnapshot) {
+	if s.wall.MinNotional <= 0 {
+		return
+	}
+	now := time.Now()
+	for _, w := range FindWalls(book, s.wall) {
+		key := fmt.Sprintf("%s:%s:%s:%g", exchangeName, snap.Symbol, w.Side, w.Price)
+		s.mu.Lock()
+		last, fired := s.wallsFired[key]
+		if fired && now.Sub(last) < s.wallCooldown {
+			s.mu.Unlock()
+			continue
+		}
+		s.wallsFired[key] = now
+		for k, t := range s.wallsFired {
+			if now.Sub(t) >= s.wallCooldown {
+				delete(s.wallsFired, k)
+			}
+		}
+		s.mu.Unlock()
+
+		side := "买单墙"
+		if w.Side == SideAsk {
+			side = "卖单墙"
+		}
+		title := "大额挂单警报！"
+		text := fmt.Sprintf("### %s 出现%s！\n\n", snap.Symbol, side) +
+			fmt.Sprintf("- **交易所**: %s\n", exchangeName) +
+			fmt.Sprintf("- **挂单价格**: %.4f (中间价 %.4f，偏离 %.2f%%)\n", w.Price, snap.MidPrice, (w.Price-snap.MidPrice)/snap.MidPrice*100) +
+			fmt.Sprintf("- **挂单金额**: %.0f (同侧中位数的 %.1f 倍)\n", w.Notional, w.Multiple) +
+			fmt.Sprintf("- **来源**: 订单簿监控")
+
+		s.logger.Info("Sending order book wall alert",
+			zap.String("exchange", exchangeName),
+			zap.String("symbol", snap.Symbol),
+			zap.String("side", w.Side),
+			zap.Float64("price", w.Price),
+			zap.Float64("notional", w.Notional))
+		alert := event.Alert{Kind: event.KindOrderBookWall, Exchange: exchangeName, Symbol: snap.Symbol, Value: w.Multiple, Title: title, Text: text}
+		if err := s.alerts.Send(ctx, alert); err != nil {
+			s.logger.Error("Failed to send order book wall alert", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"klineio/internal/model"
+	"klineio/internal/repository"
+	"klineio/pkg/exchange"
+	"klineio/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+// testBook builds a book around a mid of 100 with levels every 0.25, each worth 1000 USDT.
+func testBook() *exchange.OrderBook {
+	book := &exchange.OrderBook{Symbol: "BTC-USDT", Timestamp: time.Unix(0, 0)}
+	for i := 0; i < 12; i++ {
+		bid := 99.75 - 0.25*float64(i)
+		ask := 100.25 + 0.25*float64(i)
+		book.Bids = append(book.Bids, exchange.PriceLevel{Price: bid, Quantity: 1000 / bid})
+		book.Asks = append(book.Asks, exchange.PriceLevel{Price: ask, Quantity: 1000 / ask})
+	}
+	return book
+}
+
+func TestNewOrderBookSnapshot(t *testing.T) {
+	snap := NewOrderBookSnapshot("OKEX", testBook())
+	if snap.Symbol != "BTCUSDT" || snap.MidPrice != 100 || snap.Spread != 0.5 || snap.SpreadPercent != 0.5 {
+		t.Errorf("unexpected snapshot header: %+v", snap)
+	}
+	// Within 1%: bids 99.75..99.00 and asks 100.25..101.00, four levels each. Within 2%: eight each.
+	for name, got := range map[string]float64{"bid1": snap.BidDepth1, "ask1": snap.AskDepth1} {
+		if math.Abs(got-4000) > 1e-6 {
+			t.Errorf("%s depth = %v, want 4000", name, got)
+		}
+	}
+	for name, got := range map[string]float64{"bid2": snap.BidDepth2, "ask2": snap.AskDepth2} {
+		if math.Abs(got-8000) > 1e-6 {
+			t.Errorf("%s depth = %v, want 8000", name, got)
+		}
+	}
+}
+
+func TestLiquidityDrop(t *testing.T) {
+	history := []*model.OrderBookSnapshot{
+		{BidDepth1: 5000, AskDepth1: 5000},
+		{BidDepth1: 4000, AskDepth1: 6000},
+	}
+	current := &model.OrderBookSnapshot{BidDepth1: 2000, AskDepth1: 1000}
+	if drop, ok := LiquidityDrop(current, history, 2); !ok || math.Abs(drop-70) > 1e-9 {
+		t.Errorf("LiquidityDrop = %v, %v; want 70, true", drop, ok)
+	}
+	if _, ok := LiquidityDrop(current, history, 3); ok {
+		t.Error("expected no result with too little history")
+	}
+}
+
+func TestFindWalls(t *testing.T) {
+	book := testBook()
+	book.Asks[2].Quantity *= 20  // 20000 USDT at 100.75
+	book.Bids[10].Quantity *= 50 // Beyond 2% of mid, ignored
+
+	walls := FindWalls(book, WallConfig{WithinPercent: 2, Multiple: 10, MinNotional: 5000})
+	if len(walls) != 1 {
+		t.Fatalf("expected 1 wall, got %+v", walls)
+	}
+	w := walls[0]
+	if w.Side != SideAsk || w.Price != 100.75 || math.Abs(w.Notional-20000) > 1e-6 || math.Abs(w.Multiple-20) > 1e-6 {
+		t.Errorf("unexpected wall: %+v", w)
+	}
+
+	if walls := FindWalls(book, WallConfig{WithinPercent: 2, Multiple: 10, MinNotional: 50000}); len(walls) != 0 {
+		t.Errorf("expected the notional floor to suppress the wall, got %+v", walls)
+	}
+}
+
+// cancellingBookClient serves testBook and stops the run after the first book.
+type cancellingBookClient struct {
+	listingClient
+	cancel context.CancelFunc
+}
+
+func (c *cancellingBookClient) GetOrderBook(ctx context.Context, symbol string, depth int) (*exchange.OrderBook, error) {
+	c.cancel()
+	return testBook(), nil
+}
+
+// recordingBookRepo records the snapshots stored and whether the store context was live.
+type recordingBookRepo struct {
+	repository.OrderBookRepository
+	stored   []*model.OrderBookSnapshot
+	storeErr error
+}
+
+func (r *recordingBookRepo) CreateSnapshots(ctx context.Context, snapshots []*model.OrderBookSnapshot) error {
+	r.storeErr = ctx.Err()
+	r.stored = append(r.stored, snapshots...)
+	return nil
+}
+
+func TestOrderBookRunStoresSnapshotsWhenStopped(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &cancellingBookClient{
+		listingClient: listingClient{tickers: []exchange.Ticker{
+			{Symbol: "BTCUSDT", QuoteVolume: 2e9},
+			{Symbol: "ETHUSDT", QuoteVolume: 1e9},
+		}},
+		cancel: cancel,
+	}
+	u, _ := NewUniverse(UniverseConfig{})
+	repo := &recordingBookRepo{}
+	svc := &OrderBookService{
+		bookRepo: repo,
+		universe: &UniverseService{
+			universe: u,
+			logger:   &log.Logger{Logger: zap.NewNop()},
+			seasoned: make(map[string]bool),
+			young:    make(map[string]time.Time),
+		},
+		exchangeClients: map[string]exchange.ExchangeClient{"OKEX": client},
+		logger:          &log.Logger{Logger: zap.NewNop()},
+		enabled:         true,
+		apiRequestDelay: time.Hour,
+	}
+
+	if err := svc.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run = %v, want context.Canceled", err)
+	}
+	if len(repo.stored) != 1 || repo.stored[0].Symbol != "BTCUSDT" {
+		t.Fatalf("stored %d snapshots, want the BTCUSDT one", len(repo.stored))
+	}
+	if repo.storeErr != nil {
+		t.Errorf("snapshots stored on a stopped context: %v", repo.storeErr)
+	}
+}
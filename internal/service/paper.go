@@ -0,0 +1,507 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "klineio/api/v1"
+	"klineio/internal/model"
+	"klineio/internal/repository"
+	"klineio/pkg/exchange"
+	"klineio/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// TradeSignal is an alert raised by the price monitor, offered to the paper trading strategies.
+type TradeSignal struct {
+	Name        string // SignalPriceDrop or the name of the indicator rule
+	Exchange    string
+	Symbol      string
+	DropPercent float64 // Price drops only: how far the price is below the reference average
+}
+
+// StrategyConfig is the YAML shape of an entry under paper_trading.strategies.
+type StrategyConfig struct {
+	Name              string   `mapstructure:"name"`
+	Signals           []string `mapstructure:"signals"`          // price_drop and/or names of price_monitor.rules
+	MinDropPercent    float64  `mapstructure:"min_drop_percent"` // price_drop: minimum drop below the average to enter
+	Exchanges         []string `mapstructure:"exchanges"`        // Empty means every exchange
+	Symbols           []string `mapstructure:"symbols"`          // Empty means every monitored symbol
+	InitialCash       float64  `mapstructure:"initial_cash"`     // Virtual USDT the strategy starts with
+	OrderSize         float64  `mapstructure:"order_size"`       // USDT spent per entry
+	MaxPositions      int      `mapstructure:"max_positions"`
+	TakeProfitPercent float64  `mapstructure:"take_profit_percent"` // 0 disables
+	StopLossPercent   float64  `mapstructure:"stop_loss_percent"`   // 0 disables
+	MaxHoldHours      int      `mapstructure:"max_hold_hours"`      // 0 disables
+	CooldownHours     int      `mapstructure:"cooldown_hours"`      // Time after an exit before the market is entered again
+}
+
+// Strategy is a validated paper trading strategy. It only goes long: it buys a market when one of its
+// signals fires and sells on take profit, stop loss or after the maximum holding time.
+type Strategy struct {
+	StrategyConfig
+	signals   map[string]bool
+	exchanges map[string]bool
+	symbols   map[string]bool
+}
+
+// NewStrategy validates cfg and fills in defaults.
+func NewStrategy(cfg StrategyConfig) (*Strategy, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("strategy name is required")
+	}
+	if len(cfg.Signals) == 0 {
+		return nil, fmt.Errorf("strategy %s has no signals", cfg.Name)
+	}
+	s := &Strategy{StrategyConfig: cfg}
+	if s.InitialCash <= 0 {
+		s.InitialCash = 10000
+	}
+	if s.OrderSize <= 0 {
+		s.OrderSize = 1000
+	}
+	s.MaxPositions = defaultInt(s.MaxPositions, 5)
+
+	s.signals = make(map[string]bool, len(cfg.Signals))
+	for _, name := range cfg.Signals {
+		s.signals[strings.ToLower(name)] = true
+	}
+	s.exchanges = upperSet(cfg.Exchanges)
+	s.symbols = upperSet(cfg.Symbols)
+	return s, nil
+}
+
+// Matches reports whether the strategy enters on sig.
+func (s *Strategy) Matches(sig TradeSignal) bool {
+	if !s.signals[strings.ToLower(sig.Name)] {
+		return false
+	}
+	if sig.Name == SignalPriceDrop && sig.DropPercent < s.MinDropPercent {
+		return false
+	}
+	if len(s.exchanges) > 0 && !s.exchanges[strings.ToUpper(sig.Exchange)] {
+		return false
+	}
+	return len(s.symbols) == 0 || s.symbols[exchange.CanonicalSymbol(sig.Symbol)]
+}
+
+// ExitReason returns why position should be closed at the market price at now, or "" to keep it.
+func (s *Strategy) ExitReason(position *model.PaperPosition, price float64, now time.Time) string {
+	change := (price/position.EntryPrice - 1) * 100
+	switch {
+	case s.TakeProfitPercent > 0 && change >= s.TakeProfitPercent:
+		return model.PaperReasonTakeProfit
+	case s.StopLossPercent > 0 && change <= -s.StopLossPercent:
+		return model.PaperReasonStopLoss
+	case s.MaxHoldHours > 0 && now.Sub(time.UnixMilli(position.OpenedAt)) >= time.Duration(s.MaxHoldHours)*time.Hour:
+		return model.PaperReasonMaxHold
+	}
+	return ""
+}
+
+// simulateBuy fills a buy of notional USDT at the market price, worsened by slippage. Fees are charged on top.
+func simulateBuy(market, notional, feeRate, slippage float64) (price, quantity, fee float64) {
+	price = market * (1 + slippage)
+	return price, notional / price, notional * feeRate
+}
+
+// simulateSell fills a sell of quantity at the market price, worsened by slippage. Fees are deducted from the proceeds.
+func simulateSell(market, quantity, feeRate, slippage float64) (price, proceeds, fee float64) {
+	price = market * (1 - slippage)
+	proceeds = quantity * price
+	return price, proceeds, proceeds * feeRate
+}
+
+// PaperTradingService runs simulated strategies on the price monitor's alerts. Orders are filled against
+// live ticker prices with fees and slippage; positions, trades and the equity curve are stored per strategy.
+type PaperTradingService struct {
+	paperRepo       repository.PaperTradingRepository
+	exchangeClients map[string]exchange.ExchangeClient
+	logger          *log.Logger
+	enabled         bool
+	feeRate         float64 // Fraction of the notional charged per fill
+	slippage        float64 // Fraction the fill price is worse than the ticker price
+	strategies      []*Strategy
+	now             func() time.Time
+
+	mu sync.Mutex // Serializes fills, which read and update the account cash
+}
+
+// NewPaperTradingService creates a new PaperTradingService.
+func NewPaperTradingService(
+	paperRepo repository.PaperTradingRepository,
+	binanceClient *exchange.BinanceClient,
+	okexClient *exchange.OKEXClient,
+	logger *log.Logger,
+	conf *viper.Viper,
+) *PaperTradingService {
+	var configs []StrategyConfig
+	if err := conf.UnmarshalKey("paper_trading.strategies", &configs); err != nil {
+		logger.Warn("Failed to parse paper trading strategies", zap.Error(err))
+	}
+	var strategies []*Strategy
+	seen := make(map[string]bool)
+	for _, cfg := range configs {
+		strategy, err := NewStrategy(cfg)
+		if err == nil && seen[strategy.Name] {
+			err = fmt.Errorf("duplicate strategy name %s", strategy.Name)
+		}
+		if err != nil {
+			logger.Warn("Ignoring invalid paper trading strategy", zap.Error(err), zap.String("strategy", cfg.Name))
+			continue
+		}
+		seen[strategy.Name] = true
+		strategies = append(strategies, strategy)
+	}
+
+	return &PaperTradingService{
+		paperRepo: paperRepo,
+		exchangeClients: map[string]exchange.ExchangeClient{
+			"BINANCE": binanceClient,
+			"OKEX":    okexClient,
+		},
+		logger:     logger,
+		enabled:    conf.GetBool("paper_trading.enabled"),
+		feeRate:    conf.GetFloat64("paper_trading.fee_percent") / 100,
+		slippage:   conf.GetFloat64("paper_trading.slippage_percent") / 100,
+		strategies: strategies,
+		now:        time.Now,
+	}
+}
+
+// strategy returns the configured strategy called name, or nil.
+func (s *PaperTradingService) strategy(name string) *Strategy {
+	for _, st := range s.strategies {
+		if st.Name == name {
+			return st
+		}
+	}
+	return nil
+}
+
+// account returns the strategy's account, opening it with the initial cash on first use.
+func (s *PaperTradingService) account(ctx context.Context, st *Strategy) (*model.PaperAccount, error) {
+	account, err := s.paperRepo.GetAccount(ctx, st.Name)
+	if err != nil || account != nil {
+		return account, err
+	}
+	account = &model.PaperAccount{Strategy: st.Name, InitialCash: st.InitialCash, Cash: st.InitialCash, PeakEquity: st.InitialCash}
+	if err := s.paperRepo.CreateAccount(ctx, account); err != nil {
+		return nil, fmt.Errorf("failed to create paper account: %w", err)
+	}
+	return account, nil
+}
+
+// OnSignal lets every strategy that trades sig enter the market. Errors are logged.
+func (s *PaperTradingService) OnSignal(ctx context.Context, sig TradeSignal) {
+	if !s.enabled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, st := range s.strategies {
+		if !st.Matches(sig) {
+			continue
+		}
+		if err := s.enter(ctx, st, sig); err != nil {
+			s.logger.Error("Failed to enter paper position", zap.Error(err), zap.String("strategy", st.Name),
+				zap.String("exchange", sig.Exchange), zap.String("symbol", sig.Symbol))
+		}
+	}
+}
+
+func (s *PaperTradingService) enter(ctx context.Context, st *Strategy, sig TradeSignal) error {
+	client, ok := s.exchangeClients[strings.ToUpper(sig.Exchange)]
+	if !ok {
+		return fmt.Errorf("unknown exchange %s", sig.Exchange)
+	}
+	account, err := s.account(ctx, st)
+	if err != nil {
+		return err
+	}
+	positions, err := s.paperRepo.ListPositions(ctx, st.Name)
+	if err != nil {
+		return fmt.Errorf("failed to list paper positions: %w", err)
+	}
+	for _, p := range positions {
+		if p.Exchange == sig.Exchange && p.Symbol == sig.Symbol {
+			return nil
+		}
+	}
+	if len(positions) >= st.MaxPositions {
+		s.logger.Debug("Paper strategy is at its position limit", zap.String("strategy", st.Name))
+		return nil
+	}
+	now := s.now()
+	if st.CooldownHours > 0 {
+		last, err := s.paperRepo.GetLastTrade(ctx, st.Name, sig.Exchange, sig.Symbol)
+		if err != nil {
+			return fmt.Errorf("failed to get last paper trade: %w", err)
+		}
+		if last != nil && last.Side == model.PaperSideSell && now.Sub(time.UnixMilli(last.Timestamp)) < time.Duration(st.CooldownHours)*time.Hour {
+			return nil
+		}
+	}
+	if account.Cash < st.OrderSize*(1+s.feeRate) {
+		s.logger.Debug("Paper strategy has insufficient cash", zap.String("strategy", st.Name), zap.Float64("cash", account.Cash))
+		return nil
+	}
+
+	market, err := client.GetLatestPrice(ctx, sig.Symbol)
+	if err != nil {
+		return fmt.Errorf("failed to get latest price: %w", err)
+	}
+	if market <= 0 {
+		return fmt.Errorf("invalid latest price %v", market)
+	}
+	price, quantity, fee := simulateBuy(market, st.OrderSize, s.feeRate, s.slippage)
+	account.Cash -= st.OrderSize + fee
+	trade := &model.PaperTrade{
+		Strategy:    st.Name,
+		Exchange:    sig.Exchange,
+		Symbol:      sig.Symbol,
+		Side:        model.PaperSideBuy,
+		Quantity:    quantity,
+		Price:       price,
+		MarketPrice: market,
+		Fee:         fee,
+		Reason:      sig.Name,
+		Timestamp:   now.UnixMilli(),
+	}
+	position := &model.PaperPosition{
+		Strategy:   st.Name,
+		Exchange:   sig.Exchange,
+		Symbol:     sig.Symbol,
+		Quantity:   quantity,
+		EntryPrice: price,
+		Cost:       st.OrderSize + fee,
+		LastPrice:  market,
+		OpenedAt:   now.UnixMilli(),
+	}
+	if err := s.paperRepo.OpenPosition(ctx, account, trade, position); err != nil {
+		return err
+	}
+	s.logger.Info("Opened paper position", zap.String("strategy", st.Name), zap.String("exchange", sig.Exchange),
+		zap.String("symbol", sig.Symbol), zap.String("signal", sig.Name), zap.Float64("price", price), zap.Float64("quantity", quantity))
+	return nil
+}
+
+// Run marks every strategy's open positions to the live ticker prices, closes those that hit an exit
+// and appends a point to each equity curve.
+func (s *PaperTradingService) Run(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, st := range s.strategies {
+		if err := s.runStrategy(ctx, st); err != nil {
+			s.logger.Error("Failed to run paper strategy", zap.Error(err), zap.String("strategy", st.Name))
+		}
+	}
+	return nil
+}
+
+func (s *PaperTradingService) runStrategy(ctx context.Context, st *Strategy) error {
+	account, err := s.account(ctx, st)
+	if err != nil {
+		return err
+	}
+	positions, err := s.paperRepo.ListPositions(ctx, st.Name)
+	if err != nil {
+		return fmt.Errorf("failed to list paper positions: %w", err)
+	}
+
+	now := s.now()
+	var open []*model.PaperPosition
+	for _, p := range positions {
+		client, ok := s.exchangeClients[strings.ToUpper(p.Exchange)]
+		if !ok {
+			open = append(open, p)
+			continue
+		}
+		market, err := client.GetLatestPrice(ctx, p.Symbol)
+		if err != nil || market <= 0 {
+			// Value the position at its last known price until the ticker is back
+			s.logger.Warn("Failed to get latest price for paper position", zap.Error(err),
+				zap.String("exchange", p.Exchange), zap.String("symbol", p.Symbol))
+			open = append(open, p)
+			continue
+		}
+		p.LastPrice = market
+
+		reason := st.ExitReason(p, market, now)
+		if reason == "" {
+			open = append(open, p)
+			continue
+		}
+		price, proceeds, fee := simulateSell(market, p.Quantity, s.feeRate, s.slippage)
+		account.Cash += proceeds - fee
+		trade := &model.PaperTrade{
+			Strategy:    st.Name,
+			Exchange:    p.Exchange,
+			Symbol:      p.Symbol,
+			Side:        model.PaperSideSell,
+			Quantity:    p.Quantity,
+			Price:       price,
+			MarketPrice: market,
+			Fee:         fee,
+			RealizedPnL: proceeds - fee - p.Cost,
+			Reason:      reason,
+			Timestamp:   now.UnixMilli(),
+		}
+		if err := s.paperRepo.ClosePosition(ctx, account, trade, p); err != nil {
+			account.Cash -= proceeds - fee
+			s.logger.Error("Failed to close paper position", zap.Error(err), zap.String("strategy", st.Name), zap.String("symbol", p.Symbol))
+			open = append(open, p)
+			continue
+		}
+		s.logger.Info("Closed paper position", zap.String("strategy", st.Name), zap.String("exchange", p.Exchange),
+			zap.String("symbol", p.Symbol), zap.String("reason", reason), zap.Float64("realizedPnL", trade.RealizedPnL))
+	}
+	if err := s.paperRepo.UpdatePositions(ctx, open); err != nil {
+		return err
+	}
+
+	point := &model.PaperEquity{Strategy: st.Name, Cash: account.Cash, Timestamp: now.UnixMilli()}
+	for _, p := range open {
+		point.PositionsValue += p.Quantity * p.LastPrice
+	}
+	point.Equity = point.Cash + point.PositionsValue
+	account.PeakEquity = max(account.PeakEquity, point.Equity)
+	if account.PeakEquity > 0 {
+		account.MaxDrawdownPercent = max(account.MaxDrawdownPercent, (account.PeakEquity-point.Equity)/account.PeakEquity*100)
+	}
+	return s.paperRepo.RecordEquity(ctx, account, point)
+}
+
+// ListPerformance reports the performance of every configured strategy.
+func (s *PaperTradingService) ListPerformance(ctx context.Context) ([]v1.PaperStrategyPerformance, error) {
+	out := make([]v1.PaperStrategyPerformance, 0, len(s.strategies))
+	for _, st := range s.strategies {
+		perf, err := s.performance(ctx, st)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *perf)
+	}
+	return out, nil
+}
+
+// GetPerformance reports the performance and open positions of the strategy called name.
+func (s *PaperTradingService) GetPerformance(ctx context.Context, name string) (*v1.PaperStrategyPerformance, error) {
+	st := s.strategy(name)
+	if st == nil {
+		return nil, v1.ErrNotFound
+	}
+	return s.performance(ctx, st)
+}
+
+func (s *PaperTradingService) performance(ctx context.Context, st *Strategy) (*v1.PaperStrategyPerformance, error) {
+	perf := &v1.PaperStrategyPerformance{
+		Strategy:    st.Name,
+		Signals:     st.Signals,
+		InitialCash: st.InitialCash,
+		Cash:        st.InitialCash,
+		Positions:   []v1.PaperPosition{},
+	}
+	account, err := s.paperRepo.GetAccount(ctx, st.Name)
+	if err != nil {
+		return nil, err
+	}
+	if account != nil {
+		perf.InitialCash = account.InitialCash
+		perf.Cash = account.Cash
+		perf.MaxDrawdownPercent = account.MaxDrawdownPercent
+		perf.UpdatedAt = account.UpdatedAt.UnixMilli()
+	}
+
+	positions, err := s.paperRepo.ListPositions(ctx, st.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range positions {
+		value := p.Quantity * p.LastPrice
+		pos := v1.PaperPosition{
+			Exchange:      p.Exchange,
+			Symbol:        p.Symbol,
+			Quantity:      p.Quantity,
+			EntryPrice:    p.EntryPrice,
+			LastPrice:     p.LastPrice,
+			Value:         value,
+			UnrealizedPnL: value - p.Cost,
+			OpenedAt:      p.OpenedAt,
+		}
+		if p.Cost > 0 {
+			pos.UnrealizedPercent = pos.UnrealizedPnL / p.Cost * 100
+		}
+		perf.Positions = append(perf.Positions, pos)
+		perf.PositionsValue += value
+		perf.UnrealizedPnL += pos.UnrealizedPnL
+	}
+	perf.Equity = perf.Cash + perf.PositionsValue
+	if perf.InitialCash > 0 {
+		perf.ReturnPercent = (perf.Equity/perf.InitialCash - 1) * 100
+	}
+
+	stats, err := s.paperRepo.GetTradeStats(ctx, st.Name)
+	if err != nil {
+		return nil, err
+	}
+	perf.Trades = int(stats.Trades)
+	perf.ClosedTrades = int(stats.Closed)
+	perf.Fees = stats.Fees
+	perf.RealizedPnL = stats.RealizedPnL
+	if stats.Closed > 0 {
+		perf.WinRate = float64(stats.Wins) / float64(stats.Closed) * 100
+	}
+	return perf, nil
+}
+
+// ListTrades returns up to limit of the strategy's most recent fills, newest first.
+func (s *PaperTradingService) ListTrades(ctx context.Context, name string, limit int) ([]v1.PaperTrade, error) {
+	if s.strategy(name) == nil {
+		return nil, v1.ErrNotFound
+	}
+	trades, err := s.paperRepo.ListTrades(ctx, name, limit)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]v1.PaperTrade, len(trades))
+	for i, t := range trades {
+		out[i] = v1.PaperTrade{
+			Exchange:    t.Exchange,
+			Symbol:      t.Symbol,
+			Side:        t.Side,
+			Quantity:    t.Quantity,
+			Price:       t.Price,
+			MarketPrice: t.MarketPrice,
+			Fee:         t.Fee,
+			RealizedPnL: t.RealizedPnL,
+			Reason:      t.Reason,
+			Timestamp:   t.Timestamp,
+		}
+	}
+	return out, nil
+}
+
+// ListEquity returns the strategy's equity curve over the last days days, oldest first.
+func (s *PaperTradingService) ListEquity(ctx context.Context, name string, days int) ([]v1.PaperEquityPoint, error) {
+	if s.strategy(name) == nil {
+		return nil, v1.ErrNotFound
+	}
+	points, err := s.paperRepo.ListEquity(ctx, name, s.now().AddDate(0, 0, -days).UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	out := make([]v1.PaperEquityPoint, len(points))
+	for i, p := range points {
+		out[i] = v1.PaperEquityPoint{Cash: p.Cash, PositionsValue: p.PositionsValue, Equity: p.Equity, Timestamp: p.Timestamp}
+	}
+	return out, nil
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"klineio/internal/model"
+	"klineio/internal/repository"
+	"klineio/pkg/exchange"
+	"klineio/pkg/log"
+
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// tickerClient serves GetLatestPrice from a map; the price monitor's other calls are not used by paper trading.
+type tickerClient struct {
+	exchange.ExchangeClient
+	prices map[string]float64
+}
+
+func (c *tickerClient) GetLatestPrice(ctx context.Context, symbol string) (float64, error) {
+	return c.prices[symbol], nil
+}
+
+func TestStrategyMatches(t *testing.T) {
+	st, err := NewStrategy(StrategyConfig{Name: "dip", Signals: []string{"price_drop", "rsi_oversold"}, MinDropPercent: 20, Symbols: []string{"btcusdt"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		sig  TradeSignal
+		want bool
+	}{
+		{TradeSignal{Name: SignalPriceDrop, Exchange: "BINANCE", Symbol: "BTCUSDT", DropPercent: 25}, true},
+		{TradeSignal{Name: SignalPriceDrop, Exchange: "BINANCE", Symbol: "BTCUSDT", DropPercent: 5}, false},
+		{TradeSignal{Name: "rsi_oversold", Exchange: "OKEX", Symbol: "BTC-USDT"}, true},
+		{TradeSignal{Name: "macd_cross", Exchange: "BINANCE", Symbol: "BTCUSDT"}, false},
+		{TradeSignal{Name: SignalPriceDrop, Exchange: "BINANCE", Symbol: "ETHUSDT", DropPercent: 25}, false},
+	}
+	for _, c := range cases {
+		if got := st.Matches(c.sig); got != c.want {
+			t.Errorf("Matches(%+v) = %v, want %v", c.sig, got, c.want)
+		}
+	}
+
+	if _, err := NewStrategy(StrategyConfig{Name: "none"}); err == nil {
+		t.Error("a strategy without signals should be rejected")
+	}
+}
+
+func TestStrategyExitReason(t *testing.T) {
+	st, _ := NewStrategy(StrategyConfig{Name: "dip", Signals: []string{"price_drop"}, TakeProfitPercent: 10, StopLossPercent: 5, MaxHoldHours: 24})
+	opened := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := &model.PaperPosition{EntryPrice: 100, OpenedAt: opened.UnixMilli()}
+
+	cases := []struct {
+		price float64
+		at    time.Time
+		want  string
+	}{
+		{105, opened.Add(time.Hour), ""},
+		{110, opened.Add(time.Hour), model.PaperReasonTakeProfit},
+		{95, opened.Add(time.Hour), model.PaperReasonStopLoss},
+		{101, opened.Add(24 * time.Hour), model.PaperReasonMaxHold},
+	}
+	for _, c := range cases {
+		if got := st.ExitReason(p, c.price, c.at); got != c.want {
+			t.Errorf("ExitReason(%v, %v) = %q, want %q", c.price, c.at, got, c.want)
+		}
+	}
+}
+
+func TestPaperTradingRoundTrip(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&model.PaperAccount{}, &model.PaperPosition{}, &model.PaperTrade{}, &model.PaperEquity{}); err != nil {
+		t.Fatal(err)
+	}
+	logger := &log.Logger{Logger: zap.NewNop()}
+	st, _ := NewStrategy(StrategyConfig{Name: "dip", Signals: []string{"price_drop"}, OrderSize: 1000, TakeProfitPercent: 10, CooldownHours: 24})
+	client := &tickerClient{prices: map[string]float64{"BTCUSDT": 100}}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc := &PaperTradingService{
+		paperRepo:       repository.NewPaperTradingRepository(repository.NewRepository(logger, db), logger),
+		exchangeClients: map[string]exchange.ExchangeClient{"BINANCE": client},
+		logger:          logger,
+		enabled:         true,
+		feeRate:         0.001,
+		slippage:        0.001,
+		strategies:      []*Strategy{st},
+		now:             func() time.Time { return now },
+	}
+	ctx := context.Background()
+	sig := TradeSignal{Name: SignalPriceDrop, Exchange: "BINANCE", Symbol: "BTCUSDT", DropPercent: 20}
+
+	// Buy 1000 USDT at 100.1 with a 1 USDT fee; a repeated signal does not add to the position.
+	svc.OnSignal(ctx, sig)
+	svc.OnSignal(ctx, sig)
+	perf, err := svc.GetPerformance(ctx, "dip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(perf.Positions) != 1 || perf.Cash != 8999 || math.Abs(perf.Positions[0].Quantity-1000/100.1) > 1e-9 {
+		t.Fatalf("unexpected state after entry: %+v", perf)
+	}
+
+	// The price rises past the take profit and the position is sold at 119.88.
+	client.prices["BTCUSDT"] = 120
+	now = now.Add(time.Hour)
+	if err := svc.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	perf, _ = svc.GetPerformance(ctx, "dip")
+	proceeds := 1000 / 100.1 * 119.88
+	wantPnL := proceeds*0.999 - 1001
+	if len(perf.Positions) != 0 || perf.ClosedTrades != 1 || perf.WinRate != 100 || math.Abs(perf.RealizedPnL-wantPnL) > 1e-6 {
+		t.Fatalf("unexpected state after exit: %+v", perf)
+	}
+	if math.Abs(perf.Equity-(8999+proceeds*0.999)) > 1e-6 || math.Abs(perf.Fees-(1+proceeds*0.001)) > 1e-6 {
+		t.Errorf("unexpected equity or fees: %+v", perf)
+	}
+
+	// The market is in cooldown after the exit.
+	svc.OnSignal(ctx, sig)
+	if perf, _ = svc.GetPerformance(ctx, "dip"); len(perf.Positions) != 0 {
+		t.Errorf("entered again during the cooldown: %+v", perf.Positions)
+	}
+	now = now.Add(25 * time.Hour)
+	svc.OnSignal(ctx, sig)
+	if perf, _ = svc.GetPerformance(ctx, "dip"); len(perf.Positions) != 1 {
+		t.Errorf("expected a new entry after the cooldown: %+v", perf.Positions)
+	}
+
+	trades, err := svc.ListTrades(ctx, "dip", 10)
+	if err != nil || len(trades) != 3 || trades[1].Reason != model.PaperReasonTakeProfit {
+		t.Errorf("unexpected trades: %+v, %v", trades, err)
+	}
+}
@@ -0,0 +1,456 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "klineio/api/v1"
+	"klineio/internal/model"
+	"klineio/internal/repository"
+	"klineio/pkg/event"
+	"klineio/pkg/exchange"
+	"klineio/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// pnlPeriods are the look-back periods, in days, PnL is reported for.
+var pnlPeriods = []int{1, 7, 30}
+
+// Holding is a quantity of an asset held at a venue, before valuation.
+type Holding struct {
+	Asset     string
+	Venue     string
+	Quantity  float64
+	CostBasis float64 // Average cost per unit in USDT, 0 if unknown
+}
+
+// Position is a valued holding.
+type Position struct {
+	Holding
+	Price      float64 // In USDT, 0 if unknown
+	Value      float64
+	Allocation float64 // Percent of the portfolio value
+}
+
+// UnrealizedPnL returns the gain of the position over its cost, or 0 if the cost or price is unknown.
+func (p Position) UnrealizedPnL() float64 {
+	if p.CostBasis <= 0 || p.Price <= 0 {
+		return 0
+	}
+	return (p.Price - p.CostBasis) * p.Quantity
+}
+
+// BelowCostPercent returns how far the price is below the cost basis in percent, or 0 if it is not.
+func (p Position) BelowCostPercent() float64 {
+	if p.CostBasis <= 0 || p.Price <= 0 || p.Price >= p.CostBasis {
+		return 0
+	}
+	return (p.CostBasis - p.Price) / p.CostBasis * 100
+}
+
+// Valuation is the value of a user's portfolio at a point in time.
+type Valuation struct {
+	UserID     string
+	At         time.Time
+	TotalValue float64
+	Positions  []Position // Largest first
+	Unpriced   []string   // Assets for which no price was found
+}
+
+// PnL is the change in portfolio value over a period.
+type PnL struct {
+	Days          int
+	Change        float64
+	ChangePercent float64
+}
+
+// ComputePnL compares value at at with the latest snapshot taken at least days days earlier, for each period.
+// Periods without such a snapshot are omitted.
+func ComputePnL(value float64, at time.Time, snapshots []*model.PortfolioSnapshot, periods []int) []PnL {
+	var out []PnL
+	today := at.UTC().Truncate(24 * time.Hour)
+	for _, days := range periods {
+		cutoff := today.AddDate(0, 0, -days)
+		var base *model.PortfolioSnapshot
+		for _, s := range snapshots {
+			if !s.Date.After(cutoff) {
+				base = s
+			}
+		}
+		if base == nil || base.TotalValue == 0 {
+			continue
+		}
+		change := value - base.TotalValue
+		out = append(out, PnL{Days: days, Change: change, ChangePercent: change / base.TotalValue * 100})
+	}
+	return out
+}
+
+// Drawdown returns the highest of value and the snapshot values, and how far value is below it in percent.
+func Drawdown(value float64, snapshots []*model.PortfolioSnapshot) (peak, percent float64) {
+	peak = value
+	for _, s := range snapshots {
+		if s.TotalValue > peak {
+			peak = s.TotalValue
+		}
+	}
+	if peak == 0 {
+		return 0, 0
+	}
+	return peak, (peak - value) / peak * 100
+}
+
+// PortfolioService values users' exchange balances and manual holdings with the prices klineio collects,
+// stores daily snapshots and alerts on portfolio drawdowns and assets falling below their cost basis.
+type PortfolioService struct {
+	portfolioRepo    repository.PortfolioRepository
+	holdingRepo      repository.HoldingRepository
+	priceRepo        repository.ExchangePriceRepository
+	accounts         map[string]exchange.AccountClient
+	exchangeClients  map[string]exchange.ExchangeClient
+	alerts           *AlertService
+	logger           *log.Logger
+	enabled          bool
+	userID           string  // Owner of the exchange accounts configured under exchange.*
+	drawdownPercent  float64 // Alert when the portfolio is this far below its peak, 0 disables
+	drawdownWindow   int     // Days of snapshots the peak is taken from
+	belowCostPercent float64 // Alert when an asset's price is this far below its cost basis, 0 disables
+
+	mu         sync.Mutex
+	inDrawdown map[string]bool // Users whose current drawdown has been alerted
+	belowCost  map[string]bool // User, venue and asset of holdings whose fall below cost has been alerted
+}
+
+// NewPortfolioService creates a new PortfolioService.
+func NewPortfolioService(
+	portfolioRepo repository.PortfolioRepository,
+	holdingRepo repository.HoldingRepository,
+	priceRepo repository.ExchangePriceRepository,
+	binanceAccount *exchange.BinanceAccountClient,
+	okexAccount *exchange.OKEXAccountClient,
+	binanceClient *exchange.BinanceClient,
+	okexClient *exchange.OKEXClient,
+	alerts *AlertService,
+	logger *log.Logger,
+	conf *viper.Viper,
+) *PortfolioService {
+	return &PortfolioService{
+		portfolioRepo: portfolioRepo,
+		holdingRepo:   holdingRepo,
+		priceRepo:     priceRepo,
+		accounts: map[string]exchange.AccountClient{
+			"BINANCE": binanceAccount,
+			"OKEX":    okexAccount,
+		},
+		exchangeClients: map[string]exchange.ExchangeClient{
+			"BINANCE": binanceClient,
+			"OKEX":    okexClient,
+		},
+		alerts:           alerts,
+		logger:           logger,
+		enabled:          conf.GetBool("portfolio.enabled"),
+		userID:           conf.GetString("portfolio.user_id"),
+		drawdownPercent:  conf.GetFloat64("portfolio.drawdown_percent"),
+		drawdownWindow:   defaultInt(conf.GetInt("portfolio.drawdown_window_days"), 30),
+		belowCostPercent: conf.GetFloat64("portfolio.below_cost_percent"),
+		inDrawdown:       make(map[string]bool),
+		belowCost:        make(map[string]bool),
+	}
+}
+
+// users returns the users whose portfolios are tracked: the owner of the exchange accounts and
+// everyone with manual holdings.
+func (s *PortfolioService) users(ctx context.Context) ([]string, error) {
+	users, err := s.holdingRepo.ListUserIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list holding users: %w", err)
+	}
+	if s.userID != "" && !slices.Contains(users, s.userID) {
+		users = append([]string{s.userID}, users...)
+	}
+	return users, nil
+}
+
+// holdings collects what userID holds on the configured exchange accounts and their manual holdings.
+func (s *PortfolioService) holdings(ctx context.Context, userID string) ([]Holding, error) {
+	manual, err := s.holdingRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list holdings: %w", err)
+	}
+	holdings := make([]Holding, 0, len(manual))
+	for _, h := range manual {
+		holdings = append(holdings, Holding{Asset: h.Asset, Venue: h.Venue, Quantity: h.Quantity, CostBasis: h.CostBasis})
+	}
+	if userID != s.userID {
+		return holdings, nil
+	}
+	for venue, account := range s.accounts {
+		if !account.Configured() {
+			continue
+		}
+		balances, err := account.GetBalances(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s balances: %w", venue, err)
+		}
+		for _, b := range balances {
+			holdings = append(holdings, Holding{Asset: strings.ToUpper(b.Asset), Venue: venue, Quantity: b.Total()})
+		}
+	}
+	return holdings, nil
+}
+
+// priceOf returns the USDT price of asset, preferring the latest price stored for venue, then for any
+// other exchange, then a live price. USD stablecoins are valued at 1; those pegged to other currencies, such as
+// EURC, are priced like any other asset. cache avoids repeated lookups within one valuation.
+func (s *PortfolioService) priceOf(ctx context.Context, asset, venue string, cache map[string]float64) float64 {
+	if asset == quoteAsset || usdStablecoins[asset] {
+		return 1
+	}
+	key := overrideKey(venue, asset)
+	if price, ok := cache[key]; ok {
+		return price
+	}
+
+	symbol := asset + quoteAsset
+	venues := []string{venue}
+	for name := range s.exchangeClients {
+		if name != venue {
+			venues = append(venues, name)
+		}
+	}
+	var price float64
+	for _, name := range venues {
+		if p, err := s.priceRepo.GetLatestExchangePriceBySymbolAndExchange(ctx, symbol, name); err == nil && p.Price > 0 {
+			price = p.Price
+			break
+		}
+	}
+	if price == 0 {
+		for _, name := range venues {
+			client, ok := s.exchangeClients[name]
+			if !ok {
+				continue
+			}
+			if p, err := client.GetLatestPrice(ctx, symbol); err == nil && p > 0 {
+				price = p
+				break
+			}
+		}
+	}
+	cache[key] = price
+	return price
+}
+
+// Value values the current holdings of userID.
+func (s *PortfolioService) Value(ctx context.Context, userID string) (*Valuation, error) {
+	holdings, err := s.holdings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Valuation{UserID: userID, At: time.Now()}
+	cache := make(map[string]float64)
+	unpriced := make(map[string]bool)
+	for _, h := range holdings {
+		price := s.priceOf(ctx, h.Asset, h.Venue, cache)
+		if price == 0 {
+			unpriced[h.Asset] = true
+		}
+		p := Position{Holding: h, Price: price, Value: h.Quantity * price}
+		v.Positions = append(v.Positions, p)
+		v.TotalValue += p.Value
+	}
+	for i := range v.Positions {
+		if v.TotalValue > 0 {
+			v.Positions[i].Allocation = v.Positions[i].Value / v.TotalValue * 100
+		}
+	}
+	sort.SliceStable(v.Positions, func(i, j int) bool { return v.Positions[i].Value > v.Positions[j].Value })
+	for asset := range unpriced {
+		v.Unpriced = append(v.Unpriced, asset)
+	}
+	sort.Strings(v.Unpriced)
+	return v, nil
+}
+
+// Run values every tracked portfolio, stores today's snapshot and checks for drawdowns.
+func (s *PortfolioService) Run(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	users, err := s.users(ctx)
+	if err != nil {
+		return err
+	}
+	for _, userID := range users {
+		if err := s.snapshot(ctx, userID); err != nil {
+			s.logger.Error("Failed to snapshot portfolio", zap.Error(err), zap.String("userId", userID))
+		}
+	}
+	return nil
+}
+
+func (s *PortfolioService) snapshot(ctx context.Context, userID string) error {
+	v, err := s.Value(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if len(v.Unpriced) > 0 {
+		s.logger.Warn("Portfolio contains assets without a price", zap.String("userId", userID), zap.Strings("assets", v.Unpriced))
+	}
+
+	date := v.At.UTC().Truncate(24 * time.Hour)
+	snapshot := &model.PortfolioSnapshot{UserID: userID, Date: date, TotalValue: v.TotalValue, Timestamp: v.At.UnixMilli()}
+	positions := make([]*model.PortfolioPosition, len(v.Positions))
+	for i, p := range v.Positions {
+		positions[i] = &model.PortfolioPosition{
+			UserID:   userID,
+			Date:     date,
+			Asset:    p.Asset,
+			Venue:    p.Venue,
+			Quantity: p.Quantity,
+			Price:    p.Price,
+			Value:    p.Value,
+		}
+	}
+
+	// Read the history before saving, so that an earlier valuation today still counts towards the peak.
+	history, err := s.portfolioRepo.ListSnapshots(ctx, userID, date.AddDate(0, 0, -s.drawdownWindow))
+	if err != nil {
+		return fmt.Errorf("failed to list portfolio snapshots: %w", err)
+	}
+	if err := s.portfolioRepo.SaveSnapshot(ctx, snapshot, positions); err != nil {
+		return err
+	}
+	s.checkDrawdown(ctx, v, history)
+	s.checkBelowCost(ctx, v)
+	return nil
+}
+
+// checkDrawdown alerts once when the portfolio falls drawdownPercent below its peak and rearms once it recovers.
+func (s *PortfolioService) checkDrawdown(ctx context.Context, v *Valuation, history []*model.PortfolioSnapshot) {
+	if s.drawdownPercent <= 0 || v.TotalValue == 0 {
+		return
+	}
+	peak, drawdown := Drawdown(v.TotalValue, history)
+
+	s.mu.Lock()
+	wasAlerted := s.inDrawdown[v.UserID]
+	s.inDrawdown[v.UserID] = drawdown >= s.drawdownPercent
+	s.mu.Unlock()
+	if wasAlerted || drawdown < s.drawdownPercent {
+		return
+	}
+
+	title := "组合回撤警报！"
+	text := "### 组合回撤警报！\n\n" +
+		fmt.Sprintf("- **用户**: %s\n", v.UserID) +
+		fmt.Sprintf("- **当前市值**: %.2f USDT\n", v.TotalValue) +
+		fmt.Sprintf("- **近 %d 日峰值**: %.2f USDT\n", s.drawdownWindow, peak) +
+		fmt.Sprintf("- **回撤**: %.2f%% (阈值: %.2f%%)\n", drawdown, s.drawdownPercent)
+	for i, p := range v.Positions {
+		if i == 5 {
+			break
+		}
+		text += fmt.Sprintf("- **%s@%s**: %.2f USDT (%.1f%%)\n", p.Asset, p.Venue, p.Value, p.Allocation)
+	}
+	text += fmt.Sprintf("- **来源**: 组合监控")
+
+	s.logger.Info("Sending portfolio drawdown alert", zap.String("userId", v.UserID), zap.Float64("drawdown", drawdown))
+	alert := event.Alert{Kind: event.KindPortfolioDrawdown, UserID: v.UserID, Value: drawdown, Threshold: s.drawdownPercent, Title: title, Text: text}
+	if err := s.alerts.Send(ctx, alert); err != nil {
+		s.logger.Error("Failed to send portfolio drawdown alert", zap.Error(err))
+	}
+}
+
+// checkBelowCost alerts once when a position's price falls belowCostPercent below its cost basis
+// and rearms once it recovers.
+func (s *PortfolioService) checkBelowCost(ctx context.Context, v *Valuation) {
+	if s.belowCostPercent <= 0 {
+		return
+	}
+	for _, p := range v.Positions {
+		if p.CostBasis <= 0 || p.Price <= 0 {
+			continue
+		}
+		below := p.BelowCostPercent()
+		key := v.UserID + ":" + overrideKey(p.Venue, p.Asset)
+
+		s.mu.Lock()
+		wasAlerted := s.belowCost[key]
+		s.belowCost[key] = below >= s.belowCostPercent
+		s.mu.Unlock()
+		if wasAlerted || below < s.belowCostPercent {
+			continue
+		}
+
+		title := fmt.Sprintf("%s 跌破成本价警报！", p.Asset)
+		text := fmt.Sprintf("### %s 跌破成本价警报！\n\n", p.Asset) +
+			fmt.Sprintf("- **用户**: %s\n", v.UserID) +
+			fmt.Sprintf("- **持仓**: %s@%s %.8f\n", p.Asset, p.Venue, p.Quantity) +
+			fmt.Sprintf("- **当前价格**: %.8f USDT\n", p.Price) +
+			fmt.Sprintf("- **成本价**: %.8f USDT\n", p.CostBasis) +
+			fmt.Sprintf("- **低于成本**: %.2f%% (阈值: %.2f%%)\n", below, s.belowCostPercent) +
+			fmt.Sprintf("- **浮动盈亏**: %.2f USDT\n", p.UnrealizedPnL()) +
+			fmt.Sprintf("- **来源**: 组合监控")
+
+		s.logger.Info("Sending below cost basis alert", zap.String("userId", v.UserID), zap.String("asset", p.Asset), zap.Float64("belowPercent", below))
+		alert := event.Alert{Kind: event.KindBelowCostBasis, UserID: v.UserID, Symbol: p.Asset, Value: below, Threshold: s.belowCostPercent, Title: title, Text: text}
+		if err := s.alerts.Send(ctx, alert); err != nil {
+			s.logger.Error("Failed to send below cost basis alert", zap.Error(err))
+		}
+	}
+}
+
+// GetPortfolio values the user's portfolio and reports its allocation, PnL and drawdown.
+func (s *PortfolioService) GetPortfolio(ctx context.Context, userID string) (*v1.GetPortfolioResponseData, error) {
+	v, err := s.Value(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	window := max(s.drawdownWindow, pnlPeriods[len(pnlPeriods)-1])
+	history, err := s.portfolioRepo.ListSnapshots(ctx, userID, v.At.UTC().Truncate(24*time.Hour).AddDate(0, 0, -window))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list portfolio snapshots: %w", err)
+	}
+
+	data := &v1.GetPortfolioResponseData{TotalValue: v.TotalValue, Unpriced: v.Unpriced, Timestamp: v.At.UnixMilli()}
+	for _, p := range v.Positions {
+		data.Positions = append(data.Positions, v1.PortfolioPosition{
+			Asset:         p.Asset,
+			Venue:         p.Venue,
+			Quantity:      p.Quantity,
+			Price:         p.Price,
+			Value:         p.Value,
+			Allocation:    p.Allocation,
+			CostBasis:     p.CostBasis,
+			UnrealizedPnL: p.UnrealizedPnL(),
+		})
+	}
+	for _, pnl := range ComputePnL(v.TotalValue, v.At, history, pnlPeriods) {
+		data.PnL = append(data.PnL, v1.PortfolioPnL{Days: pnl.Days, Change: pnl.Change, ChangePercent: pnl.ChangePercent})
+	}
+	data.PeakValue, data.DrawdownPercent = Drawdown(v.TotalValue, history)
+	return data, nil
+}
+
+// ListSnapshots returns the user's daily portfolio values over the last days days, oldest first.
+func (s *PortfolioService) ListSnapshots(ctx context.Context, userID string, days int) ([]v1.PortfolioSnapshot, error) {
+	since := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -days)
+	snapshots, err := s.portfolioRepo.ListSnapshots(ctx, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]v1.PortfolioSnapshot, len(snapshots))
+	for i, snap := range snapshots {
+		out[i] = v1.PortfolioSnapshot{Date: snap.Date.Format("2006-01-02"), TotalValue: snap.TotalValue}
+	}
+	return out, nil
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"klineio/internal/model"
+	"klineio/internal/repository"
+	"klineio/pkg/log"
+	"klineio/pkg/notifier"
+
+	"go.uber.org/zap"
+)
+
+func snapshots(start time.Time, values ...float64) []*model.PortfolioSnapshot {
+	out := make([]*model.PortfolioSnapshot, len(values))
+	for i, v := range values {
+		out[i] = &model.PortfolioSnapshot{Date: start.AddDate(0, 0, i), TotalValue: v}
+	}
+	return out
+}
+
+func TestComputePnL(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	history := snapshots(start, 100, 110, 120, 130, 140, 150, 160, 170, 180)
+	at := start.AddDate(0, 0, 8).Add(12 * time.Hour)
+
+	pnl := ComputePnL(200, at, history, []int{1, 7, 30})
+	if len(pnl) != 2 {
+		t.Fatalf("expected 2 periods without the 30 day one, got %+v", pnl)
+	}
+	if pnl[0].Days != 1 || pnl[0].Change != 30 {
+		t.Errorf("1d PnL should compare with yesterday's 170, got %+v", pnl[0])
+	}
+	if pnl[1].Days != 7 || pnl[1].Change != 90 || math.Abs(pnl[1].ChangePercent-81.818181) > 1e-4 {
+		t.Errorf("7d PnL should compare with 110, got %+v", pnl[1])
+	}
+}
+
+func TestDrawdown(t *testing.T) {
+	history := snapshots(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 100, 200, 150)
+	if peak, percent := Drawdown(150, history); peak != 200 || percent != 25 {
+		t.Errorf("Drawdown = %v, %v%%", peak, percent)
+	}
+	if peak, percent := Drawdown(250, history); peak != 250 || percent != 0 {
+		t.Errorf("a new high should have no drawdown, got %v, %v%%", peak, percent)
+	}
+}
+
+func TestCheckDrawdown(t *testing.T) {
+	var alerts int32
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&alerts, 1)
+	}))
+	defer webhook.Close()
+
+	logger := &log.Logger{Logger: zap.NewNop()}
+	svc := &PortfolioService{
+		alerts:          &AlertService{userNotifiers: map[string]notifier.Notifier{"u1": notifier.NewDingTalkNotifier(webhook.URL, logger)}, logger: logger},
+		logger:          logger,
+		drawdownPercent: 10,
+		drawdownWindow:  30,
+		inDrawdown:      make(map[string]bool),
+	}
+	ctx := context.Background()
+	history := snapshots(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 1000)
+
+	for _, value := range []float64{950, 850, 800, 980, 880} {
+		svc.checkDrawdown(ctx, &Valuation{UserID: "u1", TotalValue: value}, history)
+	}
+	// Alerted at 850, not again at 800, rearmed at 980 and alerted again at 880.
+	if got := atomic.LoadInt32(&alerts); got != 2 {
+		t.Errorf("expected 2 alerts, got %d", got)
+	}
+}
+
+func TestCheckBelowCost(t *testing.T) {
+	var alerts int32
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&alerts, 1)
+	}))
+	defer webhook.Close()
+
+	logger := &log.Logger{Logger: zap.NewNop()}
+	svc := &PortfolioService{
+		alerts:           &AlertService{userNotifiers: map[string]notifier.Notifier{"u1": notifier.NewDingTalkNotifier(webhook.URL, logger)}, logger: logger},
+		logger:           logger,
+		belowCostPercent: 20,
+		belowCost:        make(map[string]bool),
+	}
+	ctx := context.Background()
+	position := func(asset string, price, cost float64) Position {
+		return Position{Holding: Holding{Asset: asset, Venue: "LEDGER", Quantity: 2, CostBasis: cost}, Price: price}
+	}
+
+	p := position("BTC", 75, 100)
+	if p.BelowCostPercent() != 25 || p.UnrealizedPnL() != -50 {
+		t.Errorf("unexpected position figures: %v%%, %v", p.BelowCostPercent(), p.UnrealizedPnL())
+	}
+	for _, price := range []float64{90, 75, 70, 95, 79} {
+		svc.checkBelowCost(ctx, &Valuation{UserID: "u1", Positions: []Position{
+			position("BTC", price, 100),
+			position("ETH", 10, 0), // No cost basis
+		}})
+	}
+	// Alerted at 75, not again at 70, rearmed at 95 and alerted again at 79.
+	if got := atomic.LoadInt32(&alerts); got != 2 {
+		t.Errorf("expected 2 alerts, got %d", got)
+	}
+}
+
+// storedPriceRepo serves the latest stored price of each symbol, on every exchange.
+type storedPriceRepo struct {
+	repository.ExchangePriceRepository
+	prices map[string]float64
+}
+
+func (r *storedPriceRepo) GetLatestExchangePriceBySymbolAndExchange(ctx context.Context, symbol, exchangeName string) (*model.ExchangePrice, error) {
+	price, ok := r.prices[symbol]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return &model.ExchangePrice{Symbol: symbol, Exchange: exchangeName, Price: price}, nil
+}
+
+func TestPriceOfStablecoins(t *testing.T) {
+	svc := &PortfolioService{priceRepo: &storedPriceRepo{prices: map[string]float64{"EURCUSDT": 1.08}}}
+	cache := make(map[string]float64)
+	ctx := context.Background()
+
+	if p := svc.priceOf(ctx, "USDC", "BINANCE", cache); p != 1 {
+		t.Errorf("expected USDC at 1, got %v", p)
+	}
+	// A euro stablecoin is worth its EUR price, not 1 USDT
+	if p := svc.priceOf(ctx, "EURC", "BINANCE", cache); p != 1.08 {
+		t.Errorf("expected EURC at 1.08, got %v", p)
+	}
+}
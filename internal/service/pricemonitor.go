@@ -3,22 +3,26 @@ package service
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"klineio/internal/model"
 	"klineio/internal/repository"
+	"klineio/pkg/event"
 	"klineio/pkg/exchange"
 	"klineio/pkg/log"
-	"klineio/pkg/notifier"
+	"klineio/pkg/resample"
+	"klineio/pkg/runstats"
 
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
 
 const (
-	// Interval for fetching K-lines, e.g., "1d" for 1 day
-	KlineInterval = "1d"
-	// Limit for fetching K-lines, e.g., 30 for 30 days
+	// Default interval for fetching K-lines when price_monitor.average.interval is unset
+	KlineInterval = exchange.Interval1d
+	// Default number of K-lines to average over when price_monitor.average.lookback is unset
 	KlineLimit = 30
 )
 
@@ -26,12 +30,21 @@ const (
 type PriceMonitorService struct {
 	priceRepo repository.ExchangePriceRepository
 	// monitorRepo      repository.MonitorConfigRepository // Removed: No longer directly used for main monitoring logic
-	exchangeClients  map[string]exchange.ExchangeClient
-	notifier         *notifier.DingTalkNotifier
-	logger           *log.Logger
-	defaultThreshold float64       // New: Default price drop threshold
-	topNSymbols      int           // New: Number of top symbols to fetch
-	apiRequestDelay  time.Duration // New: Delay between API requests for each symbol
+	exchangeClients map[string]exchange.ExchangeClient
+	alerts          *AlertService
+	logger          *log.Logger
+	settings        AlertSettings // Price drop threshold, reference averages and indicator rules
+	topNSymbols     int           // New: Number of top symbols to fetch
+	apiRequestDelay time.Duration // New: Delay between API requests for each symbol
+	spreadMonitor   *SpreadMonitorService
+	anomalyMonitor  *AnomalyMonitorService
+	klineService    *KlineService
+	universe        *UniverseService
+	paperTrading    *PaperTradingService
+	watchdog        *WatchdogService
+
+	firedMu   sync.Mutex
+	lastFired map[string]time.Time // Last K-line each rule fired on, keyed by rule:exchange:symbol
 }
 
 // NewPriceMonitorService creates a new PriceMonitorService.
@@ -40,7 +53,13 @@ func NewPriceMonitorService(
 	// monitorRepo repository.MonitorConfigRepository, // Removed: No longer directly used for main monitoring logic
 	binanceClient *exchange.BinanceClient,
 	okexClient *exchange.OKEXClient,
-	notifier *notifier.DingTalkNotifier,
+	alerts *AlertService,
+	spreadMonitor *SpreadMonitorService,
+	anomalyMonitor *AnomalyMonitorService,
+	klineService *KlineService,
+	universe *UniverseService,
+	paperTrading *PaperTradingService,
+	watchdog *WatchdogService,
 	logger *log.Logger,
 	conf *viper.Viper,
 ) *PriceMonitorService {
@@ -51,13 +70,104 @@ func NewPriceMonitorService(
 	return &PriceMonitorService{
 		priceRepo: priceRepo, // Corrected: remove dereference
 		// monitorRepo:      monitorRepo, // Removed: No longer directly used for main monitoring logic
-		exchangeClients:  exchangeClients,
-		notifier:         notifier,
-		logger:           logger,
-		defaultThreshold: conf.GetFloat64("price_monitor.default_threshold"),
-		topNSymbols:      conf.GetInt("price_monitor.top_n_symbols"),
-		apiRequestDelay:  time.Duration(conf.GetInt("price_monitor.api_request_delay_ms")) * time.Millisecond, // Read from config
+		exchangeClients: exchangeClients,
+		alerts:          alerts,
+		logger:          logger,
+		settings:        LoadAlertSettings(conf, logger),
+		topNSymbols:     conf.GetInt("price_monitor.top_n_symbols"),
+		apiRequestDelay: time.Duration(conf.GetInt("price_monitor.api_request_delay_ms")) * time.Millisecond, // Read from config
+		spreadMonitor:   spreadMonitor,
+		anomalyMonitor:  anomalyMonitor,
+		klineService:    klineService,
+		universe:        universe,
+		paperTrading:    paperTrading,
+		watchdog:        watchdog,
+		lastFired:       make(map[string]time.Time),
+	}
+}
+
+// AlertSettings holds the price_monitor settings that decide when the monitor alerts.
+type AlertSettings struct {
+	Threshold float64                  // Fraction below the reference average that triggers a price drop alert
+	Average   AverageConfig            // Default reference average settings
+	Overrides map[string]AverageConfig // Per exchange:symbol reference average settings
+	Rules     []*Rule                  // Indicator alert rules
+}
+
+// LoadAlertSettings reads the alert settings under price_monitor. Invalid overrides and rules are logged and skipped.
+func LoadAlertSettings(conf *viper.Viper, logger *log.Logger) AlertSettings {
+	averageConfig := AverageConfig{
+		Method:            AverageSMA,
+		Interval:          KlineInterval,
+		Lookback:          KlineLimit,
+		ExcludeInProgress: conf.GetBool("price_monitor.average.exclude_in_progress"),
+	}
+	if method, err := ParseAverageMethod(conf.GetString("price_monitor.average.method")); err != nil {
+		logger.Warn("Invalid average method, falling back to SMA", zap.Error(err))
+	} else {
+		averageConfig.Method = method
+	}
+	if s := conf.GetString("price_monitor.average.interval"); s != "" {
+		if interval, err := exchange.ParseInterval(s); err != nil {
+			logger.Warn("Invalid average interval, falling back to default", zap.Error(err))
+		} else {
+			averageConfig.Interval = interval
+		}
+	}
+	if lookback := conf.GetInt("price_monitor.average.lookback"); lookback > 0 {
+		averageConfig.Lookback = lookback
+	}
+
+	var overrides []averageOverride
+	if err := conf.UnmarshalKey("price_monitor.overrides", &overrides); err != nil {
+		logger.Warn("Failed to parse price monitor overrides", zap.Error(err))
+	}
+	averageOverrides := make(map[string]AverageConfig, len(overrides))
+	for _, o := range overrides {
+		merged, err := o.merge(averageConfig)
+		if err != nil {
+			logger.Warn("Ignoring invalid price monitor override", zap.Error(err), zap.String("symbol", o.Symbol), zap.String("exchange", o.Exchange))
+			continue
+		}
+		averageOverrides[overrideKey(o.Exchange, o.Symbol)] = merged
+	}
+
+	var ruleConfigs []RuleConfig
+	if err := conf.UnmarshalKey("price_monitor.rules", &ruleConfigs); err != nil {
+		logger.Warn("Failed to parse price monitor rules", zap.Error(err))
+	}
+	var rules []*Rule
+	for _, rc := range ruleConfigs {
+		rule, err := NewRule(rc, averageConfig.Interval)
+		if err != nil {
+			logger.Warn("Ignoring invalid price monitor rule", zap.Error(err), zap.String("rule", rc.Name))
+			continue
+		}
+		rules = append(rules, rule)
+	}
+
+	return AlertSettings{
+		Threshold: conf.GetFloat64("price_monitor.default_threshold"),
+		Average:   averageConfig,
+		Overrides: averageOverrides,
+		Rules:     rules,
+	}
+}
+
+// AverageFor returns the reference average settings for a symbol on an exchange.
+func (a AlertSettings) AverageFor(exchangeName, symbol string) AverageConfig {
+	if cfg, ok := a.Overrides[overrideKey(exchangeName, symbol)]; ok {
+		return cfg
+	}
+	return a.Average
+}
+
+// PriceDrop reports whether latest is more than threshold (a fraction) below average, and by how many percent.
+func PriceDrop(latest, average, threshold float64) (dropPercent float64, fired bool) {
+	if average <= 0 || latest >= average*(1-threshold) {
+		return 0, false
 	}
+	return (1 - latest/average) * 100, true
 }
 
 // RunMonitor fetches prices, calculates averages, and sends notifications if thresholds are met.
@@ -71,73 +181,124 @@ func (s *PriceMonitorService) RunMonitor(ctx context.Context) error {
 	//     return fmt.Errorf("failed to get monitor configs: %w", err)
 	// }
 
+	book := make(PriceBook)
+
+	// The watchdog hears about the run even if it times out or is cancelled, so it needs a context of its own
+	watchdogCtx := context.WithoutCancel(ctx)
+
+	// Take the tickers of every exchange before working through the symbols, so that the spread check
+	// compares prices taken moments apart rather than a whole pass over one exchange's symbols apart
+	tickersByExchange := make(map[string][]exchange.Ticker, len(s.exchangeClients))
 	for exchangeName, client := range s.exchangeClients {
 		s.logger.Info("Fetching top symbols for exchange", zap.String("exchange", exchangeName))
 
-		tickers, err := client.GetTopVolumeTickers(ctx, s.topNSymbols)
+		tickers, err := s.universe.Select(ctx, client, exchangeName, s.topNSymbols)
 		if err != nil {
 			s.logger.Error("Failed to get top volume tickers", zap.Error(err), zap.String("exchange", exchangeName))
+			s.watchdog.ExchangeRun(watchdogCtx, exchangeName, fmt.Errorf("failed to get top volume tickers: %w", err))
 			continue
 		}
+		tickersByExchange[exchangeName] = tickers
+		for _, ticker := range tickers {
+			if ticker.Price > 0 {
+				book.Set(exchangeName, ticker.Symbol, ticker.Price, ticker.Timestamp)
+			}
+		}
+	}
 
+	for exchangeName, tickers := range tickersByExchange {
+		client := s.exchangeClients[exchangeName]
 		if len(tickers) == 0 {
 			s.logger.Warn("No top tickers data for exchange", zap.String("exchange", exchangeName))
 			continue
 		}
 
+		// The exchange fails the run for the watchdog if no price at all could be stored, or if the run
+		// stopped before getting through its symbols
+		var stored int
+		var lastErr error
+		stopped := func() error {
+			s.watchdog.ExchangeRun(watchdogCtx, exchangeName, fmt.Errorf("run stopped after storing %d of %d prices: %w", stored, len(tickers), ctx.Err()))
+			return ctx.Err()
+		}
 		for i, ticker := range tickers {
 			// Check context before processing each ticker
 			select {
 			case <-ctx.Done():
 				s.logger.Info("Context cancelled, stopping price monitor", zap.String("exchange", exchangeName))
-				return ctx.Err()
+				return stopped()
 			default:
 			}
 
 			symbol := ticker.Symbol // Use the symbol from the fetched ticker
 
 			// 1. Fetch latest price and store it (upsert logic to ensure daily unique records)
-			latestPrice, err := s.FetchAndStorePrice(ctx, client, symbol, exchangeName)
+			latestPrice, err := s.FetchAndStorePrice(ctx, client, symbol, exchangeName, ticker.QuoteVolume)
 			if err != nil {
 				s.logger.Error("Failed to fetch and store latest price for top symbol",
 					zap.Error(err),
 					zap.String("symbol", symbol),
 					zap.String("exchange", exchangeName))
+				runstats.Failed(ctx)
+				lastErr = err
 				continue
 			}
+			stored++
+
+			// Keep the 1m candle store up to date for resampling
+			if err := s.klineService.Collect(ctx, client, exchangeName, symbol); err != nil {
+				s.logger.Error("Failed to collect 1m candles",
+					zap.Error(err),
+					zap.String("symbol", symbol),
+					zap.String("exchange", exchangeName))
+			}
+
+			// Flag statistically unusual moves against the symbol's own stored history
+			if err := s.anomalyMonitor.Check(ctx, exchangeName, symbol); err != nil {
+				s.logger.Error("Failed to check anomalies",
+					zap.Error(err),
+					zap.String("symbol", symbol),
+					zap.String("exchange", exchangeName))
+			}
 
 			// 2. Get historical K-lines for average calculation
-			klines, err := client.GetKlines(ctx, symbol, KlineInterval, KlineLimit)
+			avgCfg := s.settings.AverageFor(exchangeName, symbol)
+			limit := avgCfg.Bars()
+			if avgCfg.ExcludeInProgress {
+				limit++ // Fetch one extra bar so the lookback stays full after dropping the open one
+			}
+			klines, err := resample.FetchKlines(ctx, client, symbol, avgCfg.Interval, limit)
 			if err != nil {
 				s.logger.Error("Failed to get klines for top symbol",
 					zap.Error(err),
 					zap.String("symbol", symbol),
 					zap.String("exchange", exchangeName))
+				runstats.Failed(ctx)
 				continue
 			}
 
+			klines = PrepareKlines(klines, avgCfg.Interval, avgCfg.ExcludeInProgress, time.Now())
+			if len(klines) > avgCfg.Bars() {
+				klines = klines[len(klines)-avgCfg.Bars():]
+			}
 			if len(klines) == 0 {
 				s.logger.Warn("No klines data for top symbol",
 					zap.String("symbol", symbol),
 					zap.String("exchange", exchangeName))
+				runstats.Failed(ctx)
 				continue
 			}
 
-			// 3. Calculate 30-day average price based on close prices
-			var closePrices []float64
-			for _, kline := range klines {
-				closePrices = append(closePrices, kline.Close)
-			}
-			averagePrice := s.CalculateAveragePrice(closePrices)
+			// 3. Calculate the reference average using the configured method
+			averagePrice := CalculateAverage(klines, avgCfg)
 
 			// 4. Check for price drop using default threshold
-			if averagePrice > 0 && latestPrice < averagePrice*(1-s.defaultThreshold) {
-				dropPercentage := (1 - latestPrice/averagePrice) * 100
+			if dropPercentage, fired := PriceDrop(latestPrice, averagePrice, s.settings.Threshold); fired {
 				title := "价格下跌警报！"
 				text := fmt.Sprintf("### %s (%s) 价格下跌警报！\n\n", symbol, exchangeName) +
 					fmt.Sprintf("- **当前价格**: %.4f\n", latestPrice) +
-					fmt.Sprintf("- **近%d天平均价格**: %.4f\n", KlineLimit, averagePrice) +
-					fmt.Sprintf("- **跌幅**: %.2f%% (阈值: %.2f%%)\n", dropPercentage, s.defaultThreshold*100) +
+					fmt.Sprintf("- **近%d根%s K线%s均价**: %.4f\n", min(len(klines), avgCfg.Lookback), avgCfg.Interval, strings.ToUpper(string(avgCfg.Method)), averagePrice) +
+					fmt.Sprintf("- **跌幅**: %.2f%% (阈值: %.2f%%)\n", dropPercentage, s.settings.Threshold*100) +
 					fmt.Sprintf("- **来源**: 热门币种监控")
 
 				s.logger.Info("Sending price drop alert for top symbol",
@@ -147,23 +308,38 @@ func (s *PriceMonitorService) RunMonitor(ctx context.Context) error {
 					zap.Float64("averagePrice", averagePrice),
 					zap.Float64("dropPercentage", dropPercentage))
 
-				err = s.notifier.SendMarkdownMessage(ctx, title, text)
-				if err != nil {
-					s.logger.Error("Failed to send DingTalk notification for top symbol", zap.Error(err))
+				alert := event.Alert{Kind: event.KindPriceDrop, Exchange: exchangeName, Symbol: symbol, Value: dropPercentage, Threshold: s.settings.Threshold * 100, Title: title, Text: text}
+				if err := s.alerts.Send(ctx, alert); err != nil {
+					s.logger.Error("Failed to send price drop alert for top symbol", zap.Error(err))
 				}
+				s.paperTrading.OnSignal(ctx, TradeSignal{Name: SignalPriceDrop, Exchange: exchangeName, Symbol: symbol, DropPercent: dropPercentage})
 			}
 
+			// 5. Check indicator rules configured for this symbol
+			s.EvaluateRules(ctx, client, exchangeName, symbol)
+			runstats.Succeeded(ctx)
+
 			// Introduce a delay after processing each ticker to avoid rate limits
 			if s.apiRequestDelay > 0 && i < len(tickers)-1 {
 				s.logger.Debug("Introducing API request delay", zap.Duration("duration", s.apiRequestDelay))
 				select {
 				case <-ctx.Done():
-					return ctx.Err()
+					return stopped()
 				case <-time.After(s.apiRequestDelay):
 					// Continue after delay
 				}
 			}
 		}
+		if stored == 0 {
+			s.watchdog.ExchangeRun(watchdogCtx, exchangeName, fmt.Errorf("no price stored for %d symbols, last error: %w", len(tickers), lastErr))
+		} else {
+			s.watchdog.ExchangeRun(watchdogCtx, exchangeName, nil)
+		}
+	}
+
+	// Compare the prices collected above across exchanges
+	if err := s.spreadMonitor.Check(ctx, book); err != nil {
+		s.logger.Error("Failed to check cross-exchange spreads", zap.Error(err))
 	}
 
 	s.logger.Info("Price monitor run finished for top symbols")
@@ -180,8 +356,71 @@ func (s *PriceMonitorService) RunMonitor(ctx context.Context) error {
 	return nil
 }
 
-// FetchAndStorePrice fetches the latest price and stores it in the database using upsert logic.
-func (s *PriceMonitorService) FetchAndStorePrice(ctx context.Context, client exchange.ExchangeClient, symbol, exchangeName string) (float64, error) {
+// EvaluateRules checks every indicator rule that applies to symbol and sends an alert for each one that fires.
+// A rule fires at most once per K-line, so repeated runs within the same bar do not repeat the alert.
+func (s *PriceMonitorService) EvaluateRules(ctx context.Context, client exchange.ExchangeClient, exchangeName, symbol string) {
+	klinesByInterval := make(map[exchange.Interval][]exchange.Kline)
+	for _, rule := range s.settings.Rules {
+		if !rule.Matches(exchangeName, symbol) {
+			continue
+		}
+
+		klines, ok := klinesByInterval[rule.Bar]
+		if !ok || len(klines) < rule.Lookback {
+			fetched, err := resample.FetchKlines(ctx, client, symbol, rule.Bar, rule.Lookback+1)
+			if err != nil {
+				s.logger.Error("Failed to get klines for rule",
+					zap.Error(err),
+					zap.String("rule", rule.Name),
+					zap.String("symbol", symbol),
+					zap.String("exchange", exchangeName))
+				continue
+			}
+			// Rules only look at closed bars so that crossovers cannot flicker within a bar.
+			klines = PrepareKlines(fetched, rule.Bar, true, time.Now())
+			klinesByInterval[rule.Bar] = klines
+		}
+
+		sig := rule.Evaluate(klines)
+		if sig == nil || !s.markFired(rule, exchangeName, symbol, sig.BarTime) {
+			continue
+		}
+
+		title := "指标警报！"
+		text := fmt.Sprintf("### %s (%s) 指标警报：%s\n\n", symbol, exchangeName, rule.Name) +
+			fmt.Sprintf("- **信号**: %s\n", sig.Message) +
+			fmt.Sprintf("- **收盘价**: %.4f\n", sig.Close) +
+			fmt.Sprintf("- **K线**: %s %s\n", rule.Interval, sig.BarTime.UTC().Format("2006-01-02 15:04 MST")) +
+			fmt.Sprintf("- **来源**: 指标规则监控")
+
+		s.logger.Info("Sending indicator rule alert",
+			zap.String("rule", rule.Name),
+			zap.String("symbol", symbol),
+			zap.String("exchange", exchangeName),
+			zap.String("signal", sig.Message))
+
+		alert := event.Alert{Kind: event.KindRule, Rule: rule.Name, Exchange: exchangeName, Symbol: symbol, Title: title, Text: text}
+		if err := s.alerts.Send(ctx, alert); err != nil {
+			s.logger.Error("Failed to send indicator rule alert", zap.Error(err))
+		}
+		s.paperTrading.OnSignal(ctx, TradeSignal{Name: rule.Name, Exchange: exchangeName, Symbol: symbol})
+	}
+}
+
+// markFired records that rule fired on the bar opened at barTime and reports whether this is the first time.
+func (s *PriceMonitorService) markFired(rule *Rule, exchangeName, symbol string, barTime time.Time) bool {
+	key := rule.Name + ":" + overrideKey(exchangeName, symbol)
+	s.firedMu.Lock()
+	defer s.firedMu.Unlock()
+	if last, ok := s.lastFired[key]; ok && !barTime.After(last) {
+		return false
+	}
+	s.lastFired[key] = barTime
+	return true
+}
+
+// FetchAndStorePrice fetches the latest price and stores it, together with the ticker's 24h volume, using upsert logic.
+func (s *PriceMonitorService) FetchAndStorePrice(ctx context.Context, client exchange.ExchangeClient, symbol, exchangeName string, volume float64) (float64, error) {
 	price, err := client.GetLatestPrice(ctx, symbol)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get latest price for %s from %s: %w", symbol, exchangeName, err)
@@ -194,6 +433,7 @@ func (s *PriceMonitorService) FetchAndStorePrice(ctx context.Context, client exc
 		Symbol:    symbol,
 		Exchange:  exchangeName,
 		Price:     price,
+		Volume:    volume,
 		Timestamp: currentTime.UnixMilli(),
 	}
 	err = s.priceRepo.UpsertExchangePrice(ctx, exchangePrice)
@@ -201,18 +441,7 @@ func (s *PriceMonitorService) FetchAndStorePrice(ctx context.Context, client exc
 		return 0, fmt.Errorf("failed to upsert exchange price: %w", err)
 	}
 	s.logger.Debug("Upserted price record", zap.String("symbol", symbol), zap.String("exchange", exchangeName), zap.Float64("price", price))
+	s.alerts.PublishPrice(ctx, event.PriceSnapshot{Exchange: exchangeName, Symbol: symbol, Price: price, QuoteVolume: volume, Time: currentTime.UTC()})
 
 	return price, nil
 }
-
-// CalculateAveragePrice calculates the average of a slice of prices.
-func (s *PriceMonitorService) CalculateAveragePrice(prices []float64) float64 {
-	if len(prices) == 0 {
-		return 0
-	}
-	var sum float64
-	for _, price := range prices {
-		sum += price
-	}
-	return sum / float64(len(prices))
-}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"klineio/internal/repository"
+	"klineio/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// RetentionService permanently deletes stored market data older than the configured number of days.
+// A retention of 0 days keeps the data forever.
+type RetentionService struct {
+	priceRepo     repository.ExchangePriceRepository
+	candleRepo    repository.CandleRepository
+	orderBookRepo repository.OrderBookRepository
+	tradeRepo     repository.TradeRepository
+	spreadRepo    repository.PriceSpreadRepository
+	logger        *log.Logger
+	conf          *viper.Viper
+	now           func() time.Time
+}
+
+// NewRetentionService creates a new RetentionService.
+func NewRetentionService(
+	priceRepo repository.ExchangePriceRepository,
+	candleRepo repository.CandleRepository,
+	orderBookRepo repository.OrderBookRepository,
+	tradeRepo repository.TradeRepository,
+	spreadRepo repository.PriceSpreadRepository,
+	logger *log.Logger,
+	conf *viper.Viper,
+) *RetentionService {
+	return &RetentionService{
+		priceRepo:     priceRepo,
+		candleRepo:    candleRepo,
+		orderBookRepo: orderBookRepo,
+		tradeRepo:     tradeRepo,
+		spreadRepo:    spreadRepo,
+		logger:        logger,
+		conf:          conf,
+		now:           time.Now,
+	}
+}
+
+// Run deletes the expired rows of every table with a retention configured. The retention is read on
+// every run so that it can be changed without a restart.
+func (s *RetentionService) Run(ctx context.Context) error {
+	tables := []struct {
+		key    string
+		delete func(before time.Time) (int64, error)
+	}{
+		{"exchange_prices_days", func(before time.Time) (int64, error) { return s.priceRepo.DeleteExchangePricesBefore(ctx, before) }},
+		{"candles_days", func(before time.Time) (int64, error) {
+			return s.candleRepo.DeleteCandlesBefore(ctx, BaseInterval.String(), before.UnixMilli())
+		}},
+		{"order_book_days", func(before time.Time) (int64, error) {
+			return s.orderBookRepo.DeleteSnapshotsBefore(ctx, before.UnixMilli())
+		}},
+		{"large_trades_days", func(before time.Time) (int64, error) {
+			return s.tradeRepo.DeleteLargeTradesBefore(ctx, before.UnixMilli())
+		}},
+		{"price_spreads_days", func(before time.Time) (int64, error) {
+			return s.spreadRepo.DeletePriceSpreadsBefore(ctx, before.UnixMilli())
+		}},
+	}
+
+	var errs []error
+	for _, table := range tables {
+		days := s.conf.GetInt("retention." + table.key)
+		if days <= 0 {
+			continue
+		}
+		before := s.now().AddDate(0, 0, -days)
+		deleted, err := table.delete(before)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("retention.%s: %w", table.key, err))
+			continue
+		}
+		s.logger.Info("Deleted expired rows", zap.String("retention", table.key), zap.Time("before", before), zap.Int64("deleted", deleted))
+	}
+	return errors.Join(errs...)
+}
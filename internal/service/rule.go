@@ -0,0 +1,225 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"klineio/pkg/exchange"
+	"klineio/pkg/indicator"
+)
+
+// RuleType identifies the indicator condition an alert rule checks.
+type RuleType string
+
+const (
+	RuleRSI       RuleType = "rsi"        // RSI below oversold or above overbought
+	RuleMACDCross RuleType = "macd_cross" // MACD line crossing its signal line
+	RuleBollinger RuleType = "bollinger"  // Close outside the Bollinger bands
+	RuleMACross   RuleType = "ma_cross"   // Golden/death cross of a fast and a slow moving average
+)
+
+// Cross directions accepted by the macd_cross and ma_cross rules. An empty direction matches both.
+const (
+	DirectionBullish = "bullish" // MACD above signal, or golden cross
+	DirectionBearish = "bearish" // MACD below signal, or death cross
+)
+
+// RuleConfig is the YAML shape of an entry under price_monitor.rules.
+// Fields that do not apply to a rule type are ignored; zero values fall back to conventional defaults.
+type RuleConfig struct {
+	Name       string   `mapstructure:"name"`
+	Type       string   `mapstructure:"type"`
+	Interval   string   `mapstructure:"interval"`  // K-line interval, defaults to price_monitor.average.interval
+	Lookback   int      `mapstructure:"lookback"`  // K-lines fetched for evaluation
+	Exchanges  []string `mapstructure:"exchanges"` // Empty means every exchange
+	Symbols    []string `mapstructure:"symbols"`   // Empty means every monitored symbol
+	Period     int      `mapstructure:"period"`    // rsi, bollinger
+	Oversold   float64  `mapstructure:"oversold"`  // rsi
+	Overbought float64  `mapstructure:"overbought"`
+	Fast       int      `mapstructure:"fast"` // macd_cross, ma_cross
+	Slow       int      `mapstructure:"slow"`
+	Signal     int      `mapstructure:"signal"`  // macd_cross
+	StdDev     float64  `mapstructure:"std_dev"` // bollinger
+	MAType     string   `mapstructure:"ma_type"` // ma_cross: sma, ema or wma
+	Direction  string   `mapstructure:"direction"`
+}
+
+// Rule is a validated indicator alert rule.
+type Rule struct {
+	RuleConfig
+	Type      RuleType
+	Bar       exchange.Interval // K-line interval parsed from RuleConfig.Interval
+	exchanges map[string]bool
+	symbols   map[string]bool
+}
+
+// RuleSignal describes a rule that fired on the latest closed K-line.
+type RuleSignal struct {
+	Rule    *Rule
+	BarTime time.Time // Open time of the K-line that triggered the rule
+	Close   float64
+	Message string
+}
+
+// NewRule validates cfg and fills in defaults. defaultInterval is used when cfg.Interval is empty.
+func NewRule(cfg RuleConfig, defaultInterval exchange.Interval) (*Rule, error) {
+	r := &Rule{RuleConfig: cfg, Type: RuleType(strings.ToLower(cfg.Type)), Bar: defaultInterval}
+	if r.Interval != "" {
+		bar, err := exchange.ParseInterval(r.Interval)
+		if err != nil {
+			return nil, err
+		}
+		r.Bar = bar
+	}
+	r.Interval = r.Bar.String()
+	r.Direction = strings.ToLower(r.Direction)
+	if r.Direction != "" && r.Direction != DirectionBullish && r.Direction != DirectionBearish {
+		return nil, fmt.Errorf("unsupported direction: %s", cfg.Direction)
+	}
+
+	var minCandles int
+	switch r.Type {
+	case RuleRSI:
+		r.Period = defaultInt(r.Period, 14)
+		if r.Oversold == 0 && r.Overbought == 0 {
+			r.Oversold, r.Overbought = 30, 70
+		}
+		minCandles = r.Period + 1
+	case RuleMACDCross:
+		r.Fast, r.Slow, r.Signal = defaultInt(r.Fast, 12), defaultInt(r.Slow, 26), defaultInt(r.Signal, 9)
+		minCandles = r.Slow + r.Signal
+	case RuleBollinger:
+		r.Period = defaultInt(r.Period, 20)
+		if r.StdDev == 0 {
+			r.StdDev = 2
+		}
+		minCandles = r.Period
+	case RuleMACross:
+		r.Fast, r.Slow = defaultInt(r.Fast, 50), defaultInt(r.Slow, 200)
+		r.MAType = strings.ToLower(r.MAType)
+		switch r.MAType {
+		case "":
+			r.MAType = "sma"
+		case "sma", "ema", "wma":
+		default:
+			return nil, fmt.Errorf("unsupported ma_type: %s", cfg.MAType)
+		}
+		minCandles = r.Slow + 1
+	default:
+		return nil, fmt.Errorf("unsupported rule type: %s", cfg.Type)
+	}
+	if r.Fast > 0 && r.Slow > 0 && r.Fast >= r.Slow {
+		return nil, fmt.Errorf("fast period %d must be shorter than slow period %d", r.Fast, r.Slow)
+	}
+	if r.Lookback < minCandles+1 {
+		r.Lookback = max(minCandles+1, 100)
+	}
+	if r.Name == "" {
+		r.Name = string(r.Type)
+	}
+
+	r.exchanges = upperSet(r.Exchanges)
+	r.symbols = upperSet(r.Symbols)
+	return r, nil
+}
+
+// Matches reports whether the rule applies to symbol on exchangeName.
+func (r *Rule) Matches(exchangeName, symbol string) bool {
+	if len(r.exchanges) > 0 && !r.exchanges[strings.ToUpper(exchangeName)] {
+		return false
+	}
+	return len(r.symbols) == 0 || r.symbols[strings.ToUpper(symbol)]
+}
+
+// Evaluate checks the rule against closed klines (oldest first) and returns a signal if it fires on the last one.
+func (r *Rule) Evaluate(klines []exchange.Kline) *RuleSignal {
+	if len(klines) < 2 {
+		return nil
+	}
+	last := klines[len(klines)-1]
+	signal := func(format string, args ...interface{}) *RuleSignal {
+		return &RuleSignal{Rule: r, BarTime: last.OpenTime, Close: last.Close, Message: fmt.Sprintf(format, args...)}
+	}
+
+	switch r.Type {
+	case RuleRSI:
+		v := indicator.Last(indicator.Compute(indicator.NewRSI(r.Period), klines))
+		if math.IsNaN(v) {
+			return nil
+		}
+		if r.Oversold > 0 && v <= r.Oversold {
+			return signal("RSI(%d) = %.2f，低于超卖线 %.2f", r.Period, v, r.Oversold)
+		}
+		if r.Overbought > 0 && v >= r.Overbought {
+			return signal("RSI(%d) = %.2f，高于超买线 %.2f", r.Period, v, r.Overbought)
+		}
+	case RuleMACDCross:
+		values := indicator.ComputeMACD(klines, r.Fast, r.Slow, r.Signal)
+		prev, cur := values[len(values)-2], values[len(values)-1]
+		if math.IsNaN(prev.Signal) || math.IsNaN(cur.Signal) {
+			return nil
+		}
+		if r.Direction != DirectionBearish && prev.MACD <= prev.Signal && cur.MACD > cur.Signal {
+			return signal("MACD(%d,%d,%d) 上穿信号线 (MACD %.6f, 信号 %.6f)", r.Fast, r.Slow, r.Signal, cur.MACD, cur.Signal)
+		}
+		if r.Direction != DirectionBullish && prev.MACD >= prev.Signal && cur.MACD < cur.Signal {
+			return signal("MACD(%d,%d,%d) 下穿信号线 (MACD %.6f, 信号 %.6f)", r.Fast, r.Slow, r.Signal, cur.MACD, cur.Signal)
+		}
+	case RuleBollinger:
+		bands := indicator.ComputeBollinger(klines, r.Period, r.StdDev)
+		b := bands[len(bands)-1]
+		if math.IsNaN(b.Middle) {
+			return nil
+		}
+		if r.Direction != DirectionBearish && last.Close > b.Upper {
+			return signal("收盘价 %.4f 突破布林带上轨 %.4f (BOLL(%d,%.1f))", last.Close, b.Upper, r.Period, r.StdDev)
+		}
+		if r.Direction != DirectionBullish && last.Close < b.Lower {
+			return signal("收盘价 %.4f 跌破布林带下轨 %.4f (BOLL(%d,%.1f))", last.Close, b.Lower, r.Period, r.StdDev)
+		}
+	case RuleMACross:
+		fast := indicator.Compute(r.newMA(r.Fast), klines)
+		slow := indicator.Compute(r.newMA(r.Slow), klines)
+		n := len(klines)
+		pf, ps, cf, cs := fast[n-2], slow[n-2], fast[n-1], slow[n-1]
+		if math.IsNaN(ps) || math.IsNaN(pf) {
+			return nil
+		}
+		name := strings.ToUpper(r.MAType)
+		if r.Direction != DirectionBearish && pf <= ps && cf > cs {
+			return signal("金叉：%s(%d) %.4f 上穿 %s(%d) %.4f", name, r.Fast, cf, name, r.Slow, cs)
+		}
+		if r.Direction != DirectionBullish && pf >= ps && cf < cs {
+			return signal("死叉：%s(%d) %.4f 下穿 %s(%d) %.4f", name, r.Fast, cf, name, r.Slow, cs)
+		}
+	}
+	return nil
+}
+
+func (r *Rule) newMA(period int) indicator.Indicator {
+	switch r.MAType {
+	case "ema":
+		return indicator.NewEMA(period)
+	case "wma":
+		return indicator.NewWMA(period)
+	default:
+		return indicator.NewSMA(period)
+	}
+}
+
+func defaultInt(v, def int) int {
+	if v > 0 {
+		return v
+	}
+	return def
+}
+
+func upperSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[strings.ToUpper(v)] = true
+	}
+	return set
+}
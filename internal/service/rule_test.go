@@ -0,0 +1,65 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"klineio/pkg/exchange"
+)
+
+func klinesFromCloses(closes ...float64) []exchange.Kline {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	klines := make([]exchange.Kline, len(closes))
+	for i, c := range closes {
+		klines[i] = exchange.Kline{OpenTime: base.Add(time.Duration(i) * time.Hour), Open: c, High: c, Low: c, Close: c, Volume: 1}
+	}
+	return klines
+}
+
+func TestNewRuleDefaults(t *testing.T) {
+	r, err := NewRule(RuleConfig{Type: "RSI"}, "1d")
+	if err != nil {
+		t.Fatalf("NewRule error: %v", err)
+	}
+	if r.Name != "rsi" || r.Interval != "1d" || r.Period != 14 || r.Oversold != 30 || r.Overbought != 70 || r.Lookback < 15 {
+		t.Errorf("unexpected defaults: %+v", r)
+	}
+
+	if _, err := NewRule(RuleConfig{Type: "unknown"}, "1d"); err == nil {
+		t.Errorf("expected error for unknown rule type")
+	}
+	if _, err := NewRule(RuleConfig{Type: "ma_cross", Fast: 20, Slow: 10}, "1d"); err == nil {
+		t.Errorf("expected error when fast >= slow")
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	r, _ := NewRule(RuleConfig{Type: "rsi", Exchanges: []string{"binance"}, Symbols: []string{"btcusdt"}}, "1h")
+	if !r.Matches("BINANCE", "BTCUSDT") || r.Matches("OKEX", "BTCUSDT") || r.Matches("BINANCE", "ETHUSDT") {
+		t.Errorf("rule filters not applied")
+	}
+}
+
+func TestRuleEvaluate(t *testing.T) {
+	rsi, _ := NewRule(RuleConfig{Type: "rsi", Period: 3, Oversold: 25}, "1h")
+	if sig := rsi.Evaluate(klinesFromCloses(10, 9, 8, 7, 6)); sig == nil {
+		t.Errorf("RSI should fire on a steady decline")
+	}
+	if sig := rsi.Evaluate(klinesFromCloses(6, 7, 8, 9, 10)); sig != nil {
+		t.Errorf("overbought side should be disabled when only oversold is set: %s", sig.Message)
+	}
+
+	cross, _ := NewRule(RuleConfig{Type: "ma_cross", Fast: 2, Slow: 3, Direction: "bullish"}, "1h")
+	if sig := cross.Evaluate(klinesFromCloses(10, 9, 8, 7, 12)); sig == nil {
+		t.Errorf("golden cross not detected")
+	}
+	if sig := cross.Evaluate(klinesFromCloses(7, 8, 9, 10, 5)); sig != nil {
+		t.Errorf("death cross should be ignored for bullish direction: %s", sig.Message)
+	}
+
+	boll, _ := NewRule(RuleConfig{Type: "bollinger", Period: 4, StdDev: 1}, "1h")
+	sig := boll.Evaluate(klinesFromCloses(10, 10.1, 9.9, 10, 8))
+	if sig == nil || !sig.BarTime.Equal(time.Date(2024, 1, 1, 4, 0, 0, 0, time.UTC)) {
+		t.Errorf("lower band breakout not detected on the last bar: %+v", sig)
+	}
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"klineio/internal/model"
+	"klineio/internal/repository"
+	"klineio/pkg/event"
+	"klineio/pkg/exchange"
+	"klineio/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// SpreadMonitorService compares the price of the same instrument across exchanges,
+// stores every observed spread and alerts when a spread stays above the threshold.
+type SpreadMonitorService struct {
+	spreadRepo       repository.PriceSpreadRepository
+	alerts           *AlertService
+	logger           *log.Logger
+	enabled          bool
+	thresholdPercent float64       // Absolute percent spread that counts as a breach
+	sustain          time.Duration // How long a breach must last before alerting
+	maxSkew          time.Duration // Largest gap between the two prices of a pair before it is skipped
+
+	mu          sync.Mutex
+	breachSince map[string]time.Time // Start of the current breach, keyed by symbol:exchangeA:exchangeB
+	alerted     map[string]bool      // Whether the current breach has been alerted
+}
+
+// NewSpreadMonitorService creates a new SpreadMonitorService.
+func NewSpreadMonitorService(
+	spreadRepo repository.PriceSpreadRepository,
+	alerts *AlertService,
+	logger *log.Logger,
+	conf *viper.Viper,
+) *SpreadMonitorService {
+	maxSkew := time.Duration(conf.GetInt("spread_monitor.max_skew_seconds")) * time.Second
+	if maxSkew <= 0 {
+		maxSkew = time.Minute
+	}
+	return &SpreadMonitorService{
+		spreadRepo:       spreadRepo,
+		alerts:           alerts,
+		logger:           logger,
+		enabled:          conf.GetBool("spread_monitor.enabled"),
+		thresholdPercent: conf.GetFloat64("spread_monitor.threshold_percent"),
+		sustain:          time.Duration(conf.GetInt("spread_monitor.sustain_minutes")) * time.Minute,
+		maxSkew:          maxSkew,
+		breachSince:      make(map[string]time.Time),
+		alerted:          make(map[string]bool),
+	}
+}
+
+// Quote is a price and the time it was observed.
+type Quote struct {
+	Price float64
+	Time  time.Time
+}
+
+// PriceBook collects the latest quote per canonical symbol and exchange during a monitor run.
+type PriceBook map[string]map[string]Quote
+
+// Set records price for symbol on exchangeName, observed at at.
+func (b PriceBook) Set(exchangeName, symbol string, price float64, at time.Time) {
+	symbol = exchange.CanonicalSymbol(symbol)
+	if b[symbol] == nil {
+		b[symbol] = make(map[string]Quote)
+	}
+	b[symbol][exchangeName] = Quote{Price: price, Time: at}
+}
+
+// ComputeSpreads builds one spread per exchange pair for every symbol quoted on at least two exchanges.
+// Exchanges within a pair are ordered by name so that the sign of the spread is stable across runs.
+// A pair whose prices were observed more than maxSkew apart is skipped, as the market may have moved
+// in between. A spread is stamped with the later of
+// its two observations.
+func ComputeSpreads(book PriceBook, maxSkew time.Duration) []*model.PriceSpread {
+	var spreads []*model.PriceSpread
+	symbols := make([]string, 0, len(book))
+	for symbol := range book {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	for _, symbol := range symbols {
+		prices := book[symbol]
+		exchanges := make([]string, 0, len(prices))
+		for name := range prices {
+			exchanges = append(exchanges, name)
+		}
+		sort.Strings(exchanges)
+
+		for i := 0; i < len(exchanges); i++ {
+			for j := i + 1; j < len(exchanges); j++ {
+				qa, qb := prices[exchanges[i]], prices[exchanges[j]]
+				a, b := qa.Price, qb.Price
+				if a <= 0 || b <= 0 {
+					continue
+				}
+				skew := qa.Time.Sub(qb.Time)
+				if skew < 0 {
+					skew = -skew
+				}
+				if skew > maxSkew {
+					continue
+				}
+				at := qa.Time
+				if qb.Time.After(at) {
+					at = qb.Time
+				}
+				spreads = append(spreads, &model.PriceSpread{
+					Symbol:        symbol,
+					ExchangeA:     exchanges[i],
+					ExchangeB:     exchanges[j],
+					PriceA:        a,
+					PriceB:        b,
+					Spread:        a - b,
+					SpreadPercent: (a - b) / math.Min(a, b) * 100,
+					Timestamp:     at.UnixMilli(),
+				})
+			}
+		}
+	}
+	return spreads
+}
+
+// Check computes, stores and evaluates the spreads in book.
+func (s *SpreadMonitorService) Check(ctx context.Context, book PriceBook) error {
+	if !s.enabled {
+		return nil
+	}
+
+	spreads := ComputeSpreads(book, s.maxSkew)
+	if len(spreads) == 0 {
+		s.logger.Debug("No instruments quoted on more than one exchange within the skew tolerance", zap.Duration("max_skew", s.maxSkew))
+		return nil
+	}
+	if err := s.spreadRepo.CreatePriceSpreads(ctx, spreads); err != nil {
+		s.logger.Error("Failed to store price spreads", zap.Error(err))
+	}
+
+	for _, spread := range spreads {
+		if !s.sustained(spread, time.UnixMilli(spread.Timestamp)) {
+			continue
+		}
+
+		title := "跨交易所价差警报！"
+		text := fmt.Sprintf("### %s 跨交易所价差警报！\n\n", spread.Symbol) +
+			fmt.Sprintf("- **%s 价格**: %.4f\n", spread.ExchangeA, spread.PriceA) +
+			fmt.Sprintf("- **%s 价格**: %.4f\n", spread.ExchangeB, spread.PriceB) +
+			fmt.Sprintf("- **价差**: %.4f (%.2f%%，阈值: %.2f%%)\n", spread.Spread, spread.SpreadPercent, s.thresholdPercent) +
+			fmt.Sprintf("- **持续时间**: 超过 %s\n", s.sustain) +
+			fmt.Sprintf("- **来源**: 价差监控")
+
+		s.logger.Info("Sending spread alert",
+			zap.String("symbol", spread.Symbol),
+			zap.String("exchangeA", spread.ExchangeA),
+			zap.String("exchangeB", spread.ExchangeB),
+			zap.Float64("spreadPercent", spread.SpreadPercent))
+
+		alert := event.Alert{Kind: event.KindSpread, Symbol: spread.Symbol, Value: math.Abs(spread.SpreadPercent), Threshold: s.thresholdPercent, Title: title, Text: text}
+		if err := s.alerts.Send(ctx, alert); err != nil {
+			s.logger.Error("Failed to send spread alert", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// sustained tracks breaches per exchange pair and reports whether spread has been above the threshold
+// for the configured duration without having been alerted yet. A breach resets once the spread narrows.
+func (s *SpreadMonitorService) sustained(spread *model.PriceSpread, now time.Time) bool {
+	key := spread.Symbol + ":" + spread.ExchangeA + ":" + spread.ExchangeB
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if math.Abs(spread.SpreadPercent) < s.thresholdPercent {
+		delete(s.breachSince, key)
+		delete(s.alerted, key)
+		return false
+	}
+	since, ok := s.breachSince[key]
+	if !ok {
+		since = now
+		s.breachSince[key] = now
+	}
+	if s.alerted[key] || now.Sub(since) < s.sustain {
+		return false
+	}
+	s.alerted[key] = true
+	return true
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestComputeSpreads(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	book := make(PriceBook)
+	book.Set("OKEX", "BTC-USDT", 101, at.Add(20*time.Second))
+	book.Set("BINANCE", "BTCUSDT", 100, at)
+	book.Set("BINANCE", "ETHUSDT", 10, at) // Only quoted on one exchange
+	book.Set("BINANCE", "SOLUSDT", 100, at)
+	book.Set("OKEX", "SOL-USDT", 110, at.Add(5*time.Minute)) // Observed too long after the Binance price
+
+	spreads := ComputeSpreads(book, time.Minute)
+	if len(spreads) != 1 {
+		t.Fatalf("expected 1 spread, got %d", len(spreads))
+	}
+	s := spreads[0]
+	if s.Symbol != "BTCUSDT" || s.ExchangeA != "BINANCE" || s.ExchangeB != "OKEX" {
+		t.Errorf("unexpected pair: %+v", s)
+	}
+	if s.Spread != -1 || math.Abs(s.SpreadPercent+1) > 1e-9 {
+		t.Errorf("unexpected spread %v (%v%%)", s.Spread, s.SpreadPercent)
+	}
+	if s.Timestamp != at.Add(20*time.Second).UnixMilli() {
+		t.Errorf("expected the spread stamped with the later observation, got %d", s.Timestamp)
+	}
+}
+
+func TestSpreadSustained(t *testing.T) {
+	svc := &SpreadMonitorService{
+		thresholdPercent: 0.5,
+		sustain:          10 * time.Minute,
+		breachSince:      make(map[string]time.Time),
+		alerted:          make(map[string]bool),
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	wide := ComputeSpreads(PriceBook{"BTCUSDT": {"BINANCE": {100, start}, "OKEX": {101, start}}}, time.Minute)[0]
+	narrow := ComputeSpreads(PriceBook{"BTCUSDT": {"BINANCE": {100, start}, "OKEX": {100.1, start}}}, time.Minute)[0]
+
+	if svc.sustained(wide, start) {
+		t.Errorf("breach should not alert before the sustain period")
+	}
+	if !svc.sustained(wide, start.Add(10*time.Minute)) {
+		t.Errorf("breach should alert after the sustain period")
+	}
+	if svc.sustained(wide, start.Add(15*time.Minute)) {
+		t.Errorf("breach should alert only once")
+	}
+	if svc.sustained(narrow, start.Add(20*time.Minute)) || svc.sustained(wide, start.Add(25*time.Minute)) {
+		t.Errorf("a new breach should restart the sustain period")
+	}
+}
@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"klineio/internal/model"
+	"klineio/internal/repository"
+	"klineio/pkg/event"
+	"klineio/pkg/exchange"
+	"klineio/pkg/log"
+	"klineio/pkg/runstats"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// Burst is a run of trades within a short window whose combined notional crossed the burst threshold.
+type Burst struct {
+	Start        time.Time
+	End          time.Time
+	Count        int
+	Notional     float64
+	BuyNotional  float64 // Taker buys
+	SellNotional float64 // Taker sells
+}
+
+// DetectBursts scans trades (oldest first) with a sliding window and returns non-overlapping bursts whose
+// notional within window reached threshold. Each burst is grown to the largest notional its window reaches.
+func DetectBursts(trades []exchange.Trade, window time.Duration, threshold float64) []Burst {
+	var bursts []Burst
+	start := 0
+	var sum float64
+	for end := 0; end < len(trades); end++ {
+		sum += trades[end].Notional()
+		for trades[end].Timestamp.Sub(trades[start].Timestamp) > window {
+			sum -= trades[start].Notional()
+			start++
+		}
+		if sum < threshold {
+			continue
+		}
+		// Extend while later trades still fall within the window of the first one.
+		for end+1 < len(trades) && trades[end+1].Timestamp.Sub(trades[start].Timestamp) <= window {
+			end++
+		}
+		b := Burst{Start: trades[start].Timestamp, End: trades[end].Timestamp}
+		for _, t := range trades[start : end+1] {
+			b.Count++
+			b.Notional += t.Notional()
+			if t.Side == exchange.SideSell {
+				b.SellNotional += t.Notional()
+			} else {
+				b.BuyNotional += t.Notional()
+			}
+		}
+		bursts = append(bursts, b)
+		start, sum = end+1, 0
+	}
+	return bursts
+}
+
+// tradeCursor remembers what has been seen of a symbol's trade stream between polls.
+type tradeCursor struct {
+	lastID    int64
+	tail      []exchange.Trade // Trades within the burst window before lastID, so bursts can span polls
+	lastBurst time.Time        // End of the last alerted burst
+}
+
+// TradeService polls recent trades of the monitored symbols, stores large ones and alerts on whale activity.
+type TradeService struct {
+	tradeRepo       repository.TradeRepository
+	universe        *UniverseService
+	exchangeClients map[string]exchange.ExchangeClient
+	alerts          *AlertService
+	logger          *log.Logger
+	enabled         bool
+	fetchLimit      int
+	maxPages        int // Requests per symbol and run when trades keep coming in full pages
+	topNSymbols     int
+	apiRequestDelay time.Duration
+	storeNotional   float64 // Trades at least this large are stored, 0 disables
+	alertNotional   float64 // A single trade at least this large is alerted, 0 disables
+	burstNotional   float64 // Trades adding up to this within burstWindow are alerted, 0 disables
+	burstWindow     time.Duration
+
+	mu      sync.Mutex
+	cursors map[string]*tradeCursor // Keyed by exchange:symbol
+}
+
+// NewTradeService creates a new TradeService.
+func NewTradeService(
+	tradeRepo repository.TradeRepository,
+	universe *UniverseService,
+	binanceClient *exchange.BinanceClient,
+	okexClient *exchange.OKEXClient,
+	alerts *AlertService,
+	logger *log.Logger,
+	conf *viper.Viper,
+) *TradeService {
+	return &TradeService{
+		tradeRepo: tradeRepo,
+		universe:  universe,
+		exchangeClients: map[string]exchange.ExchangeClient{
+			"BINANCE": binanceClient,
+			"OKEX":    okexClient,
+		},
+		alerts:          alerts,
+		logger:          logger,
+		enabled:         conf.GetBool("trades.enabled"),
+		fetchLimit:      defaultInt(conf.GetInt("trades.fetch_limit"), 500),
+		maxPages:        defaultInt(conf.GetInt("trades.max_pages"), 5),
+		topNSymbols:     defaultInt(conf.GetInt("trades.top_n_symbols"), conf.GetInt("price_monitor.top_n_symbols")),
+		apiRequestDelay: time.Duration(conf.GetInt("price_monitor.api_request_delay_ms")) * time.Millisecond,
+		storeNotional:   conf.GetFloat64("trades.store_min_notional"),
+		alertNotional:   conf.GetFloat64("trades.alert_notional"),
+		burstNotional:   conf.GetFloat64("trades.burst_notional"),
+		burstWindow:     time.Duration(defaultInt(conf.GetInt("trades.burst_window_seconds"), 60)) * time.Second,
+		cursors:         make(map[string]*tradeCursor),
+	}
+}
+
+// Run fetches the trades since the previous run for every monitored symbol and evaluates them.
+func (s *TradeService) Run(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+
+	for exchangeName, client := range s.exchangeClients {
+		tickers, err := s.universe.Select(ctx, client, exchangeName, s.topNSymbols)
+		if err != nil {
+			s.logger.Error("Failed to select symbols for trades", zap.Error(err), zap.String("exchange", exchangeName))
+			continue
+		}
+
+		for i, ticker := range tickers {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+
+			if err := s.poll(ctx, client, exchangeName, ticker.Symbol); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				s.logger.Error("Failed to get trades", zap.Error(err), zap.String("exchange", exchangeName), zap.String("symbol", ticker.Symbol))
+				runstats.Failed(ctx)
+			} else {
+				runstats.Succeeded(ctx)
+			}
+
+			if i < len(tickers)-1 {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(s.apiRequestDelay):
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// poll fetches the trades of symbol following the last one seen and processes them, requesting
+// further pages, up to trades.max_pages, while they come back full. Further pages only help on
+// exchanges that page forwards from the last ID, such as Binance; OKX serves the newest trades, so
+// there fetch_limit alone bounds the trades a run can catch up on.
+func (s *TradeService) poll(ctx context.Context, client exchange.ExchangeClient, exchangeName, symbol string) error {
+	canonical := exchange.CanonicalSymbol(symbol)
+	for page := 0; page < s.maxPages; page++ {
+		if page > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(s.apiRequestDelay):
+			}
+		}
+		trades, err := client.GetTradesAfter(ctx, symbol, s.cursor(exchangeName, canonical).lastID, s.fetchLimit)
+		if err != nil {
+			return err
+		}
+		s.process(ctx, exchangeName, canonical, trades)
+		if len(trades) < s.fetchLimit {
+			return nil
+		}
+	}
+	return nil
+}
+
+// cursor returns the cursor of symbol on exchangeName, creating an empty one on first use.
+func (s *TradeService) cursor(exchangeName, symbol string) *tradeCursor {
+	key := exchangeName + ":" + symbol
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cursor, ok := s.cursors[key]
+	if !ok {
+		cursor = &tradeCursor{}
+		s.cursors[key] = cursor
+	}
+	return cursor
+}
+
+// process handles the trades of one symbol that were not seen by a previous run. The first trades
+// seen of a symbol only seed its cursor: they are stored but not alerted, as they may predate the
+// process and have been alerted before a restart.
+func (s *TradeService) process(ctx context.Context, exchangeName, symbol string, trades []exchange.Trade) {
+	cursor := s.cursor(exchangeName, symbol)
+	seeding := cursor.lastID == 0
+
+	var fresh []exchange.Trade
+	for _, t := range trades {
+		if t.ID > cursor.lastID {
+			fresh = append(fresh, t)
+		}
+	}
+	if len(fresh) == 0 {
+		return
+	}
+	if cursor.lastID > 0 && fresh[0].ID > cursor.lastID+1 {
+		// Both exchanges number trades consecutively per symbol, so a gap means the fetch did not reach back far enough.
+		s.logger.Warn("Trades were missed between runs; raise trades.fetch_limit, trades.max_pages (Binance only), or run more often",
+			zap.String("exchange", exchangeName), zap.String("symbol", symbol), zap.Int64("missed", fresh[0].ID-cursor.lastID-1))
+	}
+
+	var large []*model.LargeTrade
+	for _, t := range fresh {
+		notional := t.Notional()
+		if s.storeNotional > 0 && notional >= s.storeNotional {
+			large = append(large, &model.LargeTrade{
+				Symbol:    symbol,
+				Exchange:  exchangeName,
+				TradeID:   t.ID,
+				Price:     t.Price,
+				Quantity:  t.Quantity,
+				Notional:  notional,
+				Side:      t.Side,
+				Timestamp: t.Timestamp.UnixMilli(),
+			})
+		}
+		if !seeding && s.alertNotional > 0 && notional >= s.alertNotional {
+			s.sendTradeAlert(ctx, exchangeName, symbol, t)
+		}
+	}
+	if err := s.tradeRepo.CreateLargeTrades(ctx, large); err != nil {
+		s.logger.Error("Failed to store large trades", zap.Error(err), zap.String("exchange", exchangeName), zap.String("symbol", symbol))
+	}
+
+	window := append(append([]exchange.Trade{}, cursor.tail...), fresh...)
+	if s.burstNotional > 0 {
+		for _, b := range DetectBursts(window, s.burstWindow, s.burstNotional) {
+			if !b.Start.After(cursor.lastBurst) {
+				continue // Overlaps a burst that was already alerted
+			}
+			cursor.lastBurst = b.End
+			if seeding {
+				continue
+			}
+			if b.Count == 1 && s.alertNotional > 0 && b.Notional >= s.alertNotional {
+				continue // Already alerted as a single large trade
+			}
+			s.sendBurstAlert(ctx, exchangeName, symbol, b)
+		}
+	}
+
+	last := fresh[len(fresh)-1]
+	cursor.lastID = last.ID
+	cursor.tail = nil
+	for _, t := range window {
+		if last.Timestamp.Sub(t.Timestamp) <= s.burstWindow {
+			cursor.tail = append(cursor.tail, t)
+		}
+	}
+}
+
+func (s *TradeService) sendTradeAlert(ctx context.Context, exchangeName, symbol string, t exchange.Trade) {
+	side := "主动买入"
+	if t.Side == exchange.SideSell {
+		side = "主动卖出"
+	}
+	title := "大额成交警报！"
+	text := fmt.Sprintf("### %s 大额成交警报！\n\n", symbol) +
+		fmt.Sprintf("- **交易所**: %s\n", exchangeName) +
+		fmt.Sprintf("- **方向**: %s\n", side) +
+		fmt.Sprintf("- **成交价**: %.4f\n", t.Price) +
+		fmt.Sprintf("- **成交量**: %.4f\n", t.Quantity) +
+		fmt.Sprintf("- **成交额**: %.0f (阈值: %.0f)\n", t.Notional(), s.alertNotional) +
+		fmt.Sprintf("- **时间**: %s\n", t.Timestamp.Format("2006-01-02 15:04:05")) +
+		fmt.Sprintf("- **来源**: 成交监控")
+
+	s.logger.Info("Sending large trade alert",
+		zap.String("exchange", exchangeName),
+		zap.String("symbol", symbol),
+		zap.Int64("tradeId", t.ID),
+		zap.Float64("notional", t.Notional()))
+	alert := event.Alert{Kind: event.KindLargeTrade, Exchange: exchangeName, Symbol: symbol, Value: t.Notional(), Threshold: s.alertNotional, Title: title, Text: text}
+	if err := s.alerts.Send(ctx, alert); err != nil {
+		s.logger.Error("Failed to send large trade alert", zap.Error(err))
+	}
+}
+
+func (s *TradeService) sendBurstAlert(ctx context.Context, exchangeName, symbol string, b Burst) {
+	title := "密集大额成交警报！"
+	text := fmt.Sprintf("### %s 密集大额成交警报！\n\n", symbol) +
+		fmt.Sprintf("- **交易所**: %s\n", exchangeName) +
+		fmt.Sprintf("- **时间段**: %s - %s\n", b.Start.Format("15:04:05"), b.End.Format("15:04:05")) +
+		fmt.Sprintf("- **成交笔数**: %d\n", b.Count) +
+		fmt.Sprintf("- **成交额**: %.0f (阈值: %.0f / %s)\n", b.Notional, s.burstNotional, s.burstWindow) +
+		fmt.Sprintf("- **主动买入/卖出**: %.0f / %.0f\n", b.BuyNotional, b.SellNotional) +
+		fmt.Sprintf("- **来源**: 成交监控")
+
+	s.logger.Info("Sending trade burst alert",
+		zap.String("exchange", exchangeName),
+		zap.String("symbol", symbol),
+		zap.Int("count", b.Count),
+		zap.Float64("notional", b.Notional))
+	alert := event.Alert{Kind: event.KindTradeBurst, Exchange: exchangeName, Symbol: symbol, Value: b.Notional, Threshold: s.burstNotional, Title: title, Text: text}
+	if err := s.alerts.Send(ctx, alert); err != nil {
+		s.logger.Error("Failed to send trade burst alert", zap.Error(err))
+	}
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"klineio/internal/model"
+	"klineio/pkg/exchange"
+	"klineio/pkg/log"
+	"klineio/pkg/notifier"
+
+	"go.uber.org/zap"
+)
+
+// trade builds a 1-unit taker buy at price, so its notional equals price.
+func trade(id int64, sec int, price float64) exchange.Trade {
+	return exchange.Trade{ID: id, Price: price, Quantity: 1, Side: exchange.SideBuy, Timestamp: time.Unix(int64(sec), 0)}
+}
+
+func TestDetectBursts(t *testing.T) {
+	trades := []exchange.Trade{
+		trade(1, 0, 100),
+		trade(2, 10, 400),
+		trade(3, 20, 600), // 1100 within 60s of trade 1: burst
+		trade(4, 50, 100), // Still within 60s of trade 1, folded into the burst
+		trade(5, 200, 900),
+		trade(6, 300, 900), // 1800, but 100s apart
+	}
+	trades[2].Side = exchange.SideSell
+
+	bursts := DetectBursts(trades, time.Minute, 1000)
+	if len(bursts) != 1 {
+		t.Fatalf("expected 1 burst, got %+v", bursts)
+	}
+	b := bursts[0]
+	if b.Count != 4 || b.Notional != 1200 || b.SellNotional != 600 || b.BuyNotional != 600 || !b.End.Equal(time.Unix(50, 0)) {
+		t.Errorf("unexpected burst: %+v", b)
+	}
+}
+
+type fakeTradeRepo struct {
+	stored []*model.LargeTrade
+}
+
+func (r *fakeTradeRepo) CreateLargeTrades(ctx context.Context, trades []*model.LargeTrade) error {
+	r.stored = append(r.stored, trades...)
+	return nil
+}
+
+func (r *fakeTradeRepo) ListLargeTrades(ctx context.Context, symbol string, since int64) ([]*model.LargeTrade, error) {
+	return nil, nil
+}
+
+func (r *fakeTradeRepo) DeleteLargeTradesBefore(ctx context.Context, before int64) (int64, error) {
+	return 0, nil
+}
+
+func TestTradeServiceProcess(t *testing.T) {
+	var alerts int32
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&alerts, 1)
+	}))
+	defer webhook.Close()
+
+	logger := &log.Logger{Logger: zap.NewNop()}
+	repo := &fakeTradeRepo{}
+	svc := &TradeService{
+		tradeRepo:     repo,
+		alerts:        &AlertService{notifiers: []notifier.Notifier{notifier.NewDingTalkNotifier(webhook.URL, logger)}, logger: logger},
+		logger:        logger,
+		storeNotional: 500,
+		alertNotional: 5000,
+		burstNotional: 1500,
+		burstWindow:   time.Minute,
+		cursors:       make(map[string]*tradeCursor),
+	}
+	ctx := context.Background()
+
+	// First poll: one trade is stored, nothing alerted.
+	svc.process(ctx, "BINANCE", "BTCUSDT", []exchange.Trade{trade(1, 0, 100), trade(2, 10, 800)})
+	// Second poll overlaps the first. Trade 3 completes a burst with trade 2 from the previous poll.
+	svc.process(ctx, "BINANCE", "BTCUSDT", []exchange.Trade{trade(2, 10, 800), trade(3, 30, 900)})
+	// Third poll: a whale trade. It exceeds the burst threshold on its own but is only alerted once.
+	svc.process(ctx, "BINANCE", "BTCUSDT", []exchange.Trade{trade(4, 200, 6000)})
+
+	if len(repo.stored) != 3 || repo.stored[0].TradeID != 2 || repo.stored[2].Notional != 6000 {
+		t.Errorf("unexpected stored trades: %d", len(repo.stored))
+	}
+	// The burst across polls and the whale trade.
+	if got := atomic.LoadInt32(&alerts); got != 2 {
+		t.Errorf("expected 2 alerts, got %d", got)
+	}
+}
+
+// tradeClient pages forward through trades like Binance aggTrades.
+type tradeClient struct {
+	listingClient
+	trades   []exchange.Trade
+	requests int
+}
+
+func (c *tradeClient) GetTradesAfter(ctx context.Context, symbol string, afterID int64, limit int) ([]exchange.Trade, error) {
+	c.requests++
+	if afterID == 0 {
+		return c.trades[max(0, len(c.trades)-limit):], nil
+	}
+	var out []exchange.Trade
+	for _, t := range c.trades {
+		if t.ID > afterID && len(out) < limit {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func TestTradeServicePoll(t *testing.T) {
+	var alerts int32
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&alerts, 1)
+	}))
+	defer webhook.Close()
+
+	logger := &log.Logger{Logger: zap.NewNop()}
+	repo := &fakeTradeRepo{}
+	svc := &TradeService{
+		tradeRepo:     repo,
+		alerts:        &AlertService{notifiers: []notifier.Notifier{notifier.NewDingTalkNotifier(webhook.URL, logger)}, logger: logger},
+		logger:        logger,
+		fetchLimit:    2,
+		maxPages:      5,
+		storeNotional: 500,
+		alertNotional: 5000,
+		burstWindow:   time.Minute,
+		cursors:       make(map[string]*tradeCursor),
+	}
+	client := &tradeClient{}
+	for id := int64(1); id <= 5; id++ {
+		client.trades = append(client.trades, trade(id, int(id)*100, 100))
+	}
+	client.trades[4].Price = 6000
+	ctx := context.Background()
+
+	// The first run only seeds the cursor, so the whale trade, which may have been alerted before a restart, is stored but not alerted.
+	if err := svc.poll(ctx, client, "BINANCE", "BTCUSDT"); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(&alerts); got != 0 {
+		t.Errorf("expected no alerts while seeding, got %d", got)
+	}
+
+	// Five new trades take three pages of two; none is skipped.
+	for id := int64(6); id <= 10; id++ {
+		client.trades = append(client.trades, trade(id, int(id)*100, 100))
+	}
+	client.trades[8].Price = 7000
+	client.requests = 0
+	if err := svc.poll(ctx, client, "BINANCE", "BTCUSDT"); err != nil {
+		t.Fatal(err)
+	}
+	if client.requests != 3 {
+		t.Errorf("expected 3 requests, got %d", client.requests)
+	}
+	if cursor := svc.cursors["BINANCE:BTCUSDT"]; cursor.lastID != 10 {
+		t.Errorf("expected the cursor at trade 10, got %d", cursor.lastID)
+	}
+	if len(repo.stored) != 2 || repo.stored[1].TradeID != 9 {
+		t.Errorf("unexpected stored trades: %d", len(repo.stored))
+	}
+	if got := atomic.LoadInt32(&alerts); got != 1 {
+		t.Errorf("expected 1 alert, got %d", got)
+	}
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"klineio/pkg/exchange"
+	"klineio/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// quoteAsset is the quote currency of every pair returned by GetTopVolumeTickers.
+const quoteAsset = "USDT"
+
+// usdStablecoins lists base assets pegged to the US dollar, which portfolios value at 1 USDT.
+var usdStablecoins = map[string]bool{
+	"USDC": true, "FDUSD": true, "TUSD": true, "BUSD": true, "DAI": true, "USDP": true,
+	"PYUSD": true, "USDD": true, "USDE": true, "USDS": true, "USD1": true, "RLUSD": true,
+	"GUSD": true, "FRAX": true, "LUSD": true, "SUSD": true, "USDJ": true, "UST": true,
+}
+
+// knownStablecoins lists base assets pegged to a fiat currency. Pairs against USDT barely move and only add noise.
+var knownStablecoins = func() map[string]bool {
+	coins := map[string]bool{"EUR": true, "EURI": true, "AEUR": true, "EURC": true}
+	for coin := range usdStablecoins {
+		coins[coin] = true
+	}
+	return coins
+}()
+
+// leveragedSuffixes are appended to an underlying asset to name a leveraged token, e.g., BTCUP or ETHBEAR.
+var leveragedSuffixes = []string{"UP", "DOWN", "BULL", "BEAR"}
+
+// leveragedPattern matches tokens like BTC3L or ETH5S, which are unambiguous without knowing the underlying.
+var leveragedPattern = regexp.MustCompile(`^[A-Z0-9]+[0-9]+[LS]$`)
+
+// UniverseConfig is the YAML shape of the universe section.
+type UniverseConfig struct {
+	Allowlist          []string // Always monitored, bypassing every other rule
+	Denylist           []string // Never monitored
+	IncludePatterns    []string // If set, a symbol must match at least one
+	ExcludePatterns    []string // Symbols matching any are dropped
+	ExcludeStablecoins bool
+	ExcludeLeveraged   bool
+	Stablecoins        []string // Extra base assets treated as stablecoins
+	MinQuoteVolume     float64  // Minimum 24h quote volume in USDT
+	MinListingDays     int      // Minimum number of daily candles an exchange must have
+}
+
+// Universe decides which tickers are eligible for monitoring. It does not check listing age,
+// which needs exchange calls; see UniverseService.
+type Universe struct {
+	cfg         UniverseConfig
+	allow       map[string]bool
+	deny        map[string]bool
+	stablecoins map[string]bool
+	include     []*regexp.Regexp
+	exclude     []*regexp.Regexp
+}
+
+// NewUniverse validates cfg and compiles its patterns.
+func NewUniverse(cfg UniverseConfig) (*Universe, error) {
+	u := &Universe{
+		cfg:         cfg,
+		allow:       canonicalSet(cfg.Allowlist),
+		deny:        canonicalSet(cfg.Denylist),
+		stablecoins: upperSet(cfg.Stablecoins),
+	}
+	for base := range knownStablecoins {
+		u.stablecoins[base] = true
+	}
+	var err error
+	if u.include, err = compilePatterns(cfg.IncludePatterns); err != nil {
+		return nil, err
+	}
+	if u.exclude, err = compilePatterns(cfg.ExcludePatterns); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Filter returns the eligible tickers, keeping their order. Leveraged tokens are recognized by
+// checking whether their underlying also appears in tickers, so pass the full list, not a top-N slice.
+func (u *Universe) Filter(tickers []exchange.Ticker) []exchange.Ticker {
+	listed := make(map[string]bool, len(tickers))
+	for _, t := range tickers {
+		listed[baseAsset(t.Symbol)] = true
+	}
+
+	var out []exchange.Ticker
+	for _, t := range tickers {
+		if u.reject(t, listed) != "" {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// Allowed reports whether symbol is on the allowlist.
+func (u *Universe) Allowed(symbol string) bool {
+	return u.allow[exchange.CanonicalSymbol(symbol)]
+}
+
+// reject returns why a ticker is excluded, or an empty string if it is eligible.
+func (u *Universe) reject(t exchange.Ticker, listed map[string]bool) string {
+	symbol := exchange.CanonicalSymbol(t.Symbol)
+	if u.allow[symbol] {
+		return ""
+	}
+	if u.deny[symbol] {
+		return "denylist"
+	}
+	if len(u.include) > 0 && !matchAny(u.include, symbol) {
+		return "include_patterns"
+	}
+	if matchAny(u.exclude, symbol) {
+		return "exclude_patterns"
+	}
+	base := baseAsset(symbol)
+	if u.cfg.ExcludeStablecoins && u.isStablecoin(base, t.Price) {
+		return "stablecoin"
+	}
+	if u.cfg.ExcludeLeveraged && isLeveraged(base, listed) {
+		return "leveraged"
+	}
+	if t.QuoteVolume < u.cfg.MinQuoteVolume {
+		return "min_quote_volume"
+	}
+	return ""
+}
+
+// isStablecoin reports whether base is a known stablecoin, or looks like an unknown USD stablecoin
+// trading within 2% of the peg.
+func (u *Universe) isStablecoin(base string, price float64) bool {
+	if u.stablecoins[base] {
+		return true
+	}
+	return strings.Contains(base, "USD") && price > 0.98 && price < 1.02
+}
+
+// isLeveraged reports whether base names a leveraged token. UP/DOWN/BULL/BEAR suffixes only count
+// when the underlying is listed too, so that tokens such as JUP or SYRUP are kept.
+func isLeveraged(base string, listed map[string]bool) bool {
+	if leveragedPattern.MatchString(base) {
+		return true
+	}
+	for _, suffix := range leveragedSuffixes {
+		if underlying := strings.TrimSuffix(base, suffix); underlying != base && underlying != "" && listed[underlying] {
+			return true
+		}
+	}
+	return false
+}
+
+// baseAsset strips the quote currency from a canonical symbol, e.g., BTCUSDT becomes BTC.
+func baseAsset(symbol string) string {
+	return strings.TrimSuffix(exchange.CanonicalSymbol(symbol), quoteAsset)
+}
+
+func canonicalSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[exchange.CanonicalSymbol(v)] = true
+	}
+	return set
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid universe pattern %q: %w", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func matchAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// UniverseService selects the symbols to monitor on each exchange.
+type UniverseService struct {
+	universe *Universe
+	logger   *log.Logger
+
+	mu       sync.Mutex
+	seasoned map[string]bool      // Symbols known to be older than MinListingDays; this never changes back
+	young    map[string]time.Time // Symbols found too young, and when to check again
+}
+
+// NewUniverseService creates a new UniverseService from the universe config section.
+// Stablecoins and leveraged tokens are excluded unless explicitly disabled.
+func NewUniverseService(logger *log.Logger, conf *viper.Viper) *UniverseService {
+	cfg := UniverseConfig{
+		Allowlist:          conf.GetStringSlice("universe.allowlist"),
+		Denylist:           conf.GetStringSlice("universe.denylist"),
+		IncludePatterns:    conf.GetStringSlice("universe.include_patterns"),
+		ExcludePatterns:    conf.GetStringSlice("universe.exclude_patterns"),
+		ExcludeStablecoins: !conf.IsSet("universe.exclude_stablecoins") || conf.GetBool("universe.exclude_stablecoins"),
+		ExcludeLeveraged:   !conf.IsSet("universe.exclude_leveraged") || conf.GetBool("universe.exclude_leveraged"),
+		Stablecoins:        conf.GetStringSlice("universe.stablecoins"),
+		MinQuoteVolume:     conf.GetFloat64("universe.min_quote_volume"),
+		MinListingDays:     conf.GetInt("universe.min_listing_days"),
+	}
+	universe, err := NewUniverse(cfg)
+	if err != nil {
+		logger.Warn("Invalid universe config, ignoring patterns", zap.Error(err))
+		cfg.IncludePatterns, cfg.ExcludePatterns = nil, nil
+		universe, _ = NewUniverse(cfg)
+	}
+	return &UniverseService{
+		universe: universe,
+		logger:   logger,
+		seasoned: make(map[string]bool),
+		young:    make(map[string]time.Time),
+	}
+}
+
+// Select returns up to limit eligible tickers from client, ranked by quote volume.
+func (s *UniverseService) Select(ctx context.Context, client exchange.ExchangeClient, exchangeName string, limit int) ([]exchange.Ticker, error) {
+	all, err := client.GetTopVolumeTickers(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	candidates := s.universe.Filter(all)
+	s.logger.Debug("Universe filtered",
+		zap.String("exchange", exchangeName),
+		zap.Int("total", len(all)),
+		zap.Int("eligible", len(candidates)))
+
+	var out []exchange.Ticker
+	for _, t := range candidates {
+		if limit > 0 && len(out) >= limit {
+			break
+		}
+		if !s.universe.Allowed(t.Symbol) && !s.oldEnough(ctx, client, exchangeName, t.Symbol) {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// oldEnough reports whether symbol has traded for at least MinListingDays, judging by the number
+// of daily candles the exchange returns. MinListingDays is capped at the candles the exchange
+// returns per request, 300 on OKX. Results are cached, and errors let the symbol through.
+func (s *UniverseService) oldEnough(ctx context.Context, client exchange.ExchangeClient, exchangeName, symbol string) bool {
+	days := s.universe.cfg.MinListingDays
+	if days <= 0 {
+		return true
+	}
+	if limiter, ok := client.(exchange.KlineLimiter); ok && limiter.MaxKlines() > 0 {
+		days = min(days, limiter.MaxKlines())
+	}
+	key := overrideKey(exchangeName, symbol)
+	now := time.Now()
+
+	s.mu.Lock()
+	if s.seasoned[key] {
+		s.mu.Unlock()
+		return true
+	}
+	if until, ok := s.young[key]; ok && now.Before(until) {
+		s.mu.Unlock()
+		return false
+	}
+	s.mu.Unlock()
+
+	klines, err := client.GetKlines(ctx, symbol, exchange.Interval1d, days)
+	if err != nil {
+		s.logger.Warn("Failed to check listing age", zap.Error(err), zap.String("exchange", exchangeName), zap.String("symbol", symbol))
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(klines) >= days {
+		s.seasoned[key] = true
+		delete(s.young, key)
+		return true
+	}
+	s.young[key] = now.Add(24 * time.Hour)
+	s.logger.Info("Skipping recently listed symbol",
+		zap.String("exchange", exchangeName),
+		zap.String("symbol", symbol),
+		zap.Int("days", len(klines)),
+		zap.Int("min_listing_days", days))
+	return false
+}
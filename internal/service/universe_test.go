@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"klineio/pkg/exchange"
+	"klineio/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+func symbols(tickers []exchange.Ticker) []string {
+	out := make([]string, len(tickers))
+	for i, t := range tickers {
+		out[i] = t.Symbol
+	}
+	return out
+}
+
+func TestUniverseFilter(t *testing.T) {
+	tickers := []exchange.Ticker{
+		{Symbol: "BTCUSDT", Price: 60000, QuoteVolume: 1e9},
+		{Symbol: "USDCUSDT", Price: 1.0001, QuoteVolume: 9e8},
+		{Symbol: "FDUSDUSDT", Price: 0.9998, QuoteVolume: 8e8},
+		{Symbol: "NEWUSDXUSDT", Price: 1.001, QuoteVolume: 7e8}, // Unknown stablecoin near the peg
+		{Symbol: "ETHUSDT", Price: 3000, QuoteVolume: 6e8},
+		{Symbol: "BTCUPUSDT", Price: 20, QuoteVolume: 5e8},
+		{Symbol: "ETH3LUSDT", Price: 2, QuoteVolume: 4e8},
+		{Symbol: "JUPUSDT", Price: 1, QuoteVolume: 3e8}, // UP suffix, but J is not listed
+		{Symbol: "SCAMUSDT", Price: 5, QuoteVolume: 2e8},
+		{Symbol: "DUSTUSDT", Price: 5, QuoteVolume: 1e3},
+	}
+	u, err := NewUniverse(UniverseConfig{
+		Denylist:           []string{"SCAM-USDT"},
+		ExcludeStablecoins: true,
+		ExcludeLeveraged:   true,
+		MinQuoteVolume:     1e6,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := symbols(u.Filter(tickers))
+	want := []string{"BTCUSDT", "ETHUSDT", "JUPUSDT"}
+	if len(got) != len(want) {
+		t.Fatalf("Filter = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Filter = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestUniversePatternsAndAllowlist(t *testing.T) {
+	tickers := []exchange.Ticker{
+		{Symbol: "BTCUSDT", QuoteVolume: 1e9},
+		{Symbol: "USDCUSDT", Price: 1, QuoteVolume: 9e8},
+		{Symbol: "ETHUSDT", QuoteVolume: 6e8},
+		{Symbol: "PEPEUSDT", QuoteVolume: 5e8},
+	}
+	u, err := NewUniverse(UniverseConfig{
+		Allowlist:          []string{"USDCUSDT"},
+		IncludePatterns:    []string{"^(BTC|ETH|PEPE)"},
+		ExcludePatterns:    []string{"^PEPE"},
+		ExcludeStablecoins: true,
+		MinQuoteVolume:     7e8,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The allowlist bypasses the include pattern, stablecoin detection and the volume floor.
+	if got := symbols(u.Filter(tickers)); len(got) != 2 || got[0] != "BTCUSDT" || got[1] != "USDCUSDT" {
+		t.Errorf("Filter = %v", got)
+	}
+
+	if _, err := NewUniverse(UniverseConfig{ExcludePatterns: []string{"("}}); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+// listingClient returns as many daily candles as a symbol has been listed for.
+type listingClient struct {
+	tickers   []exchange.Ticker
+	ages      map[string]int
+	maxKlines int // Candles returned per request at most, unlimited if 0
+	calls     int
+}
+
+func (c *listingClient) MaxKlines() int {
+	return c.maxKlines
+}
+
+func (c *listingClient) GetLatestPrice(ctx context.Context, symbol string) (float64, error) {
+	return 0, nil
+}
+
+func (c *listingClient) GetKlines(ctx context.Context, symbol string, interval exchange.Interval, limit int) ([]exchange.Kline, error) {
+	c.calls++
+	if c.maxKlines > 0 {
+		limit = min(limit, c.maxKlines)
+	}
+	return make([]exchange.Kline, min(c.ages[symbol], limit)), nil
+}
+
+func (c *listingClient) GetTopVolumeTickers(ctx context.Context, limit int) ([]exchange.Ticker, error) {
+	return c.tickers, nil
+}
+
+func (c *listingClient) GetOrderBook(ctx context.Context, symbol string, depth int) (*exchange.OrderBook, error) {
+	return nil, nil
+}
+
+func (c *listingClient) GetTradesAfter(ctx context.Context, symbol string, afterID int64, limit int) ([]exchange.Trade, error) {
+	return nil, nil
+}
+
+func (c *listingClient) SupportsInterval(interval exchange.Interval) bool {
+	return true
+}
+
+func TestUniverseSelectListingAge(t *testing.T) {
+	client := &listingClient{
+		tickers: []exchange.Ticker{
+			{Symbol: "NEWUSDT", QuoteVolume: 3e9},
+			{Symbol: "BTCUSDT", QuoteVolume: 2e9},
+			{Symbol: "ETHUSDT", QuoteVolume: 1e9},
+			{Symbol: "SOLUSDT", QuoteVolume: 5e8},
+		},
+		ages: map[string]int{"NEWUSDT": 3, "BTCUSDT": 1000, "ETHUSDT": 1000, "SOLUSDT": 1000},
+	}
+	u, _ := NewUniverse(UniverseConfig{MinListingDays: 30})
+	svc := &UniverseService{
+		universe: u,
+		logger:   &log.Logger{Logger: zap.NewNop()},
+		seasoned: make(map[string]bool),
+		young:    make(map[string]time.Time),
+	}
+
+	for run := 0; run < 2; run++ {
+		got, err := svc.Select(context.Background(), client, "BINANCE", 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := symbols(got); len(s) != 2 || s[0] != "BTCUSDT" || s[1] != "ETHUSDT" {
+			t.Errorf("run %d: Select = %v", run, s)
+		}
+	}
+	// NEW, BTC and ETH are checked once; SOL is never needed, and the second run is served from the cache.
+	if client.calls != 3 {
+		t.Errorf("expected 3 listing checks, got %d", client.calls)
+	}
+}
+
+func TestUniverseListingAgeCappedByExchange(t *testing.T) {
+	client := &listingClient{
+		tickers: []exchange.Ticker{
+			{Symbol: "BTCUSDT", QuoteVolume: 2e9},
+			{Symbol: "NEWUSDT", QuoteVolume: 1e9},
+		},
+		ages:      map[string]int{"BTCUSDT": 1000, "NEWUSDT": 100},
+		maxKlines: 300,
+	}
+	u, _ := NewUniverse(UniverseConfig{MinListingDays: 365})
+	svc := &UniverseService{
+		universe: u,
+		logger:   &log.Logger{Logger: zap.NewNop()},
+		seasoned: make(map[string]bool),
+		young:    make(map[string]time.Time),
+	}
+
+	// A year of history cannot be seen in 300 daily candles, so 300 days is enough
+	got, err := svc.Select(context.Background(), client, "OKEX", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := symbols(got); len(s) != 1 || s[0] != "BTCUSDT" {
+		t.Errorf("Select = %v", s)
+	}
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"klineio/internal/repository"
+	"klineio/pkg/log"
+	"klineio/pkg/notifier"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// Keys of the conditions the watchdog tracks, besides one per exchange.
+const (
+	watchdogPrices   = "prices"
+	watchdogNotifier = "notifier"
+)
+
+// watchdogCondition is the state of one tracked condition.
+type watchdogCondition struct {
+	failures int
+	since    time.Time // Time of the first of the consecutive failures
+	firing   bool
+}
+
+// WatchdogService watches the monitoring pipeline itself and sends operational alerts to a separate
+// DingTalk channel, watchdog.webhook_url, when it breaks: an exchange failing several runs in a row,
+// no price stored for a while, or alerts failing to be delivered. Once the condition clears, it sends
+// a recovery message. Without a webhook the alerts are only logged.
+//
+// Its methods do nothing on a nil WatchdogService.
+type WatchdogService struct {
+	notifier         notifier.Notifier
+	priceRepo        repository.ExchangePriceRepository
+	logger           *log.Logger
+	exchangeFailures int           // Consecutive failed runs of an exchange before alerting
+	staleAfter       time.Duration // Longest time without a stored price before alerting
+	notifierFailures int           // Consecutive failed deliveries before alerting
+	started          time.Time     // Stands in for the latest price until one is stored
+	now              func() time.Time
+
+	mu         sync.Mutex
+	conditions map[string]*watchdogCondition
+}
+
+// NewWatchdogService creates a new WatchdogService from the watchdog config section.
+func NewWatchdogService(priceRepo repository.ExchangePriceRepository, logger *log.Logger, conf *viper.Viper) *WatchdogService {
+	w := &WatchdogService{
+		priceRepo:        priceRepo,
+		logger:           logger,
+		exchangeFailures: defaultInt(conf.GetInt("watchdog.exchange_failures"), 3),
+		staleAfter:       time.Duration(defaultInt(conf.GetInt("watchdog.stale_prices_minutes"), 30)) * time.Minute,
+		notifierFailures: defaultInt(conf.GetInt("watchdog.notifier_failures"), 3),
+		started:          time.Now(),
+		now:              time.Now,
+		conditions:       make(map[string]*watchdogCondition),
+	}
+	if webhook := conf.GetString("watchdog.webhook_url"); webhook != "" {
+		w.notifier = notifier.NewDingTalkNotifier(webhook, logger)
+	} else {
+		logger.Warn("watchdog.webhook_url is not set, pipeline failures will only be logged")
+	}
+	return w
+}
+
+// ExchangeRun records the outcome of one monitor run on an exchange; err is nil if it stored any price.
+func (w *WatchdogService) ExchangeRun(ctx context.Context, exchangeName string, err error) {
+	if w == nil {
+		return
+	}
+	w.observe(ctx, "exchange:"+exchangeName, err, w.exchangeFailures, func(failures int) string {
+		return fmt.Sprintf("交易所 %s 连续 %d 次监控失败", exchangeName, failures)
+	})
+}
+
+// Delivery records the outcome of delivering one alert to the notifiers.
+func (w *WatchdogService) Delivery(ctx context.Context, err error) {
+	if w == nil {
+		return
+	}
+	w.observe(ctx, watchdogNotifier, err, w.notifierFailures, func(failures int) string {
+		return fmt.Sprintf("告警通知连续 %d 次发送失败", failures)
+	})
+}
+
+// CheckPrices checks that a price was stored within watchdog.stale_prices_minutes. Failing to query
+// the latest price, e.g. with the database down, counts as stale.
+func (w *WatchdogService) CheckPrices(ctx context.Context) {
+	if w == nil {
+		return
+	}
+	latest, err := w.priceRepo.GetLatestTimestamp(ctx)
+	if err == nil {
+		last := time.UnixMilli(latest)
+		if last.Before(w.started) {
+			last = w.started
+		}
+		if age := w.now().Sub(last); age > w.staleAfter {
+			err = fmt.Errorf("no price stored for %s", age.Truncate(time.Second))
+		}
+	}
+	w.observe(ctx, watchdogPrices, err, 1, func(int) string {
+		return fmt.Sprintf("超过 %d 分钟没有写入价格", int(w.staleAfter.Minutes()))
+	})
+}
+
+// observe records one result of the condition called key. It alerts once failures in a row reach
+// threshold, and sends a recovery message on the next success after that.
+func (w *WatchdogService) observe(ctx context.Context, key string, err error, threshold int, describe func(failures int) string) {
+	now := w.now()
+	w.mu.Lock()
+	c, ok := w.conditions[key]
+	if !ok {
+		c = &watchdogCondition{}
+		w.conditions[key] = c
+	}
+	if err == nil {
+		recovered, since := c.firing, c.since
+		*c = watchdogCondition{}
+		w.mu.Unlock()
+		if recovered {
+			w.logger.WithContext(ctx).Info("Watchdog condition recovered", zap.String("condition", key))
+			title := "监控恢复：" + describe(threshold)
+			text := "### 监控恢复\n\n" +
+				fmt.Sprintf("- **问题**: %s\n", describe(threshold)) +
+				fmt.Sprintf("- **持续时间**: %s\n", now.Sub(since).Truncate(time.Second)) +
+				fmt.Sprintf("- **恢复时间**: %s", now.Format("2006-01-02 15:04:05"))
+			w.send(ctx, title, text)
+		}
+		return
+	}
+	if c.failures == 0 {
+		c.since = now
+	}
+	c.failures++
+	fire := !c.firing && c.failures >= threshold
+	if fire {
+		c.firing = true
+	}
+	failures, since := c.failures, c.since
+	w.mu.Unlock()
+
+	if !fire {
+		return
+	}
+	w.logger.WithContext(ctx).Error("Watchdog condition firing", zap.String("condition", key), zap.Int("failures", failures), zap.Error(err))
+	title := "监控异常：" + describe(failures)
+	text := "### 监控异常\n\n" +
+		fmt.Sprintf("- **问题**: %s\n", describe(failures)) +
+		fmt.Sprintf("- **开始时间**: %s\n", since.Format("2006-01-02 15:04:05")) +
+		fmt.Sprintf("- **最近错误**: %s", err)
+	w.send(ctx, title, text)
+}
+
+// send sends an operational message. It does not go through the AlertService, so that it reaches
+// the ops channel even when alert delivery is what is broken.
+func (w *WatchdogService) send(ctx context.Context, title, text string) {
+	if w.notifier == nil {
+		return
+	}
+	if err := w.notifier.SendMarkdownMessage(ctx, title, text); err != nil {
+		w.logger.WithContext(ctx).Error("Failed to send watchdog message", zap.String("title", title), zap.Error(err))
+	}
+}
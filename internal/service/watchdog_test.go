@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"klineio/internal/repository"
+	"klineio/pkg/event"
+	"klineio/pkg/exchange"
+	"klineio/pkg/log"
+	"klineio/pkg/notifier"
+
+	"go.uber.org/zap"
+)
+
+// latestPriceRepo serves GetLatestTimestamp; the watchdog uses nothing else.
+type latestPriceRepo struct {
+	repository.ExchangePriceRepository
+	latest int64
+	err    error
+}
+
+func (r *latestPriceRepo) GetLatestTimestamp(ctx context.Context) (int64, error) {
+	return r.latest, r.err
+}
+
+func TestWatchdogService(t *testing.T) {
+	logger := &log.Logger{Logger: zap.NewNop()}
+	ops := &countingNotifier{}
+	prices := &latestPriceRepo{}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	w := &WatchdogService{
+		notifier:         ops,
+		priceRepo:        prices,
+		logger:           logger,
+		exchangeFailures: 3,
+		staleAfter:       30 * time.Minute,
+		notifierFailures: 2,
+		started:          now,
+		now:              func() time.Time { return now },
+		conditions:       make(map[string]*watchdogCondition),
+	}
+	ctx := context.Background()
+	geoBlocked := errors.New("451 Unavailable For Legal Reasons")
+
+	// An exchange alerts on its third failed run in a row, once, and recovers on the next good run
+	for i := 0; i < 2; i++ {
+		w.ExchangeRun(ctx, "BINANCE", geoBlocked)
+	}
+	w.ExchangeRun(ctx, "OKEX", nil)
+	if ops.sent != 0 {
+		t.Fatalf("expected no alert before the threshold, got %d", ops.sent)
+	}
+	w.ExchangeRun(ctx, "BINANCE", geoBlocked)
+	w.ExchangeRun(ctx, "BINANCE", geoBlocked)
+	if ops.sent != 1 {
+		t.Errorf("expected a single alert, got %d", ops.sent)
+	}
+	w.ExchangeRun(ctx, "BINANCE", nil)
+	w.ExchangeRun(ctx, "BINANCE", nil)
+	if ops.sent != 2 {
+		t.Errorf("expected a single recovery message, got %d messages", ops.sent)
+	}
+
+	// Prices count as stored at start, go stale after 30 minutes, and a failing query is stale too
+	ops.sent = 0
+	w.CheckPrices(ctx)
+	now = now.Add(31 * time.Minute)
+	prices.latest = now.Add(-10 * time.Minute).UnixMilli()
+	w.CheckPrices(ctx)
+	if ops.sent != 0 {
+		t.Errorf("expected fresh prices, got %d messages", ops.sent)
+	}
+	prices.err = errors.New("database is closed")
+	w.CheckPrices(ctx)
+	if ops.sent != 1 {
+		t.Errorf("expected an alert when the latest price cannot be queried, got %d messages", ops.sent)
+	}
+	prices.err = nil
+	now = now.Add(30 * time.Minute)
+	w.CheckPrices(ctx)
+	if ops.sent != 1 {
+		t.Errorf("expected stale prices not to repeat the alert, got %d messages", ops.sent)
+	}
+	prices.latest = now.UnixMilli()
+	w.CheckPrices(ctx)
+	if ops.sent != 2 {
+		t.Errorf("expected a recovery message, got %d messages", ops.sent)
+	}
+
+	// Failing deliveries alert on the ops channel, not through the failing notifier
+	ops.sent = 0
+	failing := &countingNotifier{err: errors.New("webhook down")}
+	alerts := &AlertService{notifiers: []notifier.Notifier{failing}, logger: logger, watchdog: w}
+	alert := event.Alert{Kind: event.KindPriceDrop, Exchange: "BINANCE", Symbol: "BTCUSDT"}
+	for i := 0; i < 2; i++ {
+		alerts.Deliver(ctx, alert)
+	}
+	if ops.sent != 1 || failing.sent != 2 {
+		t.Errorf("expected one ops alert after two failed deliveries, got %d and %d", ops.sent, failing.sent)
+	}
+
+	// A nil watchdog, as in services built without one, ignores everything
+	var none *WatchdogService
+	none.ExchangeRun(ctx, "BINANCE", geoBlocked)
+	none.CheckPrices(ctx)
+	none.Delivery(ctx, geoBlocked)
+}
+
+func TestRunMonitorReportsStoppedRun(t *testing.T) {
+	logger := &log.Logger{Logger: zap.NewNop()}
+	ops := &countingNotifier{}
+	w := &WatchdogService{
+		notifier:         ops,
+		priceRepo:        &latestPriceRepo{err: errors.New("database is closed")},
+		logger:           logger,
+		exchangeFailures: 1,
+		staleAfter:       30 * time.Minute,
+		started:          time.Now(),
+		now:              time.Now,
+		conditions:       make(map[string]*watchdogCondition),
+	}
+	u, _ := NewUniverse(UniverseConfig{})
+	s := &PriceMonitorService{
+		exchangeClients: map[string]exchange.ExchangeClient{
+			"BINANCE": &listingClient{tickers: []exchange.Ticker{{Symbol: "BTCUSDT", QuoteVolume: 1e9}}},
+		},
+		universe: &UniverseService{universe: u, logger: logger, seasoned: make(map[string]bool), young: make(map[string]time.Time)},
+		watchdog: w,
+		logger:   logger,
+	}
+
+	// A run that times out before storing any price still counts as a failed exchange run. Stale prices
+	// are left to the watchdog job, which runs whether or not the monitor does.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.RunMonitor(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the run to stop with context.Canceled, got %v", err)
+	}
+	if !w.conditions["exchange:BINANCE"].firing || w.conditions[watchdogPrices] != nil || ops.sent != 1 {
+		t.Errorf("expected only the exchange to alert, got %d messages", ops.sent)
+	}
+}
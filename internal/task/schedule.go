@@ -0,0 +1,149 @@
+package task
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Schedule is when and how a job runs. Each job is configured under tasks.<name>; unset keys keep
+// the job's defaults.
+type Schedule struct {
+	Enabled      bool
+	Cron         string        // Standard 5-field cron expression; takes precedence over Interval
+	Interval     time.Duration // Fixed interval between runs
+	Timeout      time.Duration // Deadline of the context passed to the job
+	Timezone     string        // IANA zone the cron expression is evaluated in
+	RunOnStartup bool          // Run once as soon as the scheduler starts
+	Singleton    bool          // Skip a run while the previous one is in progress, here or on another instance
+}
+
+// LoadSchedule reads tasks.<name> over def. tasks.timezone sets the default time zone of every job.
+func LoadSchedule(conf *viper.Viper, name string, def Schedule) (Schedule, error) {
+	s := def
+	key := "tasks." + name + "."
+	if conf.IsSet(key + "enabled") {
+		s.Enabled = conf.GetBool(key + "enabled")
+	}
+	switch {
+	case conf.GetString(key+"cron") != "":
+		s.Cron = conf.GetString(key + "cron")
+	case conf.IsSet(key + "interval"):
+		s.Cron = ""
+		s.Interval = conf.GetDuration(key + "interval")
+	}
+	if conf.IsSet(key + "timeout") {
+		s.Timeout = conf.GetDuration(key + "timeout")
+	}
+	if conf.IsSet(key + "run_on_startup") {
+		s.RunOnStartup = conf.GetBool(key + "run_on_startup")
+	}
+	if conf.IsSet(key + "singleton") {
+		s.Singleton = conf.GetBool(key + "singleton")
+	}
+	s.Timezone = conf.GetString(key + "timezone")
+	if s.Timezone == "" {
+		s.Timezone = conf.GetString("tasks.timezone")
+	}
+	if s.Timezone == "" {
+		s.Timezone = "UTC"
+	}
+
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return s, fmt.Errorf("tasks.%s: invalid timezone: %w", name, err)
+	}
+	if s.Cron == "" && s.Interval <= 0 {
+		return s, fmt.Errorf("tasks.%s: a cron expression or a positive interval is required", name)
+	}
+	if s.Timeout <= 0 {
+		return s, fmt.Errorf("tasks.%s: timeout must be positive", name)
+	}
+	return s, nil
+}
+
+// CronSpec returns the cron expression pinned to the schedule's time zone.
+func (s Schedule) CronSpec() string {
+	return fmt.Sprintf("CRON_TZ=%s %s", s.Timezone, s.Cron)
+}
+
+// String describes the schedule for logs.
+func (s Schedule) String() string {
+	if s.Cron != "" {
+		return s.CronSpec()
+	}
+	return "every " + s.Interval.String()
+}
+
+// Names of the jobs run by the task server.
+const (
+	JobPriceMonitor = "price_monitor"
+	JobOrderBook    = "order_book"
+	JobTrades       = "trades"
+	JobPortfolio    = "portfolio"
+	JobPaperTrading = "paper_trading"
+	JobDailyDigest  = "daily_digest"
+	JobBackfill     = "backfill"
+	JobRetention    = "retention"
+	JobWatchdog     = "watchdog"
+)
+
+// Definition is a job and its schedule before tasks.<name> is applied.
+type Definition struct {
+	Name     string
+	Defaults Schedule
+}
+
+// Definitions returns every job the task server runs. The defaults keep the settings that predate the tasks
+// section, e.g. order_book.enabled and order_book.interval_minutes, so existing configs behave as before.
+func Definitions(conf *viper.Viper) []Definition {
+	timeout := time.Duration(conf.GetInt("price_monitor.timeout_seconds")) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	every := func(key string, def int, unit time.Duration) time.Duration {
+		if v := conf.GetInt(key); v > 0 {
+			return time.Duration(v) * unit
+		}
+		return time.Duration(def) * unit
+	}
+
+	return []Definition{
+		{JobPriceMonitor, Schedule{Enabled: true, Interval: 5 * time.Minute, Timeout: timeout, RunOnStartup: true, Singleton: true}},
+		// Depth snapshots are heavier than price checks, so they run on their own schedule
+		{JobOrderBook, Schedule{
+			Enabled: conf.GetBool("order_book.enabled"), Interval: every("order_book.interval_minutes", 5, time.Minute), Timeout: timeout, RunOnStartup: true, Singleton: true,
+		}},
+		// Poll trades more often than the price monitor so that fetch_limit covers the gap between runs
+		{JobTrades, Schedule{
+			Enabled: conf.GetBool("trades.enabled"), Interval: every("trades.interval_seconds", 60, time.Second), Timeout: timeout, RunOnStartup: true, Singleton: true,
+		}},
+		// Each valuation overwrites today's snapshot, so the last run of a day is the one kept
+		{JobPortfolio, Schedule{
+			Enabled: conf.GetBool("portfolio.enabled"), Interval: every("portfolio.interval_minutes", 60, time.Minute), Timeout: timeout, RunOnStartup: true, Singleton: true,
+		}},
+		// Mark paper positions to market; entries happen on the price monitor's alerts
+		{JobPaperTrading, Schedule{
+			Enabled: conf.GetBool("paper_trading.enabled"), Interval: every("paper_trading.interval_minutes", 5, time.Minute), Timeout: timeout, RunOnStartup: true, Singleton: true,
+		}},
+		{JobDailyDigest, Schedule{Cron: "0 9 * * *", Timeout: timeout, Singleton: true}},
+		// Fill the gap left by downtime as soon as the scheduler is back
+		{JobBackfill, Schedule{
+			Enabled: conf.GetBool("kline_store.enabled"), Interval: time.Hour, Timeout: 30 * time.Minute, RunOnStartup: true, Singleton: true,
+		}},
+		{JobRetention, Schedule{Cron: "0 3 * * *", Timeout: 30 * time.Minute, Singleton: true}},
+		// Checks for stale prices apart from the price monitor, which is what stops storing them when it hangs or is paused
+		{JobWatchdog, Schedule{Enabled: true, Interval: 5 * time.Minute, Timeout: time.Minute, Singleton: true}},
+	}
+}
+
+// Lookup returns the configured schedule of the job called name; ok is false if there is no such job.
+func Lookup(conf *viper.Viper, name string) (s Schedule, ok bool, err error) {
+	for _, def := range Definitions(conf) {
+		if def.Name == name {
+			s, err = LoadSchedule(conf, name, def.Defaults)
+			return s, true, err
+		}
+	}
+	return Schedule{}, false, nil
+}
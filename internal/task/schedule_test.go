@@ -0,0 +1,82 @@
+package task
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func loadConf(t *testing.T, yml string) *viper.Viper {
+	conf := viper.New()
+	conf.SetConfigType("yml")
+	if err := conf.ReadConfig(strings.NewReader(yml)); err != nil {
+		t.Fatal(err)
+	}
+	return conf
+}
+
+func TestLoadSchedule(t *testing.T) {
+	conf := loadConf(t, `
+tasks:
+  timezone: Asia/Shanghai
+  price_monitor:
+    interval: 1m
+    run_on_startup: false
+    singleton: false
+  daily_digest:
+    enabled: true
+    cron: "30 8 * * *"
+    timezone: Europe/London
+  retention:
+    interval: 6h
+`)
+	def := Schedule{Enabled: true, Interval: 5 * time.Minute, Timeout: time.Minute, RunOnStartup: true, Singleton: true}
+
+	s, err := LoadSchedule(conf, "price_monitor", def)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Enabled || s.Interval != time.Minute || s.RunOnStartup || s.Singleton || s.Timezone != "Asia/Shanghai" || s.Timeout != time.Minute {
+		t.Errorf("unexpected price_monitor schedule: %+v", s)
+	}
+
+	s, err = LoadSchedule(conf, "daily_digest", Schedule{Cron: "0 9 * * *", Timeout: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Enabled || s.CronSpec() != "CRON_TZ=Europe/London 30 8 * * *" {
+		t.Errorf("unexpected daily_digest schedule: %+v", s)
+	}
+
+	// An interval in the config replaces a default cron expression.
+	s, err = LoadSchedule(conf, "retention", Schedule{Cron: "0 3 * * *", Timeout: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Cron != "" || s.Interval != 6*time.Hour || s.String() != "every 6h0m0s" {
+		t.Errorf("unexpected retention schedule: %+v", s)
+	}
+
+	// Unconfigured jobs keep their defaults.
+	if s, err := LoadSchedule(conf, "order_book", def); err != nil || s.Interval != def.Interval || !s.RunOnStartup || !s.Singleton {
+		t.Errorf("unexpected order_book schedule: %+v, %v", s, err)
+	}
+}
+
+func TestLoadScheduleInvalid(t *testing.T) {
+	conf := loadConf(t, `
+tasks:
+  bad_zone:
+    interval: 1m
+    timezone: Mars/Olympus
+  no_interval:
+    interval: 0s
+`)
+	for _, name := range []string{"bad_zone", "no_interval"} {
+		if _, err := LoadSchedule(conf, name, Schedule{Interval: time.Minute, Timeout: time.Minute}); err == nil {
+			t.Errorf("expected an error for %s", name)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Package broker publishes and consumes messages through a pluggable message queue.
+package broker
+
+import (
+	"context"
+	"fmt"
+
+	"klineio/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// Message is a message received from a topic.
+type Message struct {
+	Topic string
+	Key   string
+	Value []byte
+}
+
+// Handler processes a message. It handles its own errors; a message is not redelivered.
+type Handler func(ctx context.Context, msg Message)
+
+// Broker is a message queue.
+type Broker interface {
+	// Publish sends value to topic. Messages with the same key keep their order where the broker supports it.
+	Publish(ctx context.Context, topic, key string, value []byte) error
+	// Subscribe passes the messages of topic to handler until ctx is done. Subscribers in the same group
+	// share the messages between them, while every group receives each message.
+	Subscribe(ctx context.Context, topic, group string, handler Handler) error
+	Close() error
+}
+
+// Drivers selected by broker.driver.
+const (
+	DriverNone   = "none"
+	DriverMemory = "memory"
+	DriverKafka  = "kafka"
+	DriverNATS   = "nats"
+)
+
+// NewBroker connects to the broker configured by broker.driver. The none driver, the default, drops
+// every message and its subscriptions wait for their context to end. Topics are prefixed with
+// broker.topic_prefix, "klineio." by default.
+func NewBroker(conf *viper.Viper, logger *log.Logger) (Broker, func(), error) {
+	prefix := conf.GetString("broker.topic_prefix")
+	if !conf.IsSet("broker.topic_prefix") {
+		prefix = "klineio."
+	}
+
+	var b Broker
+	switch driver := conf.GetString("broker.driver"); driver {
+	case "", DriverNone:
+		return Nop{}, func() {}, nil
+	case DriverMemory:
+		b = NewMemory()
+	case DriverKafka:
+		brokers := conf.GetStringSlice("broker.kafka.brokers")
+		if len(brokers) == 0 {
+			return nil, nil, fmt.Errorf("broker.kafka.brokers is required for the kafka driver")
+		}
+		b = NewKafka(brokers, logger)
+	case DriverNATS:
+		nb, err := NewNATS(conf.GetString("broker.nats.url"), logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		b = nb
+	default:
+		return nil, nil, fmt.Errorf("unknown broker driver %q", driver)
+	}
+	logger.Info("Using message broker", zap.String("driver", conf.GetString("broker.driver")), zap.String("topicPrefix", prefix))
+
+	b = &prefixed{Broker: b, prefix: prefix}
+	return b, func() {
+		if err := b.Close(); err != nil {
+			logger.Warn("Failed to close message broker", zap.Error(err))
+		}
+	}, nil
+}
+
+// prefixed adds a prefix to the topics of a Broker.
+type prefixed struct {
+	Broker
+	prefix string
+}
+
+func (p *prefixed) Publish(ctx context.Context, topic, key string, value []byte) error {
+	return p.Broker.Publish(ctx, p.prefix+topic, key, value)
+}
+
+func (p *prefixed) Subscribe(ctx context.Context, topic, group string, handler Handler) error {
+	return p.Broker.Subscribe(ctx, p.prefix+topic, group, handler)
+}
+
+// Nop drops every message.
+type Nop struct{}
+
+func (Nop) Publish(ctx context.Context, topic, key string, value []byte) error { return nil }
+
+func (Nop) Subscribe(ctx context.Context, topic, group string, handler Handler) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (Nop) Close() error { return nil }
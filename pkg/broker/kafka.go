@@ -0,0 +1,65 @@
+package broker
+
+import (
+	"context"
+	"time"
+
+	"klineio/pkg/log"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// Kafka publishes to and consumes from Kafka topics, which are created on first use if the cluster allows it.
+// Messages are committed after the handler returns, so a consumer that crashes mid-message sees it again.
+type Kafka struct {
+	brokers []string
+	writer  *kafka.Writer
+	logger  *log.Logger
+}
+
+// NewKafka creates a Kafka broker for the given bootstrap servers. Connections are made on first use.
+func NewKafka(brokers []string, logger *log.Logger) *Kafka {
+	return &Kafka{
+		brokers: brokers,
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Balancer:               &kafka.Hash{},
+			BatchTimeout:           10 * time.Millisecond,
+			RequiredAcks:           kafka.RequireOne,
+			AllowAutoTopicCreation: true,
+		},
+		logger: logger,
+	}
+}
+
+func (k *Kafka) Publish(ctx context.Context, topic, key string, value []byte) error {
+	return k.writer.WriteMessages(ctx, kafka.Message{Topic: topic, Key: []byte(key), Value: value})
+}
+
+func (k *Kafka) Subscribe(ctx context.Context, topic, group string, handler Handler) error {
+	r := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: k.brokers,
+		GroupID: group,
+		Topic:   topic,
+	})
+	defer r.Close()
+
+	for {
+		m, err := r.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		handler(ctx, Message{Topic: m.Topic, Key: string(m.Key), Value: m.Value})
+		if err := r.CommitMessages(ctx, m); err != nil && ctx.Err() == nil {
+			k.logger.Warn("Failed to commit Kafka message", zap.Error(err), zap.String("topic", m.Topic), zap.Int64("offset", m.Offset))
+		}
+	}
+}
+
+func (k *Kafka) Close() error {
+	return k.writer.Close()
+}
@@ -0,0 +1,64 @@
+package broker
+
+import (
+	"context"
+	"sync"
+)
+
+// memoryBuffer is the number of messages a group can fall behind before Publish blocks.
+const memoryBuffer = 256
+
+// Memory passes messages between the publishers and subscribers of one process. Messages published
+// to a topic without subscribers are dropped.
+type Memory struct {
+	mu     sync.Mutex
+	groups map[string]map[string]chan Message // Topic to group to the group's queue
+}
+
+// NewMemory creates an empty Memory broker.
+func NewMemory() *Memory {
+	return &Memory{groups: make(map[string]map[string]chan Message)}
+}
+
+func (m *Memory) Publish(ctx context.Context, topic, key string, value []byte) error {
+	m.mu.Lock()
+	queues := make([]chan Message, 0, len(m.groups[topic]))
+	for _, q := range m.groups[topic] {
+		queues = append(queues, q)
+	}
+	m.mu.Unlock()
+
+	msg := Message{Topic: topic, Key: key, Value: value}
+	for _, q := range queues {
+		select {
+		case q <- msg:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (m *Memory) Subscribe(ctx context.Context, topic, group string, handler Handler) error {
+	m.mu.Lock()
+	if m.groups[topic] == nil {
+		m.groups[topic] = make(map[string]chan Message)
+	}
+	q, ok := m.groups[topic][group]
+	if !ok {
+		q = make(chan Message, memoryBuffer)
+		m.groups[topic][group] = q
+	}
+	m.mu.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg := <-q:
+			handler(ctx, msg)
+		}
+	}
+}
+
+func (m *Memory) Close() error { return nil }
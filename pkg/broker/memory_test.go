@@ -0,0 +1,59 @@
+package broker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemory(t *testing.T) {
+	m := NewMemory()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	received := make(map[string]int)
+	var wg sync.WaitGroup
+	subscribe := func(name, group string) {
+		go m.Subscribe(ctx, "alerts", group, func(ctx context.Context, msg Message) {
+			mu.Lock()
+			received[name]++
+			mu.Unlock()
+			wg.Done()
+		})
+	}
+	// Two workers share the notifier group; the audit group gets its own copy of every message.
+	subscribe("worker-a", "notifier")
+	subscribe("worker-b", "notifier")
+	subscribe("audit", "audit")
+	for {
+		m.mu.Lock()
+		n := len(m.groups["alerts"])
+		m.mu.Unlock()
+		if n == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	wg.Add(20)
+	for i := 0; i < 10; i++ {
+		if err := m.Publish(ctx, "alerts", "BINANCE:BTCUSDT", []byte("alert")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Publish(ctx, "prices", "", []byte("dropped")); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if received["audit"] != 10 {
+		t.Errorf("expected the audit group to receive every message, got %d", received["audit"])
+	}
+	if got := received["worker-a"] + received["worker-b"]; got != 10 {
+		t.Errorf("expected the notifier group to receive each message once, got %d", got)
+	}
+}
@@ -0,0 +1,73 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+
+	"klineio/pkg/log"
+
+	"github.com/nats-io/nats.go"
+	"go.uber.org/zap"
+)
+
+// keyHeader carries the message key, which core NATS has no notion of.
+const keyHeader = "Klineio-Key"
+
+// NATS publishes to NATS subjects named after the topics and consumes them through queue groups.
+// Core NATS delivers at most once: messages published while no subscriber is connected are lost.
+type NATS struct {
+	conn   *nats.Conn
+	logger *log.Logger
+}
+
+// NewNATS connects to the NATS server at url, nats://127.0.0.1:4222 by default, reconnecting as needed.
+func NewNATS(url string, logger *log.Logger) (*NATS, error) {
+	if url == "" {
+		url = nats.DefaultURL
+	}
+	conn, err := nats.Connect(url,
+		nats.Name("klineio"),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			logger.Warn("Disconnected from NATS", zap.Error(err))
+		}),
+		nats.ReconnectHandler(func(c *nats.Conn) {
+			logger.Info("Reconnected to NATS", zap.String("url", c.ConnectedUrl()))
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	return &NATS{conn: conn, logger: logger}, nil
+}
+
+func (n *NATS) Publish(ctx context.Context, topic, key string, value []byte) error {
+	msg := nats.NewMsg(topic)
+	msg.Data = value
+	if key != "" {
+		msg.Header.Set(keyHeader, key)
+	}
+	return n.conn.PublishMsg(msg)
+}
+
+func (n *NATS) Subscribe(ctx context.Context, topic, group string, handler Handler) error {
+	ch := make(chan *nats.Msg, 256)
+	sub, err := n.conn.ChanQueueSubscribe(topic, group, ch)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case m := <-ch:
+			handler(ctx, Message{Topic: m.Subject, Key: m.Header.Get(keyHeader), Value: m.Data})
+		}
+	}
+}
+
+func (n *NATS) Close() error {
+	return n.conn.Drain()
+}
@@ -0,0 +1,137 @@
+// Package event defines the JSON events klineio publishes to the message broker. Other services can
+// import it to decode them.
+//
+// Every message is an Envelope whose Data holds the payload of its Type. Version is bumped whenever a
+// payload changes incompatibly; adding fields does not change it, so consumers must ignore unknown fields.
+package event
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Topics events are published to, before the broker's topic prefix.
+const (
+	TopicAlerts = "alerts"
+	TopicPrices = "prices"
+)
+
+// Event types and the payload versions this package produces.
+const (
+	TypeAlert         = "alert"
+	TypePriceSnapshot = "price_snapshot"
+
+	AlertVersion         = 1
+	PriceSnapshotVersion = 1
+)
+
+// Source is set on every event klineio publishes.
+const Source = "klineio"
+
+// Alert kinds.
+const (
+	KindPriceDrop         = "price_drop"
+	KindRule              = "rule" // Rule holds the rule name
+	KindSpread            = "spread"
+	KindAnomaly           = "anomaly"
+	KindLiquidity         = "liquidity"
+	KindOrderBookWall     = "order_book_wall"
+	KindLargeTrade        = "large_trade"
+	KindTradeBurst        = "trade_burst"
+	KindPortfolioDrawdown = "portfolio_drawdown"
+	KindBelowCostBasis    = "below_cost_basis"
+	KindDigest            = "digest"
+)
+
+// Envelope wraps every published event.
+type Envelope struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Version int             `json:"version"`
+	Source  string          `json:"source"`
+	Time    time.Time       `json:"time"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Alert is a notification raised by one of the monitors.
+type Alert struct {
+	Kind      string  `json:"kind"`
+	Rule      string  `json:"rule,omitempty"`
+	Exchange  string  `json:"exchange,omitempty"` // Empty for alerts not tied to one exchange, such as spreads and portfolios
+	UserID    string  `json:"user_id,omitempty"`  // Owner of the holdings a portfolio alert is about; empty for market alerts
+	Symbol    string  `json:"symbol,omitempty"`
+	Value     float64 `json:"value,omitempty"`     // The measure that crossed its threshold, e.g. the drop in percent
+	Threshold float64 `json:"threshold,omitempty"` // The threshold in the same unit as Value
+	Title     string  `json:"title"`
+	Text      string  `json:"text"` // DingTalk markdown
+}
+
+// PriceSnapshot is the latest price of a symbol stored by the price monitor.
+type PriceSnapshot struct {
+	Exchange    string    `json:"exchange"`
+	Symbol      string    `json:"symbol"`
+	Price       float64   `json:"price"`
+	QuoteVolume float64   `json:"quote_volume"` // 24h quote volume
+	Time        time.Time `json:"time"`
+}
+
+// New wraps data into an Envelope of the given type and version.
+func New(typ string, version int, data interface{}) (*Envelope, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s event: %w", typ, err)
+	}
+	return &Envelope{ID: newID(), Type: typ, Version: version, Source: Source, Time: time.Now().UTC(), Data: raw}, nil
+}
+
+// NewAlert wraps a into an alert event.
+func NewAlert(a Alert) (*Envelope, error) {
+	return New(TypeAlert, AlertVersion, a)
+}
+
+// NewPriceSnapshot wraps p into a price snapshot event.
+func NewPriceSnapshot(p PriceSnapshot) (*Envelope, error) {
+	return New(TypePriceSnapshot, PriceSnapshotVersion, p)
+}
+
+// Decode parses an encoded Envelope.
+func Decode(b []byte) (*Envelope, error) {
+	var e Envelope
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+	return &e, nil
+}
+
+// Alert returns the payload of an alert event, or an error for other types and unsupported versions.
+func (e *Envelope) Alert() (*Alert, error) {
+	if e.Type != TypeAlert || e.Version != AlertVersion {
+		return nil, fmt.Errorf("unsupported event %s v%d, expected %s v%d", e.Type, e.Version, TypeAlert, AlertVersion)
+	}
+	var a Alert
+	if err := json.Unmarshal(e.Data, &a); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal alert: %w", err)
+	}
+	return &a, nil
+}
+
+// PriceSnapshot returns the payload of a price snapshot event, or an error for other types and unsupported versions.
+func (e *Envelope) PriceSnapshot() (*PriceSnapshot, error) {
+	if e.Type != TypePriceSnapshot || e.Version != PriceSnapshotVersion {
+		return nil, fmt.Errorf("unsupported event %s v%d, expected %s v%d", e.Type, e.Version, TypePriceSnapshot, PriceSnapshotVersion)
+	}
+	var p PriceSnapshot
+	if err := json.Unmarshal(e.Data, &p); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal price snapshot: %w", err)
+	}
+	return &p, nil
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,39 @@
+package event
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAlertRoundTrip(t *testing.T) {
+	e, err := NewAlert(Alert{Kind: KindPriceDrop, Exchange: "BINANCE", Symbol: "BTCUSDT", Value: 8.5, Threshold: 8, Title: "价格下跌警报！"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.ID == "" || decoded.Source != Source || decoded.Type != TypeAlert || decoded.Version != AlertVersion {
+		t.Errorf("unexpected envelope: %+v", decoded)
+	}
+	a, err := decoded.Alert()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Kind != KindPriceDrop || a.Symbol != "BTCUSDT" || a.Value != 8.5 {
+		t.Errorf("unexpected alert: %+v", a)
+	}
+	if _, err := decoded.PriceSnapshot(); err == nil {
+		t.Error("expected an error decoding an alert as a price snapshot")
+	}
+
+	decoded.Version = AlertVersion + 1
+	if _, err := decoded.Alert(); err == nil {
+		t.Error("expected an error for an unsupported version")
+	}
+}
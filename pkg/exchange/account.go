@@ -0,0 +1,85 @@
+package exchange
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrMissingCredentials is returned by AccountClient methods when no API key is configured.
+var ErrMissingCredentials = errors.New("missing API credentials")
+
+// Credentials holds the API key of an exchange account. Only read permissions are needed.
+type Credentials struct {
+	APIKey     string
+	SecretKey  string
+	Passphrase string // OKEX only
+}
+
+// configured reports whether the credentials are set and are not the placeholders from the sample config.
+func (c Credentials) configured() bool {
+	placeholder := func(s string) bool { return s == "" || strings.HasPrefix(s, "YOUR_") }
+	return !placeholder(c.APIKey) && !placeholder(c.SecretKey)
+}
+
+// Balance is the holding of one asset in a spot account.
+type Balance struct {
+	Asset  string
+	Free   float64
+	Locked float64 // Reserved by open orders
+}
+
+// Total returns the free and locked quantity.
+func (b Balance) Total() float64 {
+	return b.Free + b.Locked
+}
+
+// Order is an open spot order.
+type Order struct {
+	ID        string
+	Symbol    string // Canonical symbol, e.g., BTCUSDT
+	Side      string // SideBuy or SideSell
+	Type      string // Exchange order type, e.g., LIMIT on Binance or limit on OKEX
+	Price     float64
+	Quantity  float64
+	Filled    float64
+	Status    string
+	CreatedAt time.Time
+}
+
+// Fill is an execution of one of the account's orders.
+type Fill struct {
+	ID        string
+	OrderID   string
+	Symbol    string // Canonical symbol, e.g., BTCUSDT
+	Side      string // SideBuy or SideSell
+	Price     float64
+	Quantity  float64
+	Fee       float64 // Positive when charged, negative for rebates
+	FeeAsset  string
+	IsMaker   bool
+	Timestamp time.Time
+}
+
+// AccountClient reads private account data with signed requests.
+type AccountClient interface {
+	// Configured reports whether API credentials are available.
+	Configured() bool
+	// GetBalances returns the non-zero spot balances.
+	GetBalances(ctx context.Context) ([]Balance, error)
+	// GetOpenOrders returns open spot orders, for every symbol if symbol is empty.
+	GetOpenOrders(ctx context.Context, symbol string) ([]Order, error)
+	// GetTradeHistory returns up to limit of the most recent fills for symbol, oldest first.
+	// A limit of zero or less returns as many as the exchange allows in one request.
+	GetTradeHistory(ctx context.Context, symbol string, limit int) ([]Fill, error)
+}
+
+// hmacSHA256 signs message with secret.
+func hmacSHA256(secret, message string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,146 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"klineio/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+func TestSignatures(t *testing.T) {
+	// Example from the Binance API documentation.
+	got := signBinance("NhqPtmdSJYdKjVHjA7PZj4Mge3R5YNiP1e3UZjInClVN65XAbvqqM6A7H5fATj0j",
+		"symbol=LTCBTC&side=BUY&type=LIMIT&timeInForce=GTC&quantity=1&price=0.1&recvWindow=5000&timestamp=1499827319559")
+	if got != "c8db56825ae71d6d79447849e617115f4a920fa2acdcab2b053c4b2838bd6b71" {
+		t.Errorf("signBinance = %s", got)
+	}
+	got = signOKEX("secret", "2020-12-08T09:08:57.715Z", "GET", "/api/v5/account/balance?ccy=BTC", "")
+	if got != "wpDvCwYCprcMQsQkxWJiWy+YADoQE4ep+OEKKLimMoY=" {
+		t.Errorf("signOKEX = %s", got)
+	}
+}
+
+func testAccountConf(baseURL string) *viper.Viper {
+	conf := viper.New()
+	conf.Set("exchange.binance.base_url", baseURL)
+	conf.Set("exchange.binance.api_key", "bkey")
+	conf.Set("exchange.binance.secret_key", "bsecret")
+	conf.Set("exchange.okex.base_url", baseURL)
+	conf.Set("exchange.okex.api_key", "okey")
+	conf.Set("exchange.okex.secret_key", "osecret")
+	conf.Set("exchange.okex.passphrase", "pass")
+	return conf
+}
+
+func TestBinanceAccountClient(t *testing.T) {
+	var tradeLimit string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query, sig, _ := strings.Cut(r.URL.RawQuery, "&signature=")
+		if r.Header.Get("X-MBX-APIKEY") != "bkey" || sig != signBinance("bsecret", query) || r.URL.Query().Get("timestamp") != "1700000000000" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code":-1022,"msg":"Signature for this request is not valid."}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v3/account":
+			w.Write([]byte(`{"balances":[{"asset":"BTC","free":"0.5","locked":"0.1"},{"asset":"ETH","free":"0","locked":"0"}]}`))
+		case "/api/v3/openOrders":
+			w.Write([]byte(`[{"symbol":"BTCUSDT","orderId":7,"price":"50000","origQty":"0.1","executedQty":"0.02","status":"PARTIALLY_FILLED","type":"LIMIT","side":"BUY","time":1700000000000}]`))
+		case "/api/v3/myTrades":
+			tradeLimit = r.URL.Query().Get("limit")
+			w.Write([]byte(`[{"symbol":"BTCUSDT","id":9,"orderId":7,"price":"50000","qty":"0.02","commission":"0.00002","commissionAsset":"BTC","time":1700000000000,"isBuyer":true,"isMaker":true}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewBinanceAccountClient(&log.Logger{Logger: zap.NewNop()}, testAccountConf(server.URL))
+	client.now = func() time.Time { return time.UnixMilli(1700000000000) }
+	ctx := context.Background()
+
+	balances, err := client.GetBalances(ctx)
+	if err != nil || len(balances) != 1 || balances[0].Asset != "BTC" || balances[0].Total() != 0.6 {
+		t.Errorf("GetBalances = %+v, %v", balances, err)
+	}
+	orders, err := client.GetOpenOrders(ctx, "BTC-USDT")
+	if err != nil || len(orders) != 1 || orders[0].ID != "7" || orders[0].Side != SideBuy || orders[0].Filled != 0.02 {
+		t.Errorf("GetOpenOrders = %+v, %v", orders, err)
+	}
+	fills, err := client.GetTradeHistory(ctx, "BTCUSDT", 10)
+	if err != nil || len(fills) != 1 || fills[0].OrderID != "7" || fills[0].Fee != 0.00002 || !fills[0].IsMaker {
+		t.Errorf("GetTradeHistory = %+v, %v", fills, err)
+	}
+	if _, err := client.GetTradeHistory(ctx, "BTCUSDT", 0); err != nil || tradeLimit != "1000" {
+		t.Errorf("expected a non-positive limit to request 1000 fills, got limit=%s, %v", tradeLimit, err)
+	}
+
+	client.credentials.SecretKey = "wrong"
+	if _, err := client.GetBalances(ctx); err == nil || !strings.Contains(err.Error(), "-1022") {
+		t.Errorf("expected a signature error, got %v", err)
+	}
+}
+
+func TestOKEXAccountClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ts := r.Header.Get("OK-ACCESS-TIMESTAMP")
+		if r.Header.Get("OK-ACCESS-KEY") != "okey" || r.Header.Get("OK-ACCESS-PASSPHRASE") != "pass" || ts != "2023-11-14T22:13:20.000Z" ||
+			r.Header.Get("OK-ACCESS-SIGN") != signOKEX("osecret", ts, r.Method, r.URL.RequestURI(), "") {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code":"50113","msg":"Invalid Sign","data":[]}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v5/account/balance":
+			w.Write([]byte(`{"code":"0","msg":"","data":[{"details":[{"ccy":"USDT","availBal":"100","frozenBal":"20"},{"ccy":"DOGE","availBal":"0","frozenBal":"0"}]}]}`))
+		case "/api/v5/trade/orders-pending":
+			w.Write([]byte(`{"code":"0","msg":"","data":[{"instId":"ETH-USDT","ordId":"42","px":"2000","sz":"1","accFillSz":"0","state":"live","ordType":"limit","side":"sell","cTime":"1700000000000"}]}`))
+		case "/api/v5/trade/fills-history":
+			w.Write([]byte(`{"code":"0","msg":"","data":[` +
+				`{"instId":"ETH-USDT","tradeId":"2","ordId":"42","fillPx":"2001","fillSz":"0.5","side":"sell","execType":"T","fee":"-1","feeCcy":"USDT","ts":"1700000001000"},` +
+				`{"instId":"ETH-USDT","tradeId":"1","ordId":"42","fillPx":"2000","fillSz":"0.5","side":"sell","execType":"M","fee":"0.1","feeCcy":"USDT","ts":"1700000000000"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewOKEXAccountClient(&log.Logger{Logger: zap.NewNop()}, testAccountConf(server.URL))
+	client.now = func() time.Time { return time.UnixMilli(1700000000000) }
+	ctx := context.Background()
+
+	balances, err := client.GetBalances(ctx)
+	if err != nil || len(balances) != 1 || balances[0].Asset != "USDT" || balances[0].Locked != 20 {
+		t.Errorf("GetBalances = %+v, %v", balances, err)
+	}
+	orders, err := client.GetOpenOrders(ctx, "ETHUSDT")
+	if err != nil || len(orders) != 1 || orders[0].Symbol != "ETHUSDT" || orders[0].Side != SideSell {
+		t.Errorf("GetOpenOrders = %+v, %v", orders, err)
+	}
+	fills, err := client.GetTradeHistory(ctx, "", 10)
+	if err != nil || len(fills) != 2 || fills[0].ID != "1" || fills[0].Fee != -0.1 || !fills[0].IsMaker || fills[1].Fee != 1 {
+		t.Errorf("GetTradeHistory = %+v, %v", fills, err)
+	}
+
+	client.credentials.Passphrase = ""
+	if _, err := client.GetBalances(ctx); !errors.Is(err, ErrMissingCredentials) {
+		t.Errorf("expected ErrMissingCredentials, got %v", err)
+	}
+}
+
+func TestCredentialsPlaceholders(t *testing.T) {
+	conf := viper.New()
+	conf.Set("exchange.binance.api_key", "YOUR_BINANCE_API_KEY")
+	conf.Set("exchange.binance.secret_key", "YOUR_BINANCE_SECRET_KEY")
+	if NewBinanceAccountClient(&log.Logger{Logger: zap.NewNop()}, conf).Configured() {
+		t.Error("placeholder credentials should not count as configured")
+	}
+}
@@ -6,8 +6,6 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"net/url"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -20,6 +18,22 @@ import (
 
 const binanceAPIURL = "https://api.binance.com/api/v3"
 
+// binanceMaxDepth is the largest order book limit accepted by Binance.
+const binanceMaxDepth = 5000
+
+// binanceMaxTrades is the largest aggTrades limit accepted by Binance.
+const binanceMaxTrades = 1000
+
+// binanceMaxKlines is the largest klines limit accepted by Binance.
+const binanceMaxKlines = 1000
+
+// binanceIntervals lists the kline intervals accepted by Binance.
+var binanceIntervals = map[Interval]bool{
+	Interval1s: true, Interval1m: true, Interval3m: true, Interval5m: true, Interval15m: true, Interval30m: true,
+	Interval1h: true, Interval2h: true, Interval4h: true, Interval6h: true, Interval8h: true, Interval12h: true,
+	Interval1d: true, Interval3d: true, Interval1w: true, Interval1M: true,
+}
+
 // BinanceClient implements the ExchangeClient interface for Binance.
 type BinanceClient struct {
 	client *http.Client
@@ -28,26 +42,17 @@ type BinanceClient struct {
 
 // NewBinanceClient creates a new BinanceClient.
 func NewBinanceClient(logger *log.Logger, conf *viper.Viper) *BinanceClient {
-	transport := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-	}
-
-	proxyURL := conf.GetString("proxy.http")
-	if proxyURL != "" {
-		parsedProxyURL, err := url.Parse(proxyURL)
-		if err != nil {
-			logger.Warn("Failed to parse HTTP proxy URL", zap.Error(err), zap.String("proxy_url", proxyURL))
-		} else {
-			transport.Proxy = http.ProxyURL(parsedProxyURL)
-		}
-	}
-
 	return &BinanceClient{
-		client: &http.Client{Timeout: 60 * time.Second, Transport: transport}, // Increased timeout to 60 seconds
+		client: newHTTPClient("BINANCE", logger, conf),
 		logger: logger,
 	}
 }
 
+// Ping checks that the Binance API is reachable.
+func (b *BinanceClient) Ping(ctx context.Context) error {
+	return ping(ctx, b.client, binanceAPIURL+"/ping")
+}
+
 // GetLatestPrice fetches the latest price for a given symbol from Binance.
 func (b *BinanceClient) GetLatestPrice(ctx context.Context, symbol string) (float64, error) {
 	url := fmt.Sprintf("%s/ticker/price?symbol=%s", binanceAPIURL, symbol)
@@ -87,9 +92,24 @@ func (b *BinanceClient) GetLatestPrice(ctx context.Context, symbol string) (floa
 	return price, nil
 }
 
-// GetKlines fetches K-line data for a given symbol, interval, and limit from Binance.
-func (b *BinanceClient) GetKlines(ctx context.Context, symbol string, interval string, limit int) ([]Kline, error) {
-	url := fmt.Sprintf("%s/klines?symbol=%s&interval=%s&limit=%d", binanceAPIURL, symbol, interval, limit)
+// SupportsInterval reports whether Binance offers interval natively.
+func (b *BinanceClient) SupportsInterval(interval Interval) bool {
+	return binanceIntervals[interval]
+}
+
+// MaxKlines returns the most candles Binance returns per request.
+func (b *BinanceClient) MaxKlines() int {
+	return binanceMaxKlines
+}
+
+// GetKlines fetches K-line data for a given symbol, interval, and limit from Binance. A limit above
+// MaxKlines is clamped to it.
+func (b *BinanceClient) GetKlines(ctx context.Context, symbol string, interval Interval, limit int) ([]Kline, error) {
+	if !b.SupportsInterval(interval) {
+		return nil, unsupportedInterval("binance", interval)
+	}
+
+	url := fmt.Sprintf("%s/klines?symbol=%s&interval=%s&limit=%d", binanceAPIURL, symbol, interval, min(limit, binanceMaxKlines))
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -145,7 +165,7 @@ func (b *BinanceClient) GetKlines(ctx context.Context, symbol string, interval s
 	return klines, nil
 }
 
-// GetTopVolumeTickers fetches ticker information, sorts by volume, and returns top N.
+// GetTopVolumeTickers fetches ticker information, sorts by quote volume, and returns top N.
 func (b *BinanceClient) GetTopVolumeTickers(ctx context.Context, limit int) ([]Ticker, error) {
 	url := fmt.Sprintf("%s/ticker/24hr", binanceAPIURL)
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -169,9 +189,17 @@ func (b *BinanceClient) GetTopVolumeTickers(ctx context.Context, limit int) ([]T
 	}
 
 	var rawTickers []struct {
-		Symbol    string `json:"symbol"`
-		LastPrice string `json:"lastPrice"`
-		Volume    string `json:"volume"` // Base asset volume
+		Symbol             string `json:"symbol"`
+		LastPrice          string `json:"lastPrice"`
+		OpenPrice          string `json:"openPrice"`
+		HighPrice          string `json:"highPrice"`
+		LowPrice           string `json:"lowPrice"`
+		PriceChangePercent string `json:"priceChangePercent"`
+		Volume             string `json:"volume"`      // Base asset volume
+		QuoteVolume        string `json:"quoteVolume"` // Quote asset volume
+		BidPrice           string `json:"bidPrice"`
+		AskPrice           string `json:"askPrice"`
+		CloseTime          int64  `json:"closeTime"`
 	}
 	if err := json.Unmarshal(body, &rawTickers); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal tickers response: %w", err)
@@ -179,37 +207,143 @@ func (b *BinanceClient) GetTopVolumeTickers(ctx context.Context, limit int) ([]T
 
 	var tickers []Ticker
 	for _, raw := range rawTickers {
+		// Filter for USDT pairs (or whatever base currency is desired)
+		if !strings.HasSuffix(raw.Symbol, "USDT") {
+			continue
+		}
+
 		price, err := strconv.ParseFloat(raw.LastPrice, 64)
 		if err != nil {
 			b.logger.Warn("Failed to parse Binance ticker price", zap.Error(err), zap.String("symbol", raw.Symbol), zap.String("price", raw.LastPrice))
 			continue
 		}
-		volume, err := strconv.ParseFloat(raw.Volume, 64)
+		quoteVolume, err := strconv.ParseFloat(raw.QuoteVolume, 64)
 		if err != nil {
-			b.logger.Warn("Failed to parse Binance ticker volume", zap.Error(err), zap.String("symbol", raw.Symbol), zap.String("volume", raw.Volume))
-			continue
-		}
-
-		// Filter for USDT pairs (or whatever base currency is desired)
-		if !strings.HasSuffix(raw.Symbol, "USDT") {
+			b.logger.Warn("Failed to parse Binance ticker quote volume", zap.Error(err), zap.String("symbol", raw.Symbol), zap.String("quoteVolume", raw.QuoteVolume))
 			continue
 		}
 
 		tickers = append(tickers, Ticker{
-			Symbol: raw.Symbol,
-			Price:  price,
-			Volume: volume,
+			Symbol:        raw.Symbol,
+			Price:         price,
+			Open:          parseOptionalFloat(raw.OpenPrice),
+			High:          parseOptionalFloat(raw.HighPrice),
+			Low:           parseOptionalFloat(raw.LowPrice),
+			ChangePercent: parseOptionalFloat(raw.PriceChangePercent),
+			BaseVolume:    parseOptionalFloat(raw.Volume),
+			QuoteVolume:   quoteVolume,
+			Bid:           parseOptionalFloat(raw.BidPrice),
+			Ask:           parseOptionalFloat(raw.AskPrice),
+			Timestamp:     time.UnixMilli(raw.CloseTime),
 		})
 	}
 
-	// Sort by volume in descending order
-	sort.Slice(tickers, func(i, j int) bool {
-		return tickers[i].Volume > tickers[j].Volume
-	})
+	sortByQuoteVolume(tickers)
 
 	// Return top N
-	if len(tickers) > limit {
+	if limit > 0 && len(tickers) > limit {
 		return tickers[:limit], nil
 	}
 	return tickers, nil
 }
+
+// GetOrderBook fetches the order book for a given symbol from Binance.
+func (b *BinanceClient) GetOrderBook(ctx context.Context, symbol string, depth int) (*OrderBook, error) {
+	url := fmt.Sprintf("%s/depth?symbol=%s&limit=%d", binanceAPIURL, symbol, min(depth, binanceMaxDepth))
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	res, err := b.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("binance API returned non-OK status: %s", res.Status)
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var response struct {
+		LastUpdateID int64      `json:"lastUpdateId"`
+		Bids         [][]string `json:"bids"` // [price, quantity]
+		Asks         [][]string `json:"asks"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal order book response: %w", err)
+	}
+
+	bids, err := parseLevels(response.Bids)
+	if err != nil {
+		return nil, err
+	}
+	asks, err := parseLevels(response.Asks)
+	if err != nil {
+		return nil, err
+	}
+	// The depth endpoint carries no timestamp, so use the time the response was received.
+	return &OrderBook{Symbol: symbol, Bids: bids, Asks: asks, Timestamp: time.Now()}, nil
+}
+
+// GetTradesAfter fetches the aggregate trades following afterID for a given symbol from Binance,
+// or the most recent ones if afterID is 0.
+func (b *BinanceClient) GetTradesAfter(ctx context.Context, symbol string, afterID int64, limit int) ([]Trade, error) {
+	url := fmt.Sprintf("%s/aggTrades?symbol=%s&limit=%d", binanceAPIURL, symbol, min(limit, binanceMaxTrades))
+	if afterID > 0 {
+		url += fmt.Sprintf("&fromId=%d", afterID+1)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	res, err := b.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("binance API returned non-OK status: %s", res.Status)
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var rawTrades []struct {
+		ID           int64  `json:"a"`
+		Price        string `json:"p"`
+		Quantity     string `json:"q"`
+		Time         int64  `json:"T"`
+		IsBuyerMaker bool   `json:"m"` // The taker sold into a resting bid
+	}
+	if err := json.Unmarshal(body, &rawTrades); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal trades response: %w", err)
+	}
+
+	trades := make([]Trade, 0, len(rawTrades))
+	for _, raw := range rawTrades {
+		price, err := strconv.ParseFloat(raw.Price, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse trade price: %w", err)
+		}
+		qty, err := strconv.ParseFloat(raw.Quantity, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse trade quantity: %w", err)
+		}
+		side := SideBuy
+		if raw.IsBuyerMaker {
+			side = SideSell
+		}
+		trades = append(trades, Trade{ID: raw.ID, Symbol: symbol, Price: price, Quantity: qty, Side: side, Timestamp: time.UnixMilli(raw.Time)})
+	}
+	return trades, nil
+}
@@ -0,0 +1,222 @@
+package exchange
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"klineio/pkg/log"
+
+	"github.com/spf13/viper"
+)
+
+// binanceBaseURL is the host of the Binance spot REST API.
+const binanceBaseURL = "https://api.binance.com"
+
+// binanceRecvWindow is how long a signed request stays valid after its timestamp.
+const binanceRecvWindow = 5 * time.Second
+
+// BinanceAccountClient implements the AccountClient interface for Binance with HMAC-SHA256 signed requests.
+type BinanceAccountClient struct {
+	client      *http.Client
+	logger      *log.Logger
+	baseURL     string
+	credentials Credentials
+	now         func() time.Time
+}
+
+// NewBinanceAccountClient creates a new BinanceAccountClient from exchange.binance.api_key and secret_key.
+// exchange.binance.base_url overrides the API host, e.g., for the testnet.
+func NewBinanceAccountClient(logger *log.Logger, conf *viper.Viper) *BinanceAccountClient {
+	baseURL := conf.GetString("exchange.binance.base_url")
+	if baseURL == "" {
+		baseURL = binanceBaseURL
+	}
+	return &BinanceAccountClient{
+		client:  newHTTPClient("BINANCE", logger, conf),
+		logger:  logger,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		credentials: Credentials{
+			APIKey:    conf.GetString("exchange.binance.api_key"),
+			SecretKey: conf.GetString("exchange.binance.secret_key"),
+		},
+		now: time.Now,
+	}
+}
+
+// Configured reports whether Binance API credentials are available.
+func (b *BinanceAccountClient) Configured() bool {
+	return b.credentials.configured()
+}
+
+// signBinance returns the hex HMAC-SHA256 signature of a query string.
+func signBinance(secret, query string) string {
+	return hex.EncodeToString(hmacSHA256(secret, query))
+}
+
+// get sends a signed GET request and unmarshals the JSON response into out.
+func (b *BinanceAccountClient) get(ctx context.Context, path string, params url.Values, out interface{}) error {
+	if !b.Configured() {
+		return fmt.Errorf("binance: %w", ErrMissingCredentials)
+	}
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("recvWindow", strconv.FormatInt(binanceRecvWindow.Milliseconds(), 10))
+	params.Set("timestamp", strconv.FormatInt(b.now().UnixMilli(), 10))
+	query := params.Encode()
+	query += "&signature=" + signBinance(b.credentials.SecretKey, query)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", b.baseURL+path+"?"+query, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-MBX-APIKEY", b.credentials.APIKey)
+
+	res, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Msg != "" {
+			return fmt.Errorf("binance API error: %d - %s", apiErr.Code, apiErr.Msg)
+		}
+		return fmt.Errorf("binance API returned non-OK status: %s", res.Status)
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// GetBalances fetches the non-zero spot balances from Binance.
+func (b *BinanceAccountClient) GetBalances(ctx context.Context) ([]Balance, error) {
+	var response struct {
+		Balances []struct {
+			Asset  string `json:"asset"`
+			Free   string `json:"free"`
+			Locked string `json:"locked"`
+		} `json:"balances"`
+	}
+	params := url.Values{"omitZeroBalances": {"true"}}
+	if err := b.get(ctx, "/api/v3/account", params, &response); err != nil {
+		return nil, err
+	}
+
+	var balances []Balance
+	for _, raw := range response.Balances {
+		balance := Balance{Asset: raw.Asset, Free: parseOptionalFloat(raw.Free), Locked: parseOptionalFloat(raw.Locked)}
+		if balance.Total() == 0 {
+			continue
+		}
+		balances = append(balances, balance)
+	}
+	return balances, nil
+}
+
+// GetOpenOrders fetches the open spot orders from Binance.
+func (b *BinanceAccountClient) GetOpenOrders(ctx context.Context, symbol string) ([]Order, error) {
+	params := url.Values{}
+	if symbol != "" {
+		params.Set("symbol", CanonicalSymbol(symbol))
+	}
+	var rawOrders []struct {
+		Symbol      string `json:"symbol"`
+		OrderID     int64  `json:"orderId"`
+		Price       string `json:"price"`
+		OrigQty     string `json:"origQty"`
+		ExecutedQty string `json:"executedQty"`
+		Status      string `json:"status"`
+		Type        string `json:"type"`
+		Side        string `json:"side"` // BUY or SELL
+		Time        int64  `json:"time"`
+	}
+	if err := b.get(ctx, "/api/v3/openOrders", params, &rawOrders); err != nil {
+		return nil, err
+	}
+
+	orders := make([]Order, len(rawOrders))
+	for i, raw := range rawOrders {
+		orders[i] = Order{
+			ID:        strconv.FormatInt(raw.OrderID, 10),
+			Symbol:    raw.Symbol,
+			Side:      strings.ToLower(raw.Side),
+			Type:      raw.Type,
+			Price:     parseOptionalFloat(raw.Price),
+			Quantity:  parseOptionalFloat(raw.OrigQty),
+			Filled:    parseOptionalFloat(raw.ExecutedQty),
+			Status:    raw.Status,
+			CreatedAt: time.UnixMilli(raw.Time),
+		}
+	}
+	return orders, nil
+}
+
+// GetTradeHistory fetches the most recent fills for symbol from Binance. Binance requires a symbol.
+func (b *BinanceAccountClient) GetTradeHistory(ctx context.Context, symbol string, limit int) ([]Fill, error) {
+	if symbol == "" {
+		return nil, fmt.Errorf("binance trade history requires a symbol")
+	}
+	if limit <= 0 {
+		limit = binanceMaxTrades
+	}
+	params := url.Values{
+		"symbol": {CanonicalSymbol(symbol)},
+		"limit":  {strconv.Itoa(min(limit, binanceMaxTrades))},
+	}
+	var rawFills []struct {
+		Symbol          string `json:"symbol"`
+		ID              int64  `json:"id"`
+		OrderID         int64  `json:"orderId"`
+		Price           string `json:"price"`
+		Qty             string `json:"qty"`
+		Commission      string `json:"commission"`
+		CommissionAsset string `json:"commissionAsset"`
+		Time            int64  `json:"time"`
+		IsBuyer         bool   `json:"isBuyer"`
+		IsMaker         bool   `json:"isMaker"`
+	}
+	if err := b.get(ctx, "/api/v3/myTrades", params, &rawFills); err != nil {
+		return nil, err
+	}
+
+	fills := make([]Fill, len(rawFills))
+	for i, raw := range rawFills {
+		side := SideSell
+		if raw.IsBuyer {
+			side = SideBuy
+		}
+		fills[i] = Fill{
+			ID:        strconv.FormatInt(raw.ID, 10),
+			OrderID:   strconv.FormatInt(raw.OrderID, 10),
+			Symbol:    raw.Symbol,
+			Side:      side,
+			Price:     parseOptionalFloat(raw.Price),
+			Quantity:  parseOptionalFloat(raw.Qty),
+			Fee:       parseOptionalFloat(raw.Commission),
+			FeeAsset:  raw.CommissionAsset,
+			IsMaker:   raw.IsMaker,
+			Timestamp: time.UnixMilli(raw.Time),
+		}
+	}
+	return fills, nil
+}
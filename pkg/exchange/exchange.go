@@ -2,7 +2,19 @@ package exchange
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
 	"time"
+
+	"klineio/pkg/log"
+	"klineio/pkg/metrics"
+
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.uber.org/zap"
 )
 
 // Kline represents a single K-line (candlestick) data point.
@@ -16,16 +28,119 @@ type Kline struct {
 	CloseTime time.Time
 }
 
-// Ticker represents simplified ticker information for popular symbols.
+// Ticker represents 24h rolling ticker statistics for a symbol.
 type Ticker struct {
-	Symbol string
-	Price  float64
-	Volume float64
+	Symbol        string    // Canonical symbol, e.g., BTCUSDT
+	Price         float64   // Last traded price
+	Open          float64   // Price 24h ago
+	High          float64   // 24h high
+	Low           float64   // 24h low
+	ChangePercent float64   // 24h price change in percent
+	BaseVolume    float64   // 24h volume in the base asset, e.g., BTC
+	QuoteVolume   float64   // 24h volume in the quote asset, e.g., USDT
+	Bid           float64   // Best bid price
+	Ask           float64   // Best ask price
+	Timestamp     time.Time // Time the statistics were computed by the exchange
 }
 
 // ExchangeClient defines the interface for interacting with cryptocurrency exchanges.
 type ExchangeClient interface {
 	GetLatestPrice(ctx context.Context, symbol string) (float64, error)
-	GetKlines(ctx context.Context, symbol string, interval string, limit int) ([]Kline, error)
+	GetKlines(ctx context.Context, symbol string, interval Interval, limit int) ([]Kline, error)
+	// GetTopVolumeTickers returns the USDT pairs with the highest 24h quote volume.
+	// A limit of zero or less returns every pair, ranked.
 	GetTopVolumeTickers(ctx context.Context, limit int) ([]Ticker, error)
+	// GetOrderBook returns up to depth price levels per side.
+	GetOrderBook(ctx context.Context, symbol string, depth int) (*OrderBook, error)
+	// GetTradesAfter returns up to limit public trades with IDs above afterID, oldest first, or the
+	// most recent ones if afterID is 0. When more than limit trades follow afterID, an exchange that
+	// pages forward returns the earliest of them and one that only pages backwards the latest.
+	GetTradesAfter(ctx context.Context, symbol string, afterID int64, limit int) ([]Trade, error)
+	// SupportsInterval reports whether GetKlines accepts interval natively.
+	SupportsInterval(interval Interval) bool
+}
+
+// KlineLimiter is implemented by clients whose GetKlines returns at most MaxKlines candles per request.
+type KlineLimiter interface {
+	MaxKlines() int
+}
+
+// Pinger is implemented by clients that can check whether their exchange is reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// ping sends a GET request to url and checks for a 200 response.
+func ping(ctx context.Context, client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("ping returned non-OK status: %s", res.Status)
+	}
+	return nil
+}
+
+// sortByQuoteVolume orders tickers by 24h quote volume, highest first, so that rankings
+// are comparable across exchanges.
+func sortByQuoteVolume(tickers []Ticker) {
+	sort.SliceStable(tickers, func(i, j int) bool {
+		return tickers[i].QuoteVolume > tickers[j].QuoteVolume
+	})
+}
+
+// parseOptionalFloat parses a numeric string field that exchanges may leave empty, returning 0 in that case.
+func parseOptionalFloat(s string) float64 {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// newHTTPClient creates the HTTP client of an exchange client, honoring proxy.http. Requests are
+// counted, timed and traced per endpoint under exchangeName.
+func newHTTPClient(exchangeName string, logger *log.Logger, conf *viper.Viper) *http.Client {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+	}
+
+	proxyURL := conf.GetString("proxy.http")
+	if proxyURL != "" {
+		parsedProxyURL, err := url.Parse(proxyURL)
+		if err != nil {
+			logger.Warn("Failed to parse HTTP proxy URL", zap.Error(err), zap.String("proxy_url", proxyURL))
+		} else {
+			transport.Proxy = http.ProxyURL(parsedProxyURL)
+		}
+	}
+
+	// Spans are named e.g. "BINANCE GET /api/v3/klines"
+	traced := otelhttp.NewTransport(transport, otelhttp.WithSpanNameFormatter(func(_ string, req *http.Request) string {
+		return exchangeName + " " + req.Method + " " + req.URL.Path
+	}))
+	return &http.Client{Timeout: 60 * time.Second, Transport: &instrumentedTransport{exchange: exchangeName, next: traced}} // Increased timeout to 60 seconds
+}
+
+// instrumentedTransport records the metrics of every request sent through it.
+type instrumentedTransport struct {
+	exchange string
+	next     http.RoundTripper
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := t.next.RoundTrip(req)
+	code := "network_error"
+	if err == nil {
+		code = strconv.Itoa(res.StatusCode)
+	}
+	metrics.ObserveExchangeRequest(t.exchange, req.URL.Path, code, time.Since(start))
+	return res, err
 }
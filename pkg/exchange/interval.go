@@ -0,0 +1,116 @@
+package exchange
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Interval is a normalized K-line interval. Minutes use a lowercase "m" and months an uppercase "M".
+type Interval string
+
+const (
+	Interval1s  Interval = "1s"
+	Interval1m  Interval = "1m"
+	Interval3m  Interval = "3m"
+	Interval5m  Interval = "5m"
+	Interval15m Interval = "15m"
+	Interval30m Interval = "30m"
+	Interval1h  Interval = "1h"
+	Interval2h  Interval = "2h"
+	Interval4h  Interval = "4h"
+	Interval6h  Interval = "6h"
+	Interval8h  Interval = "8h"
+	Interval12h Interval = "12h"
+	Interval1d  Interval = "1d"
+	Interval2d  Interval = "2d"
+	Interval3d  Interval = "3d"
+	Interval1w  Interval = "1w"
+	Interval1M  Interval = "1M"
+	Interval3M  Interval = "3M"
+)
+
+// Intervals lists every interval offered by at least one supported exchange, shortest first.
+var Intervals = []Interval{
+	Interval1s, Interval1m, Interval3m, Interval5m, Interval15m, Interval30m,
+	Interval1h, Interval2h, Interval4h, Interval6h, Interval8h, Interval12h,
+	Interval1d, Interval2d, Interval3d, Interval1w, Interval1M, Interval3M,
+}
+
+// ErrUnsupportedInterval is returned when an exchange does not offer an interval natively.
+var ErrUnsupportedInterval = errors.New("unsupported interval")
+
+// ParseInterval normalizes exchange-style spellings such as "1H", "1D" or "1Dutc" into an Interval.
+func ParseInterval(s string) (Interval, error) {
+	s = strings.TrimSuffix(strings.TrimSpace(s), "utc")
+	if len(s) < 2 {
+		return "", fmt.Errorf("invalid interval: %q", s)
+	}
+	unit := s[len(s)-1:]
+	if unit != "M" {
+		unit = strings.ToLower(unit)
+	}
+	i := Interval(s[:len(s)-1] + unit)
+	for _, known := range Intervals {
+		if i == known {
+			return i, nil
+		}
+	}
+	return "", fmt.Errorf("invalid interval: %q", s)
+}
+
+// count and unit split an interval such as "15m" into 15 and "m".
+func (i Interval) split() (int, string) {
+	n, _ := strconv.Atoi(string(i[:len(i)-1]))
+	return n, string(i[len(i)-1:])
+}
+
+// Duration returns the length of the interval. Months are approximated as 30 days;
+// use CloseTime for exact month boundaries.
+func (i Interval) Duration() time.Duration {
+	if len(i) < 2 {
+		return 0
+	}
+	n, unit := i.split()
+	switch unit {
+	case "s":
+		return time.Duration(n) * time.Second
+	case "m":
+		return time.Duration(n) * time.Minute
+	case "h":
+		return time.Duration(n) * time.Hour
+	case "d":
+		return time.Duration(n) * 24 * time.Hour
+	case "w":
+		return time.Duration(n) * 7 * 24 * time.Hour
+	case "M":
+		return time.Duration(n) * 30 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// IsCalendar reports whether the interval is measured in calendar months rather than a fixed duration.
+func (i Interval) IsCalendar() bool {
+	return len(i) >= 2 && i[len(i)-1] == 'M'
+}
+
+// CloseTime returns the close time of a candle opened at open, one millisecond before the next candle opens.
+func (i Interval) CloseTime(open time.Time) time.Time {
+	if i.IsCalendar() {
+		n, _ := i.split()
+		return open.AddDate(0, n, 0).Add(-time.Millisecond)
+	}
+	return open.Add(i.Duration() - time.Millisecond)
+}
+
+func (i Interval) String() string {
+	return string(i)
+}
+
+// unsupportedInterval wraps ErrUnsupportedInterval with the exchange name.
+func unsupportedInterval(exchangeName string, interval Interval) error {
+	return fmt.Errorf("%w for %s: %s", ErrUnsupportedInterval, exchangeName, interval)
+}
@@ -0,0 +1,70 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseInterval(t *testing.T) {
+	tests := map[string]Interval{
+		"1m":    Interval1m,
+		"1H":    Interval1h,
+		"1Dutc": Interval1d,
+		"1W":    Interval1w,
+		"1M":    Interval1M,
+		"3M":    Interval3M,
+	}
+	for in, want := range tests {
+		if got, err := ParseInterval(in); err != nil || got != want {
+			t.Errorf("ParseInterval(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "7m", "1y", "m"} {
+		if _, err := ParseInterval(in); err == nil {
+			t.Errorf("ParseInterval(%q) should fail", in)
+		}
+	}
+}
+
+func TestIntervalCloseTime(t *testing.T) {
+	open := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	if got := Interval4h.CloseTime(open); !got.Equal(open.Add(4*time.Hour - time.Millisecond)) {
+		t.Errorf("4h close time = %s", got)
+	}
+	feb := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	if got := Interval1M.CloseTime(feb); !got.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC).Add(-time.Millisecond)) {
+		t.Errorf("1M close time = %s", got)
+	}
+}
+
+func TestIntervalSupport(t *testing.T) {
+	b, o := &BinanceClient{}, &OKEXClient{}
+	for _, i := range Intervals {
+		if !b.SupportsInterval(i) && !o.SupportsInterval(i) {
+			t.Errorf("interval %s is not offered by any exchange", i)
+		}
+	}
+	if b.SupportsInterval(Interval2d) || o.SupportsInterval(Interval8h) {
+		t.Errorf("unexpected native support")
+	}
+	if _, err := o.GetKlines(context.Background(), "BTCUSDT", Interval8h, 10); !errors.Is(err, ErrUnsupportedInterval) {
+		t.Errorf("expected ErrUnsupportedInterval, got %v", err)
+	}
+}
+
+func TestSortByQuoteVolume(t *testing.T) {
+	// A cheap coin can trade more units yet less notional than BTC.
+	tickers := []Ticker{
+		{Symbol: "DOGEUSDT", BaseVolume: 1e9, QuoteVolume: 1e8},
+		{Symbol: "BTCUSDT", BaseVolume: 2e4, QuoteVolume: 1.2e9},
+		{Symbol: "ETHUSDT", BaseVolume: 3e5, QuoteVolume: 9e8},
+	}
+	sortByQuoteVolume(tickers)
+	for i, want := range []string{"BTCUSDT", "ETHUSDT", "DOGEUSDT"} {
+		if tickers[i].Symbol != want {
+			t.Errorf("rank %d = %s, want %s", i, tickers[i].Symbol, want)
+		}
+	}
+}
@@ -6,13 +6,13 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"net/url"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"klineio/pkg/log"
+	"klineio/pkg/metrics"
 
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
@@ -20,6 +20,42 @@ import (
 
 const okexAPIURL = "https://www.okx.com/api/v5/market"
 
+// okexTimeURL returns the server time, which makes a cheap reachability check.
+const okexTimeURL = "https://www.okx.com/api/v5/public/time"
+
+// okexMaxDepth is the largest order book size accepted by OKEX.
+const okexMaxDepth = 400
+
+// okexMaxTrades is the largest trades limit accepted by OKEX.
+const okexMaxTrades = 500
+
+// okexMaxHistoryTrades is the largest history-trades limit accepted by OKEX.
+const okexMaxHistoryTrades = 100
+
+// okexMaxKlines is the largest candles limit accepted by OKEX.
+const okexMaxKlines = 300
+
+// okexBars maps intervals to OKX bar names. Intervals of 6h and longer use the UTC-aligned
+// variants so that candle boundaries match Binance rather than Hong Kong time.
+var okexBars = map[Interval]string{
+	Interval1m:  "1m",
+	Interval3m:  "3m",
+	Interval5m:  "5m",
+	Interval15m: "15m",
+	Interval30m: "30m",
+	Interval1h:  "1H",
+	Interval2h:  "2H",
+	Interval4h:  "4H",
+	Interval6h:  "6Hutc",
+	Interval12h: "12Hutc",
+	Interval1d:  "1Dutc",
+	Interval2d:  "2Dutc",
+	Interval3d:  "3Dutc",
+	Interval1w:  "1Wutc",
+	Interval1M:  "1Mutc",
+	Interval3M:  "3Mutc",
+}
+
 // OKEXClient implements the ExchangeClient interface for OKEX.
 type OKEXClient struct {
 	client *http.Client
@@ -28,33 +64,21 @@ type OKEXClient struct {
 
 // NewOKEXClient creates a new OKEXClient.
 func NewOKEXClient(logger *log.Logger, conf *viper.Viper) *OKEXClient {
-	transport := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-	}
-
-	proxyURL := conf.GetString("proxy.http")
-	if proxyURL != "" {
-		parsedProxyURL, err := url.Parse(proxyURL)
-		if err != nil {
-			logger.Warn("Failed to parse HTTP proxy URL", zap.Error(err), zap.String("proxy_url", proxyURL))
-		} else {
-			transport.Proxy = http.ProxyURL(parsedProxyURL)
-		}
-	}
-
 	return &OKEXClient{
-		client: &http.Client{Timeout: 60 * time.Second, Transport: transport}, // Increased timeout to 60 seconds
+		client: newHTTPClient("OKEX", logger, conf),
 		logger: logger,
 	}
 }
 
+// Ping checks that the OKEX API is reachable.
+func (o *OKEXClient) Ping(ctx context.Context) error {
+	return ping(ctx, o.client, okexTimeURL)
+}
+
 // GetLatestPrice fetches the latest price for a given symbol from OKEX.
 func (o *OKEXClient) GetLatestPrice(ctx context.Context, symbol string) (float64, error) {
 	// OKEX现货交易对通常为 BTC-USDT 格式，需要将 symbol (例如 BTCUSDT) 转换为 BTC-USDT
-	instId := symbol
-	if !strings.Contains(symbol, "-") && strings.HasSuffix(symbol, "USDT") {
-		instId = strings.Replace(symbol, "USDT", "-USDT", 1)
-	}
+	instId := okexInstID(symbol)
 
 	url := fmt.Sprintf("%s/tickers?instType=SPOT&instId=%s", okexAPIURL, instId)
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -90,7 +114,7 @@ func (o *OKEXClient) GetLatestPrice(ctx context.Context, symbol string) (float64
 	}
 
 	if response.Code != "0" || len(response.Data) == 0 {
-		return 0, fmt.Errorf("OKEX API error: %s - %s", response.Code, response.Msg)
+		return 0, okexAPIError(req.URL.Path, response.Code, response.Msg)
 	}
 
 	price, err := strconv.ParseFloat(response.Data[0].Last, 64)
@@ -101,31 +125,29 @@ func (o *OKEXClient) GetLatestPrice(ctx context.Context, symbol string) (float64
 	return price, nil
 }
 
-// GetKlines fetches K-line data for a given symbol, interval, and limit from OKEX.
-func (o *OKEXClient) GetKlines(ctx context.Context, symbol string, interval string, limit int) ([]Kline, error) {
-	// OKEX interval mapping: 1m, 3m, 5m, 15m, 30m, 1h, 2h, 4h, 6h, 12h, 1d, 2d, 3d, 5d, 1w, 1M, 3M, 6M, 1Y
-	okexInterval := map[string]string{
-		"1m":  "1m",
-		"5m":  "5m",
-		"15m": "15m",
-		"30m": "30m",
-		"1h":  "1H",
-		"4h":  "4H",
-		"1d":  "1D",
-	}
+// SupportsInterval reports whether OKEX offers interval natively.
+func (o *OKEXClient) SupportsInterval(interval Interval) bool {
+	_, ok := okexBars[interval]
+	return ok
+}
 
-	mappedInterval, ok := okexInterval[interval]
+// MaxKlines returns the most candles OKEX returns per request.
+func (o *OKEXClient) MaxKlines() int {
+	return okexMaxKlines
+}
+
+// GetKlines fetches K-line data for a given symbol, interval, and limit from OKEX. A limit above
+// MaxKlines is clamped to it.
+func (o *OKEXClient) GetKlines(ctx context.Context, symbol string, interval Interval, limit int) ([]Kline, error) {
+	mappedInterval, ok := okexBars[interval]
 	if !ok {
-		return nil, fmt.Errorf("unsupported interval for OKEX: %s", interval)
+		return nil, unsupportedInterval("OKEX", interval)
 	}
 
 	// Convert symbol (e.g., APTUSDT) to OKEX format (e.g., APT-USDT)
-	instId := symbol
-	if !strings.Contains(symbol, "-") && strings.HasSuffix(symbol, "USDT") {
-		instId = strings.Replace(symbol, "USDT", "-USDT", 1)
-	}
+	instId := okexInstID(symbol)
 
-	url := fmt.Sprintf("%s/candles?instId=%s&bar=%s&limit=%d", okexAPIURL, instId, mappedInterval, limit)
+	url := fmt.Sprintf("%s/candles?instId=%s&bar=%s&limit=%d", okexAPIURL, instId, mappedInterval, min(limit, okexMaxKlines))
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -156,7 +178,7 @@ func (o *OKEXClient) GetKlines(ctx context.Context, symbol string, interval stri
 	}
 
 	if response.Code != "0" {
-		return nil, fmt.Errorf("OKEX API error: %s - %s", response.Code, response.Msg)
+		return nil, okexAPIError(req.URL.Path, response.Code, response.Msg)
 	}
 
 	var klines []Kline
@@ -168,21 +190,22 @@ func (o *OKEXClient) GetKlines(ctx context.Context, symbol string, interval stri
 		close, _ := strconv.ParseFloat(rawKline[4], 64)
 		volume, _ := strconv.ParseFloat(rawKline[5], 64)
 
+		openTime := time.Unix(0, openTimeMs*int64(time.Millisecond))
 		klines = append(klines, Kline{
-			OpenTime:  time.Unix(0, openTimeMs*int64(time.Millisecond)),
+			OpenTime:  openTime,
 			Open:      open,
 			High:      high,
 			Low:       low,
 			Close:     close,
 			Volume:    volume,
-			CloseTime: time.Unix(0, openTimeMs*int64(time.Millisecond)), // OKEX only provides open time timestamp in K-line data.
+			CloseTime: interval.CloseTime(openTime), // OKEX only provides the open time, so derive the close from the interval
 		})
 	}
 
 	return klines, nil
 }
 
-// GetTopVolumeTickers fetches ticker information, sorts by volume, and returns top N.
+// GetTopVolumeTickers fetches ticker information, sorts by quote volume, and returns top N.
 func (o *OKEXClient) GetTopVolumeTickers(ctx context.Context, limit int) ([]Ticker, error) {
 	url := fmt.Sprintf("%s/tickers?instType=SPOT", okexAPIURL) // Fetch all spot tickers
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -211,7 +234,14 @@ func (o *OKEXClient) GetTopVolumeTickers(ctx context.Context, limit int) ([]Tick
 		Data []struct {
 			InstID    string `json:"instId"` // e.g., BTC-USDT
 			LastPrice string `json:"last"`
-			Vol24h    string `json:"volCcy24h"` // 24h trading volume of quote currency
+			Open24h   string `json:"open24h"`
+			High24h   string `json:"high24h"`
+			Low24h    string `json:"low24h"`
+			Vol24h    string `json:"vol24h"`    // 24h trading volume of base currency for SPOT
+			VolCcy24h string `json:"volCcy24h"` // 24h trading volume of quote currency for SPOT
+			BidPx     string `json:"bidPx"`
+			AskPx     string `json:"askPx"`
+			Ts        string `json:"ts"`
 		} `json:"data"`
 	}
 	if err := json.Unmarshal(body, &response); err != nil {
@@ -219,7 +249,7 @@ func (o *OKEXClient) GetTopVolumeTickers(ctx context.Context, limit int) ([]Tick
 	}
 
 	if response.Code != "0" {
-		return nil, fmt.Errorf("OKEX API error: %s - %s", response.Code, response.Msg)
+		return nil, okexAPIError(req.URL.Path, response.Code, response.Msg)
 	}
 
 	var tickers []Ticker
@@ -234,27 +264,199 @@ func (o *OKEXClient) GetTopVolumeTickers(ctx context.Context, limit int) ([]Tick
 			o.logger.Warn("Failed to parse OKEX ticker price", zap.Error(err), zap.String("instId", raw.InstID), zap.String("price", raw.LastPrice))
 			continue
 		}
-		volume, err := strconv.ParseFloat(raw.Vol24h, 64) // Use Vol24h as volume
+		quoteVolume, err := strconv.ParseFloat(raw.VolCcy24h, 64)
 		if err != nil {
-			o.logger.Warn("Failed to parse OKEX ticker volume", zap.Error(err), zap.String("instId", raw.InstID), zap.String("volume", raw.Vol24h))
+			o.logger.Warn("Failed to parse OKEX ticker quote volume", zap.Error(err), zap.String("instId", raw.InstID), zap.String("volCcy24h", raw.VolCcy24h))
 			continue
 		}
 
+		open := parseOptionalFloat(raw.Open24h)
+		var changePercent float64
+		if open > 0 {
+			changePercent = (price - open) / open * 100 // OKX does not report the change directly
+		}
+		ts, _ := strconv.ParseInt(raw.Ts, 10, 64)
+
 		tickers = append(tickers, Ticker{
-			Symbol: strings.ReplaceAll(raw.InstID, "-", ""), // Convert BTC-USDT to BTCUSDT
-			Price:  price,
-			Volume: volume,
+			Symbol:        CanonicalSymbol(raw.InstID), // Convert BTC-USDT to BTCUSDT
+			Price:         price,
+			Open:          open,
+			High:          parseOptionalFloat(raw.High24h),
+			Low:           parseOptionalFloat(raw.Low24h),
+			ChangePercent: changePercent,
+			BaseVolume:    parseOptionalFloat(raw.Vol24h),
+			QuoteVolume:   quoteVolume,
+			Bid:           parseOptionalFloat(raw.BidPx),
+			Ask:           parseOptionalFloat(raw.AskPx),
+			Timestamp:     time.UnixMilli(ts),
 		})
 	}
 
-	// Sort by volume in descending order
-	sort.Slice(tickers, func(i, j int) bool {
-		return tickers[i].Volume > tickers[j].Volume
-	})
+	sortByQuoteVolume(tickers)
 
 	// Return top N
-	if len(tickers) > limit {
+	if limit > 0 && len(tickers) > limit {
 		return tickers[:limit], nil
 	}
 	return tickers, nil
 }
+
+// GetOrderBook fetches the order book for a given symbol from OKEX.
+func (o *OKEXClient) GetOrderBook(ctx context.Context, symbol string, depth int) (*OrderBook, error) {
+	url := fmt.Sprintf("%s/books?instId=%s&sz=%d", okexAPIURL, okexInstID(symbol), min(depth, okexMaxDepth))
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	res, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OKEX API returned non-OK status: %s", res.Status)
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var response struct {
+		Code string `json:"code"`
+		Msg  string `json:"msg"`
+		Data []struct {
+			Asks [][]string `json:"asks"` // [price, quantity, deprecated, number of orders]
+			Bids [][]string `json:"bids"`
+			Ts   string     `json:"ts"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal order book response: %w", err)
+	}
+
+	if response.Code != "0" || len(response.Data) == 0 {
+		return nil, okexAPIError(req.URL.Path, response.Code, response.Msg)
+	}
+
+	data := response.Data[0]
+	bids, err := parseLevels(data.Bids)
+	if err != nil {
+		return nil, err
+	}
+	asks, err := parseLevels(data.Asks)
+	if err != nil {
+		return nil, err
+	}
+	ts, _ := strconv.ParseInt(data.Ts, 10, 64)
+	return &OrderBook{Symbol: symbol, Bids: bids, Asks: asks, Timestamp: time.UnixMilli(ts)}, nil
+}
+
+// GetTradesAfter fetches the trades following afterID for a given symbol from OKEX, or the most
+// recent ones if afterID is 0. OKEX only pages backwards, so it fetches the most recent trades and
+// then pages through the trade history until it reaches afterID or has collected limit trades. A gap of
+// more than limit trades is not recovered, as the oldest of them are never reached: callers asking
+// again with the newest ID returned only get the trades that followed.
+func (o *OKEXClient) GetTradesAfter(ctx context.Context, symbol string, afterID int64, limit int) ([]Trade, error) {
+	instID := okexInstID(symbol)
+	trades, err := o.getTrades(ctx, symbol, fmt.Sprintf("%s/trades?instId=%s&limit=%d", okexAPIURL, instID, min(limit, okexMaxTrades)))
+	if err != nil {
+		return nil, err
+	}
+	for afterID > 0 && len(trades) > 0 && trades[0].ID > afterID+1 && len(trades) < limit {
+		url := fmt.Sprintf("%s/history-trades?instId=%s&type=1&after=%d&limit=%d", okexAPIURL, instID, trades[0].ID, min(limit-len(trades), okexMaxHistoryTrades))
+		page, err := o.getTrades(ctx, symbol, url)
+		if err != nil {
+			return nil, err
+		}
+		if len(page) == 0 {
+			break
+		}
+		trades = append(page, trades...)
+	}
+	first := sort.Search(len(trades), func(i int) bool { return trades[i].ID > afterID })
+	return trades[first:], nil
+}
+
+// getTrades fetches one page of trades from url, which serves newest first, and returns it oldest first.
+func (o *OKEXClient) getTrades(ctx context.Context, symbol, url string) ([]Trade, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	res, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OKEX API returned non-OK status: %s", res.Status)
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var response struct {
+		Code string `json:"code"`
+		Msg  string `json:"msg"`
+		Data []struct {
+			TradeID string `json:"tradeId"`
+			Px      string `json:"px"`
+			Sz      string `json:"sz"`
+			Side    string `json:"side"` // Taker side: buy or sell
+			Ts      string `json:"ts"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal trades response: %w", err)
+	}
+
+	if response.Code != "0" {
+		return nil, okexAPIError(req.URL.Path, response.Code, response.Msg)
+	}
+
+	trades := make([]Trade, len(response.Data))
+	for i, raw := range response.Data {
+		id, err := strconv.ParseInt(raw.TradeID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse trade ID: %w", err)
+		}
+		price, err := strconv.ParseFloat(raw.Px, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse trade price: %w", err)
+		}
+		qty, err := strconv.ParseFloat(raw.Sz, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse trade quantity: %w", err)
+		}
+		ts, err := strconv.ParseInt(raw.Ts, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse trade time: %w", err)
+		}
+		trades[len(trades)-1-i] = Trade{ID: id, Symbol: symbol, Price: price, Quantity: qty, Side: raw.Side, Timestamp: time.UnixMilli(ts)}
+	}
+	return trades, nil
+}
+
+// okexInstID converts a symbol such as APTUSDT to the OKEX instrument ID APT-USDT.
+func okexInstID(symbol string) string {
+	if !strings.Contains(symbol, "-") && strings.HasSuffix(symbol, "USDT") {
+		return strings.TrimSuffix(symbol, "USDT") + "-USDT"
+	}
+	return symbol
+}
+
+// okexAPIError records and returns an error code OKEX reported in the body of a response to endpoint.
+func okexAPIError(endpoint, code, msg string) error {
+	if code == "0" {
+		code = "empty_data" // The request succeeded without returning the expected data
+	}
+	metrics.ExchangeAPIError("OKEX", endpoint, code)
+	return fmt.Errorf("OKEX API error: %s - %s", code, msg)
+}
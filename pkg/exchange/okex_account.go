@@ -0,0 +1,229 @@
+package exchange
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"klineio/pkg/log"
+
+	"github.com/spf13/viper"
+)
+
+// okexBaseURL is the host of the OKEX REST API.
+const okexBaseURL = "https://www.okx.com"
+
+// okexMaxFills is the largest fills-history limit accepted by OKEX.
+const okexMaxFills = 100
+
+// OKEXAccountClient implements the AccountClient interface for OKEX with HMAC-SHA256 signed requests.
+type OKEXAccountClient struct {
+	client      *http.Client
+	logger      *log.Logger
+	baseURL     string
+	credentials Credentials
+	now         func() time.Time
+}
+
+// NewOKEXAccountClient creates a new OKEXAccountClient from exchange.okex.api_key, secret_key and passphrase.
+// exchange.okex.base_url overrides the API host.
+func NewOKEXAccountClient(logger *log.Logger, conf *viper.Viper) *OKEXAccountClient {
+	baseURL := conf.GetString("exchange.okex.base_url")
+	if baseURL == "" {
+		baseURL = okexBaseURL
+	}
+	return &OKEXAccountClient{
+		client:  newHTTPClient("OKEX", logger, conf),
+		logger:  logger,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		credentials: Credentials{
+			APIKey:     conf.GetString("exchange.okex.api_key"),
+			SecretKey:  conf.GetString("exchange.okex.secret_key"),
+			Passphrase: conf.GetString("exchange.okex.passphrase"),
+		},
+		now: time.Now,
+	}
+}
+
+// Configured reports whether OKEX API credentials, including the passphrase, are available.
+func (o *OKEXAccountClient) Configured() bool {
+	return o.credentials.configured() && o.credentials.Passphrase != ""
+}
+
+// signOKEX returns the base64 HMAC-SHA256 signature of timestamp + method + request path (with query) + body.
+func signOKEX(secret, timestamp, method, requestPath, body string) string {
+	return base64.StdEncoding.EncodeToString(hmacSHA256(secret, timestamp+method+requestPath+body))
+}
+
+// get sends a signed GET request and unmarshals the data field of the JSON response into out.
+func (o *OKEXAccountClient) get(ctx context.Context, path string, params url.Values, out interface{}) error {
+	if !o.Configured() {
+		return fmt.Errorf("OKEX: %w", ErrMissingCredentials)
+	}
+	requestPath := path
+	if len(params) > 0 {
+		requestPath += "?" + params.Encode()
+	}
+	timestamp := o.now().UTC().Format("2006-01-02T15:04:05.000Z")
+
+	req, err := http.NewRequestWithContext(ctx, "GET", o.baseURL+requestPath, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("OK-ACCESS-KEY", o.credentials.APIKey)
+	req.Header.Set("OK-ACCESS-SIGN", signOKEX(o.credentials.SecretKey, timestamp, "GET", requestPath, ""))
+	req.Header.Set("OK-ACCESS-TIMESTAMP", timestamp)
+	req.Header.Set("OK-ACCESS-PASSPHRASE", o.credentials.Passphrase)
+
+	res, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	// OKEX reports errors in the body, with either a 200 or an error status.
+	var response struct {
+		Code string          `json:"code"`
+		Msg  string          `json:"msg"`
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		if res.StatusCode != http.StatusOK {
+			return fmt.Errorf("OKEX API returned non-OK status: %s", res.Status)
+		}
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if response.Code != "0" {
+		return okexAPIError(req.URL.Path, response.Code, response.Msg)
+	}
+	if err := json.Unmarshal(response.Data, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response data: %w", err)
+	}
+	return nil
+}
+
+// GetBalances fetches the non-zero trading account balances from OKEX.
+func (o *OKEXAccountClient) GetBalances(ctx context.Context) ([]Balance, error) {
+	var data []struct {
+		Details []struct {
+			Ccy       string `json:"ccy"`
+			AvailBal  string `json:"availBal"`
+			FrozenBal string `json:"frozenBal"`
+		} `json:"details"`
+	}
+	if err := o.get(ctx, "/api/v5/account/balance", nil, &data); err != nil {
+		return nil, err
+	}
+
+	var balances []Balance
+	for _, account := range data {
+		for _, raw := range account.Details {
+			balance := Balance{Asset: raw.Ccy, Free: parseOptionalFloat(raw.AvailBal), Locked: parseOptionalFloat(raw.FrozenBal)}
+			if balance.Total() == 0 {
+				continue
+			}
+			balances = append(balances, balance)
+		}
+	}
+	return balances, nil
+}
+
+// GetOpenOrders fetches the open spot orders from OKEX.
+func (o *OKEXAccountClient) GetOpenOrders(ctx context.Context, symbol string) ([]Order, error) {
+	params := url.Values{"instType": {"SPOT"}}
+	if symbol != "" {
+		params.Set("instId", okexInstID(symbol))
+	}
+	var data []struct {
+		InstID    string `json:"instId"`
+		OrdID     string `json:"ordId"`
+		Px        string `json:"px"`
+		Sz        string `json:"sz"`
+		AccFillSz string `json:"accFillSz"`
+		State     string `json:"state"`
+		OrdType   string `json:"ordType"`
+		Side      string `json:"side"` // buy or sell
+		CTime     string `json:"cTime"`
+	}
+	if err := o.get(ctx, "/api/v5/trade/orders-pending", params, &data); err != nil {
+		return nil, err
+	}
+
+	orders := make([]Order, len(data))
+	for i, raw := range data {
+		created, _ := strconv.ParseInt(raw.CTime, 10, 64)
+		orders[i] = Order{
+			ID:        raw.OrdID,
+			Symbol:    CanonicalSymbol(raw.InstID),
+			Side:      raw.Side,
+			Type:      raw.OrdType,
+			Price:     parseOptionalFloat(raw.Px),
+			Quantity:  parseOptionalFloat(raw.Sz),
+			Filled:    parseOptionalFloat(raw.AccFillSz),
+			Status:    raw.State,
+			CreatedAt: time.UnixMilli(created),
+		}
+	}
+	return orders, nil
+}
+
+// GetTradeHistory fetches the most recent spot fills of the last three months from OKEX,
+// for every symbol if symbol is empty.
+func (o *OKEXAccountClient) GetTradeHistory(ctx context.Context, symbol string, limit int) ([]Fill, error) {
+	if limit <= 0 {
+		limit = okexMaxFills
+	}
+	params := url.Values{
+		"instType": {"SPOT"},
+		"limit":    {strconv.Itoa(min(limit, okexMaxFills))},
+	}
+	if symbol != "" {
+		params.Set("instId", okexInstID(symbol))
+	}
+	var data []struct {
+		InstID   string `json:"instId"`
+		TradeID  string `json:"tradeId"`
+		OrdID    string `json:"ordId"`
+		FillPx   string `json:"fillPx"`
+		FillSz   string `json:"fillSz"`
+		Side     string `json:"side"`
+		ExecType string `json:"execType"` // T for taker, M for maker
+		Fee      string `json:"fee"`      // Negative when charged
+		FeeCcy   string `json:"feeCcy"`
+		Ts       string `json:"ts"`
+	}
+	if err := o.get(ctx, "/api/v5/trade/fills-history", params, &data); err != nil {
+		return nil, err
+	}
+
+	// OKEX returns the newest fill first
+	fills := make([]Fill, len(data))
+	for i, raw := range data {
+		ts, _ := strconv.ParseInt(raw.Ts, 10, 64)
+		fills[len(fills)-1-i] = Fill{
+			ID:        raw.TradeID,
+			OrderID:   raw.OrdID,
+			Symbol:    CanonicalSymbol(raw.InstID),
+			Side:      raw.Side,
+			Price:     parseOptionalFloat(raw.FillPx),
+			Quantity:  parseOptionalFloat(raw.FillSz),
+			Fee:       -parseOptionalFloat(raw.Fee),
+			FeeAsset:  raw.FeeCcy,
+			IsMaker:   raw.ExecType == "M",
+			Timestamp: time.UnixMilli(ts),
+		}
+	}
+	return fills, nil
+}
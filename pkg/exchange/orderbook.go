@@ -0,0 +1,85 @@
+package exchange
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// PriceLevel is one price level of an order book.
+type PriceLevel struct {
+	Price    float64
+	Quantity float64 // In the base asset
+}
+
+// Notional returns the value of the level in the quote asset.
+func (l PriceLevel) Notional() float64 {
+	return l.Price * l.Quantity
+}
+
+// OrderBook is a snapshot of the best bids (highest first) and asks (lowest first) of a symbol.
+type OrderBook struct {
+	Symbol    string
+	Bids      []PriceLevel
+	Asks      []PriceLevel
+	Timestamp time.Time
+}
+
+// Mid returns the midpoint between the best bid and ask, or 0 if either side is empty.
+func (b *OrderBook) Mid() float64 {
+	if len(b.Bids) == 0 || len(b.Asks) == 0 {
+		return 0
+	}
+	return (b.Bids[0].Price + b.Asks[0].Price) / 2
+}
+
+// Spread returns the best ask minus the best bid, or 0 if either side is empty.
+func (b *OrderBook) Spread() float64 {
+	if len(b.Bids) == 0 || len(b.Asks) == 0 {
+		return 0
+	}
+	return b.Asks[0].Price - b.Bids[0].Price
+}
+
+// Depth returns the quote-asset value resting on each side within percent of the mid price.
+// The result only covers the levels fetched, so a shallow snapshot understates wide bands.
+func (b *OrderBook) Depth(percent float64) (bid, ask float64) {
+	mid := b.Mid()
+	if mid == 0 {
+		return 0, 0
+	}
+	low, high := mid*(1-percent/100), mid*(1+percent/100)
+	for _, l := range b.Bids {
+		if l.Price < low {
+			break
+		}
+		bid += l.Notional()
+	}
+	for _, l := range b.Asks {
+		if l.Price > high {
+			break
+		}
+		ask += l.Notional()
+	}
+	return bid, ask
+}
+
+// parseLevels converts [price, quantity, ...] string arrays as returned by both exchanges.
+func parseLevels(raw [][]string) ([]PriceLevel, error) {
+	levels := make([]PriceLevel, 0, len(raw))
+	for _, r := range raw {
+		if len(r) < 2 {
+			return nil, fmt.Errorf("malformed price level: %v", r)
+		}
+		price, err := strconv.ParseFloat(r[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse level price: %w", err)
+		}
+		qty, err := strconv.ParseFloat(r[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse level quantity: %w", err)
+		}
+		levels = append(levels, PriceLevel{Price: price, Quantity: qty})
+	}
+	return levels, nil
+}
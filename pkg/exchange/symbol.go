@@ -0,0 +1,9 @@
+package exchange
+
+import "strings"
+
+// CanonicalSymbol normalizes exchange-specific instrument names (BTC-USDT, btcusdt) to the BTCUSDT form
+// so that the same instrument can be matched across exchanges.
+func CanonicalSymbol(symbol string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", "_", "", "/", "").Replace(symbol))
+}
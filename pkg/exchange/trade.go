@@ -0,0 +1,24 @@
+package exchange
+
+import "time"
+
+// Taker sides of a trade.
+const (
+	SideBuy  = "buy"
+	SideSell = "sell"
+)
+
+// Trade is a public trade. On Binance it is an aggregate trade: fills of one taker order at one price.
+type Trade struct {
+	ID        int64 // Increases monotonically per symbol on both exchanges
+	Symbol    string
+	Price     float64
+	Quantity  float64 // In the base asset
+	Side      string  // Taker side, SideBuy or SideSell
+	Timestamp time.Time
+}
+
+// Notional returns the value of the trade in the quote asset.
+func (t Trade) Notional() float64 {
+	return t.Price * t.Quantity
+}
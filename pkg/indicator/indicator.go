@@ -0,0 +1,119 @@
+// Package indicator implements technical indicators over exchange K-lines.
+//
+// Every indicator is a streaming type that is fed one closed candle at a time
+// through Update. Values produced before the indicator has seen enough candles
+// are NaN, and Ready reports when the warm-up period is over. Batch results
+// are obtained by replaying a slice of K-lines through a fresh indicator with
+// Compute (or ComputeMACD, ComputeBollinger and ComputeStochastic for the
+// indicators that produce more than one value per candle).
+package indicator
+
+import (
+	"math"
+
+	"klineio/pkg/exchange"
+)
+
+// Indicator is a single-valued streaming indicator.
+type Indicator interface {
+	// Update feeds the next candle and returns the current value, or NaN during warm-up.
+	Update(k exchange.Kline) float64
+	// Ready reports whether the indicator has seen enough candles to produce values.
+	Ready() bool
+}
+
+// Compute replays klines (oldest first) through ind and returns one value per candle.
+func Compute(ind Indicator, klines []exchange.Kline) []float64 {
+	out := make([]float64, len(klines))
+	for i, k := range klines {
+		out[i] = ind.Update(k)
+	}
+	return out
+}
+
+// Closes extracts the close prices of klines.
+func Closes(klines []exchange.Kline) []float64 {
+	out := make([]float64, len(klines))
+	for i, k := range klines {
+		out[i] = k.Close
+	}
+	return out
+}
+
+// Last returns the last value of a series, or NaN if it is empty.
+func Last(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	return values[len(values)-1]
+}
+
+// window is a fixed-size ring buffer of the most recent values.
+type window struct {
+	values []float64
+	next   int
+	full   bool
+}
+
+func newWindow(size int) *window {
+	if size < 1 {
+		size = 1
+	}
+	return &window{values: make([]float64, size)}
+}
+
+// push adds v and returns the value it evicted, if any.
+func (w *window) push(v float64) (evicted float64, ok bool) {
+	if w.full {
+		evicted, ok = w.values[w.next], true
+	}
+	w.values[w.next] = v
+	w.next++
+	if w.next == len(w.values) {
+		w.next = 0
+		w.full = true
+	}
+	return evicted, ok
+}
+
+func (w *window) len() int {
+	if w.full {
+		return len(w.values)
+	}
+	return w.next
+}
+
+// each calls fn with the buffered values from oldest to newest.
+func (w *window) each(fn func(i int, v float64)) {
+	n := w.len()
+	start := 0
+	if w.full {
+		start = w.next
+	}
+	for i := 0; i < n; i++ {
+		fn(i, w.values[(start+i)%len(w.values)])
+	}
+}
+
+func (w *window) minMax() (lo, hi float64) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	w.each(func(_ int, v float64) {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	})
+	return lo, hi
+}
+
+// stdDev returns the standard deviation of the buffered values around mean.
+// ddof is 0 for the population and 1 for the sample estimate.
+func (w *window) stdDev(mean float64, ddof int) float64 {
+	n := w.len()
+	if n-ddof <= 0 {
+		return math.NaN()
+	}
+	var sq float64
+	w.each(func(_ int, v float64) {
+		sq += (v - mean) * (v - mean)
+	})
+	return math.Sqrt(sq / float64(n-ddof))
+}
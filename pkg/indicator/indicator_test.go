@@ -0,0 +1,113 @@
+package indicator
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"klineio/pkg/exchange"
+)
+
+// Closes from the StockCharts RSI worked example; highs, lows and volumes are synthetic.
+// Reference values were computed independently from the textbook formulas.
+var referenceCloses = []float64{
+	44.34, 44.09, 44.15, 43.61, 44.33, 44.83, 45.10, 45.42, 45.84, 46.08,
+	45.89, 46.03, 45.61, 46.28, 46.28, 46.00, 46.03, 46.41, 46.22, 45.64,
+}
+
+func referenceKlines() []exchange.Kline {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	klines := make([]exchange.Kline, len(referenceCloses))
+	for i, c := range referenceCloses {
+		klines[i] = exchange.Kline{
+			OpenTime: base.AddDate(0, 0, i),
+			Open:     c,
+			High:     c + 0.5,
+			Low:      c - 0.4,
+			Close:    c,
+			Volume:   float64(100 + 10*i),
+		}
+	}
+	return klines
+}
+
+func assertClose(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("%s = %v, want %v", name, got, want)
+	}
+}
+
+func TestMovingAverages(t *testing.T) {
+	klines := referenceKlines()
+	assertClose(t, "SMA(10)", Last(Compute(NewSMA(10), klines)), 46.039)
+	assertClose(t, "EMA(10)", Last(Compute(NewEMA(10), klines)), 45.87036561912813)
+	assertClose(t, "WMA(10)", Last(Compute(NewWMA(10), klines)), 46.05763636363636)
+
+	values := Compute(NewSMA(10), klines)
+	for i := 0; i < 9; i++ {
+		if !math.IsNaN(values[i]) {
+			t.Errorf("SMA(10)[%d] = %v, want NaN during warm-up", i, values[i])
+		}
+	}
+	if math.IsNaN(values[9]) {
+		t.Errorf("SMA(10)[9] should be ready")
+	}
+}
+
+func TestRSI(t *testing.T) {
+	klines := referenceKlines()
+	values := Compute(NewRSI(14), klines)
+	if !math.IsNaN(values[13]) {
+		t.Errorf("RSI(14)[13] = %v, want NaN during warm-up", values[13])
+	}
+	assertClose(t, "RSI(14) first", values[14], 70.46413502109705)
+	assertClose(t, "RSI(14) last", Last(values), 57.91502067008556)
+}
+
+func TestMACD(t *testing.T) {
+	got := ComputeMACD(referenceKlines(), 5, 10, 4)
+	last := got[len(got)-1]
+	assertClose(t, "MACD", last.MACD, 0.12568800028693516)
+	assertClose(t, "MACD signal", last.Signal, 0.2356630347266977)
+	assertClose(t, "MACD histogram", last.Histogram, -0.10997503443976253)
+	if !math.IsNaN(got[8].MACD) || math.IsNaN(got[9].MACD) {
+		t.Errorf("MACD line should start with the slow EMA")
+	}
+}
+
+func TestBollinger(t *testing.T) {
+	got := ComputeBollinger(referenceKlines(), 20, 2)
+	last := got[len(got)-1]
+	assertClose(t, "Bollinger upper", last.Upper, 47.115328221650216)
+	assertClose(t, "Bollinger middle", last.Middle, 45.409)
+	assertClose(t, "Bollinger lower", last.Lower, 43.70267177834978)
+}
+
+func TestATRStochasticOBVVolatility(t *testing.T) {
+	klines := referenceKlines()
+	assertClose(t, "ATR(14)", Last(Compute(NewATR(14), klines)), 0.9400562496243353)
+	assertClose(t, "OBV", Last(Compute(NewOBV(), klines)), 600)
+	assertClose(t, "Volatility(10)", Last(Compute(NewVolatility(10), klines)), 0.008130688489139015)
+
+	stoch := ComputeStochastic(klines, 14, 3)
+	last := stoch[len(stoch)-1]
+	assertClose(t, "Stochastic %K", last.K, 42.53393665158373)
+	assertClose(t, "Stochastic %D", last.D, 65.97761083882986)
+}
+
+func TestStreamingMatchesBatch(t *testing.T) {
+	klines := referenceKlines()
+	batch := Compute(NewRSI(14), klines)
+
+	stream := NewRSI(14)
+	for i, k := range klines {
+		got := stream.Update(k)
+		if math.IsNaN(batch[i]) != math.IsNaN(got) || (!math.IsNaN(got) && got != batch[i]) {
+			t.Fatalf("streaming RSI[%d] = %v, batch = %v", i, got, batch[i])
+		}
+	}
+	if !stream.Ready() {
+		t.Errorf("RSI should be ready after %d candles", len(klines))
+	}
+}
@@ -0,0 +1,132 @@
+package indicator
+
+import (
+	"math"
+
+	"klineio/pkg/exchange"
+)
+
+// SMA is a simple moving average of close prices.
+type SMA struct {
+	period int
+	win    *window
+	sum    float64
+}
+
+// NewSMA creates an SMA over period candles.
+func NewSMA(period int) *SMA {
+	return &SMA{period: period, win: newWindow(period)}
+}
+
+// Update feeds a candle's close.
+func (s *SMA) Update(k exchange.Kline) float64 {
+	return s.Add(k.Close)
+}
+
+// Add feeds a raw value, which lets the SMA smooth other series.
+func (s *SMA) Add(v float64) float64 {
+	if old, ok := s.win.push(v); ok {
+		s.sum -= old
+	}
+	s.sum += v
+	return s.Value()
+}
+
+// Value returns the current average, or NaN during warm-up.
+func (s *SMA) Value() float64 {
+	if !s.Ready() {
+		return math.NaN()
+	}
+	return s.sum / float64(s.period)
+}
+
+// Ready reports whether period values have been seen.
+func (s *SMA) Ready() bool {
+	return s.win.len() == s.period
+}
+
+// EMA is an exponential moving average of close prices, seeded with the SMA of the first period values.
+type EMA struct {
+	period int
+	alpha  float64
+	count  int
+	sum    float64
+	value  float64
+}
+
+// NewEMA creates an EMA with smoothing factor 2/(period+1).
+func NewEMA(period int) *EMA {
+	return &EMA{period: period, alpha: 2 / float64(period+1)}
+}
+
+// Update feeds a candle's close.
+func (e *EMA) Update(k exchange.Kline) float64 {
+	return e.Add(k.Close)
+}
+
+// Add feeds a raw value, which lets the EMA smooth other series.
+func (e *EMA) Add(v float64) float64 {
+	e.count++
+	switch {
+	case e.count < e.period:
+		e.sum += v
+	case e.count == e.period:
+		e.sum += v
+		e.value = e.sum / float64(e.period)
+	default:
+		e.value = e.alpha*v + (1-e.alpha)*e.value
+	}
+	return e.Value()
+}
+
+// Value returns the current average, or NaN during warm-up.
+func (e *EMA) Value() float64 {
+	if !e.Ready() {
+		return math.NaN()
+	}
+	return e.value
+}
+
+// Ready reports whether period values have been seen.
+func (e *EMA) Ready() bool {
+	return e.count >= e.period
+}
+
+// WMA is a linearly weighted moving average of close prices, weighting the newest value by period.
+type WMA struct {
+	period int
+	win    *window
+}
+
+// NewWMA creates a WMA over period candles.
+func NewWMA(period int) *WMA {
+	return &WMA{period: period, win: newWindow(period)}
+}
+
+// Update feeds a candle's close.
+func (w *WMA) Update(k exchange.Kline) float64 {
+	return w.Add(k.Close)
+}
+
+// Add feeds a raw value.
+func (w *WMA) Add(v float64) float64 {
+	w.win.push(v)
+	return w.Value()
+}
+
+// Value returns the current average, or NaN during warm-up.
+func (w *WMA) Value() float64 {
+	if !w.Ready() {
+		return math.NaN()
+	}
+	var sum float64
+	w.win.each(func(i int, v float64) {
+		sum += float64(i+1) * v
+	})
+	return sum / float64(w.period*(w.period+1)/2)
+}
+
+// Ready reports whether period values have been seen.
+func (w *WMA) Ready() bool {
+	return w.win.len() == w.period
+}
@@ -0,0 +1,176 @@
+package indicator
+
+import (
+	"math"
+
+	"klineio/pkg/exchange"
+)
+
+// RSI is Wilder's relative strength index.
+type RSI struct {
+	period    int
+	count     int
+	prevClose float64
+	avgGain   float64
+	avgLoss   float64
+}
+
+// NewRSI creates an RSI over period price changes, e.g. NewRSI(14).
+func NewRSI(period int) *RSI {
+	return &RSI{period: period}
+}
+
+// Update feeds a candle's close.
+func (r *RSI) Update(k exchange.Kline) float64 {
+	if r.count > 0 {
+		change := k.Close - r.prevClose
+		gain, loss := math.Max(change, 0), math.Max(-change, 0)
+		if r.count <= r.period {
+			r.avgGain += gain / float64(r.period)
+			r.avgLoss += loss / float64(r.period)
+		} else {
+			r.avgGain = (r.avgGain*float64(r.period-1) + gain) / float64(r.period)
+			r.avgLoss = (r.avgLoss*float64(r.period-1) + loss) / float64(r.period)
+		}
+	}
+	r.prevClose = k.Close
+	r.count++
+	return r.Value()
+}
+
+// Value returns the current RSI in [0, 100], or NaN during warm-up.
+func (r *RSI) Value() float64 {
+	if !r.Ready() {
+		return math.NaN()
+	}
+	if r.avgLoss == 0 {
+		if r.avgGain == 0 {
+			return 50
+		}
+		return 100
+	}
+	return 100 - 100/(1+r.avgGain/r.avgLoss)
+}
+
+// Ready reports whether period price changes have been seen.
+func (r *RSI) Ready() bool {
+	return r.count > r.period
+}
+
+// MACDValue is one MACD reading.
+type MACDValue struct {
+	MACD      float64 // Fast EMA minus slow EMA
+	Signal    float64 // EMA of the MACD line
+	Histogram float64 // MACD minus signal
+}
+
+// MACD is the moving average convergence/divergence indicator.
+type MACD struct {
+	fast, slow *EMA
+	signal     *EMA
+	value      MACDValue
+}
+
+// NewMACD creates a MACD, conventionally NewMACD(12, 26, 9).
+func NewMACD(fast, slow, signal int) *MACD {
+	return &MACD{fast: NewEMA(fast), slow: NewEMA(slow), signal: NewEMA(signal)}
+}
+
+// Update feeds a candle's close. Fields are NaN until they are available.
+func (m *MACD) Update(k exchange.Kline) MACDValue {
+	f, s := m.fast.Add(k.Close), m.slow.Add(k.Close)
+	m.value = MACDValue{MACD: math.NaN(), Signal: math.NaN(), Histogram: math.NaN()}
+	if !m.slow.Ready() || !m.fast.Ready() {
+		return m.value
+	}
+	m.value.MACD = f - s
+	m.value.Signal = m.signal.Add(m.value.MACD)
+	if m.signal.Ready() {
+		m.value.Histogram = m.value.MACD - m.value.Signal
+	}
+	return m.value
+}
+
+// Value returns the latest reading.
+func (m *MACD) Value() MACDValue {
+	return m.value
+}
+
+// Ready reports whether the signal line is available.
+func (m *MACD) Ready() bool {
+	return m.signal.Ready()
+}
+
+// ComputeMACD replays klines through a new MACD.
+func ComputeMACD(klines []exchange.Kline, fast, slow, signal int) []MACDValue {
+	m := NewMACD(fast, slow, signal)
+	out := make([]MACDValue, len(klines))
+	for i, k := range klines {
+		out[i] = m.Update(k)
+	}
+	return out
+}
+
+// StochasticValue is one stochastic oscillator reading.
+type StochasticValue struct {
+	K float64 // %K in [0, 100]
+	D float64 // SMA of %K
+}
+
+// Stochastic is the stochastic oscillator.
+type Stochastic struct {
+	period int
+	highs  *window
+	lows   *window
+	d      *SMA
+	value  StochasticValue
+}
+
+// NewStochastic creates a stochastic oscillator with a kPeriod lookback and dPeriod smoothing, e.g. NewStochastic(14, 3).
+func NewStochastic(kPeriod, dPeriod int) *Stochastic {
+	return &Stochastic{
+		period: kPeriod,
+		highs:  newWindow(kPeriod),
+		lows:   newWindow(kPeriod),
+		d:      NewSMA(dPeriod),
+	}
+}
+
+// Update feeds a candle. Fields are NaN until they are available.
+func (s *Stochastic) Update(k exchange.Kline) StochasticValue {
+	s.highs.push(k.High)
+	s.lows.push(k.Low)
+	s.value = StochasticValue{K: math.NaN(), D: math.NaN()}
+	if s.highs.len() < s.period {
+		return s.value
+	}
+	_, hh := s.highs.minMax()
+	ll, _ := s.lows.minMax()
+	if hh == ll {
+		s.value.K = 50
+	} else {
+		s.value.K = 100 * (k.Close - ll) / (hh - ll)
+	}
+	s.value.D = s.d.Add(s.value.K)
+	return s.value
+}
+
+// Value returns the latest reading.
+func (s *Stochastic) Value() StochasticValue {
+	return s.value
+}
+
+// Ready reports whether %D is available.
+func (s *Stochastic) Ready() bool {
+	return s.d.Ready()
+}
+
+// ComputeStochastic replays klines through a new stochastic oscillator.
+func ComputeStochastic(klines []exchange.Kline, kPeriod, dPeriod int) []StochasticValue {
+	s := NewStochastic(kPeriod, dPeriod)
+	out := make([]StochasticValue, len(klines))
+	for i, k := range klines {
+		out[i] = s.Update(k)
+	}
+	return out
+}
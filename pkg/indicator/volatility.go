@@ -0,0 +1,179 @@
+package indicator
+
+import (
+	"math"
+
+	"klineio/pkg/exchange"
+)
+
+// BandsValue is one Bollinger Bands reading.
+type BandsValue struct {
+	Upper  float64
+	Middle float64
+	Lower  float64
+}
+
+// Bollinger computes Bollinger Bands from an SMA and the population standard deviation of closes.
+type Bollinger struct {
+	sma   *SMA
+	win   *window
+	k     float64
+	value BandsValue
+}
+
+// NewBollinger creates Bollinger Bands k standard deviations wide, e.g. NewBollinger(20, 2).
+func NewBollinger(period int, k float64) *Bollinger {
+	return &Bollinger{sma: NewSMA(period), win: newWindow(period), k: k}
+}
+
+// Update feeds a candle's close. Fields are NaN during warm-up.
+func (b *Bollinger) Update(k exchange.Kline) BandsValue {
+	mid := b.sma.Add(k.Close)
+	b.win.push(k.Close)
+	if !b.sma.Ready() {
+		b.value = BandsValue{Upper: math.NaN(), Middle: math.NaN(), Lower: math.NaN()}
+		return b.value
+	}
+	sd := b.win.stdDev(mid, 0)
+	b.value = BandsValue{Upper: mid + b.k*sd, Middle: mid, Lower: mid - b.k*sd}
+	return b.value
+}
+
+// Value returns the latest reading.
+func (b *Bollinger) Value() BandsValue {
+	return b.value
+}
+
+// Ready reports whether period closes have been seen.
+func (b *Bollinger) Ready() bool {
+	return b.sma.Ready()
+}
+
+// ComputeBollinger replays klines through new Bollinger Bands.
+func ComputeBollinger(klines []exchange.Kline, period int, k float64) []BandsValue {
+	b := NewBollinger(period, k)
+	out := make([]BandsValue, len(klines))
+	for i, kl := range klines {
+		out[i] = b.Update(kl)
+	}
+	return out
+}
+
+// ATR is Wilder's average true range.
+type ATR struct {
+	period    int
+	count     int
+	prevClose float64
+	value     float64
+}
+
+// NewATR creates an ATR over period candles, e.g. NewATR(14).
+func NewATR(period int) *ATR {
+	return &ATR{period: period}
+}
+
+// Update feeds a candle.
+func (a *ATR) Update(k exchange.Kline) float64 {
+	tr := k.High - k.Low
+	if a.count > 0 {
+		tr = math.Max(tr, math.Max(math.Abs(k.High-a.prevClose), math.Abs(k.Low-a.prevClose)))
+	}
+	a.count++
+	if a.count <= a.period {
+		a.value += tr / float64(a.period)
+	} else {
+		a.value = (a.value*float64(a.period-1) + tr) / float64(a.period)
+	}
+	a.prevClose = k.Close
+	return a.Value()
+}
+
+// Value returns the current ATR, or NaN during warm-up.
+func (a *ATR) Value() float64 {
+	if !a.Ready() {
+		return math.NaN()
+	}
+	return a.value
+}
+
+// Ready reports whether period candles have been seen.
+func (a *ATR) Ready() bool {
+	return a.count >= a.period
+}
+
+// OBV is on-balance volume. It is ready from the first candle, which contributes zero.
+type OBV struct {
+	count     int
+	prevClose float64
+	value     float64
+}
+
+// NewOBV creates an OBV.
+func NewOBV() *OBV {
+	return &OBV{}
+}
+
+// Update feeds a candle.
+func (o *OBV) Update(k exchange.Kline) float64 {
+	if o.count > 0 {
+		switch {
+		case k.Close > o.prevClose:
+			o.value += k.Volume
+		case k.Close < o.prevClose:
+			o.value -= k.Volume
+		}
+	}
+	o.count++
+	o.prevClose = k.Close
+	return o.value
+}
+
+// Value returns the running OBV.
+func (o *OBV) Value() float64 {
+	return o.value
+}
+
+// Ready reports whether any candle has been seen.
+func (o *OBV) Ready() bool {
+	return o.count > 0
+}
+
+// Volatility is the rolling sample standard deviation of log returns of closes.
+// It is not annualised; multiply by sqrt(candles per year) if needed.
+type Volatility struct {
+	period    int
+	count     int
+	prevClose float64
+	win       *window
+	sma       *SMA
+}
+
+// NewVolatility creates a rolling volatility over period returns.
+func NewVolatility(period int) *Volatility {
+	return &Volatility{period: period, win: newWindow(period), sma: NewSMA(period)}
+}
+
+// Update feeds a candle's close.
+func (v *Volatility) Update(k exchange.Kline) float64 {
+	if v.count > 0 && v.prevClose > 0 && k.Close > 0 {
+		r := math.Log(k.Close / v.prevClose)
+		v.win.push(r)
+		v.sma.Add(r)
+	}
+	v.count++
+	v.prevClose = k.Close
+	return v.Value()
+}
+
+// Value returns the current volatility, or NaN during warm-up.
+func (v *Volatility) Value() float64 {
+	if !v.Ready() {
+		return math.NaN()
+	}
+	return v.win.stdDev(v.sma.Value(), 1)
+}
+
+// Ready reports whether period returns have been seen.
+func (v *Volatility) Ready() bool {
+	return v.period > 1 && v.sma.Ready()
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -110,21 +111,28 @@ func timeEncoder(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
 func (l *Logger) WithValue(ctx context.Context, fields ...zapcore.Field) context.Context {
 	if c, ok := ctx.(*gin.Context); ok {
 		ctx = c.Request.Context()
-		c.Request = c.Request.WithContext(context.WithValue(ctx, ctxLoggerKey, l.WithContext(ctx).With(fields...)))
+		c.Request = c.Request.WithContext(context.WithValue(ctx, ctxLoggerKey, l.fromContext(ctx).With(fields...)))
 		return c
 	}
-	return context.WithValue(ctx, ctxLoggerKey, l.WithContext(ctx).With(fields...))
+	return context.WithValue(ctx, ctxLoggerKey, l.fromContext(ctx).With(fields...))
 }
 
-// WithContext Returns a zap instance from the specified context
+// WithContext Returns a zap instance from the specified context, with the trace_id and span_id of its span if any
 func (l *Logger) WithContext(ctx context.Context) *Logger {
 	if c, ok := ctx.(*gin.Context); ok {
 		ctx = c.Request.Context()
 	}
-	zl := ctx.Value(ctxLoggerKey)
-	ctxLogger, ok := zl.(*zap.Logger)
-	if ok {
-		return &Logger{ctxLogger}
+	zl := l.fromContext(ctx)
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		zl = zl.With(zap.String("trace_id", sc.TraceID().String()), zap.String("span_id", sc.SpanID().String()))
 	}
-	return l
+	return &Logger{zl}
+}
+
+// fromContext returns the logger stored in ctx by WithValue, or l's
+func (l *Logger) fromContext(ctx context.Context) *zap.Logger {
+	if ctxLogger, ok := ctx.Value(ctxLoggerKey).(*zap.Logger); ok {
+		return ctxLogger
+	}
+	return l.Logger
 }
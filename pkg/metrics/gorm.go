@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const gormStartKey = "klineio:metrics_start"
+
+// GormPlugin records the latency of every GORM statement by operation, e.g. "query", and whether it
+// failed. Unlike logging, it works from the statement's callbacks and never renders the SQL.
+type GormPlugin struct{}
+
+func (p GormPlugin) Name() string {
+	return "klineio:metrics"
+}
+
+func (p GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("metrics:before_create", p.before),
+		cb.Create().After("gorm:create").Register("metrics:after_create", p.after("create")),
+		cb.Query().Before("gorm:query").Register("metrics:before_query", p.before),
+		cb.Query().After("gorm:query").Register("metrics:after_query", p.after("query")),
+		cb.Update().Before("gorm:update").Register("metrics:before_update", p.before),
+		cb.Update().After("gorm:update").Register("metrics:after_update", p.after("update")),
+		cb.Delete().Before("gorm:delete").Register("metrics:before_delete", p.before),
+		cb.Delete().After("gorm:delete").Register("metrics:after_delete", p.after("delete")),
+		cb.Row().Before("gorm:row").Register("metrics:before_row", p.before),
+		cb.Row().After("gorm:row").Register("metrics:after_row", p.after("row")),
+		cb.Raw().Before("gorm:raw").Register("metrics:before_raw", p.before),
+		cb.Raw().After("gorm:raw").Register("metrics:after_raw", p.after("raw")),
+	)
+}
+
+func (p GormPlugin) before(db *gorm.DB) {
+	db.InstanceSet(gormStartKey, time.Now())
+}
+
+func (p GormPlugin) after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(gormStartKey)
+		if !ok {
+			return
+		}
+		failed := db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound)
+		ObserveDBQuery(operation, failed, time.Since(v.(time.Time)))
+	}
+}
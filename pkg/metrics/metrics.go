@@ -0,0 +1,126 @@
+// Package metrics defines the Prometheus metrics klineio exposes on /metrics.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "klineio"
+
+var (
+	exchangeRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "exchange_requests_total",
+		Help:      "Requests to exchange APIs by endpoint and HTTP status code, or network_error.",
+	}, []string{"exchange", "endpoint", "code"})
+
+	exchangeRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "exchange_request_duration_seconds",
+		Help:      "Latency of exchange API requests.",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"exchange", "endpoint"})
+
+	exchangeAPIErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "exchange_api_errors_total",
+		Help:      "Error codes returned in exchange API response bodies.",
+	}, []string{"exchange", "endpoint", "code"})
+
+	jobRunDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "job_run_duration_seconds",
+		Help:      "Duration of scheduled job runs, such as the price monitor, by final status.",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800},
+	}, []string{"job", "status"})
+
+	jobItems = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "job_items_total",
+		Help:      "Items, such as symbols, processed by job runs, by result: succeeded or failed.",
+	}, []string{"job", "result"})
+
+	schedulerLag = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "scheduler_lag_seconds",
+		Help:      "Delay between the time a job was scheduled to run and the time it started.",
+		Buckets:   []float64{.01, .05, .1, .5, 1, 5, 15, 60},
+	}, []string{"job"})
+
+	alerts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "alerts_total",
+		Help:      "Alerts fired by kind and, for indicator rules and anomalies, rule.",
+	}, []string{"kind", "rule"})
+
+	notifierFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifier_failures_total",
+		Help:      "Messages a notifier failed to send.",
+	}, []string{"notifier"})
+
+	dbQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Latency of database statements by GORM operation, and whether they failed.",
+		Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 5},
+	}, []string{"operation", "error"})
+)
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveExchangeRequest records a request to endpoint, the URL path, that ended with code.
+func ObserveExchangeRequest(exchange, endpoint, code string, elapsed time.Duration) {
+	exchangeRequests.WithLabelValues(exchange, endpoint, code).Inc()
+	exchangeRequestDuration.WithLabelValues(exchange, endpoint).Observe(elapsed.Seconds())
+}
+
+// ExchangeAPIError records an error code an exchange returned in the body of a response.
+func ExchangeAPIError(exchange, endpoint, code string) {
+	exchangeAPIErrors.WithLabelValues(exchange, endpoint, code).Inc()
+}
+
+// ObserveJobRun records a finished job run and the items it processed.
+func ObserveJobRun(job, status string, elapsed time.Duration, succeeded, failed int64) {
+	jobRunDuration.WithLabelValues(job, status).Observe(elapsed.Seconds())
+	jobItems.WithLabelValues(job, "succeeded").Add(float64(succeeded))
+	jobItems.WithLabelValues(job, "failed").Add(float64(failed))
+}
+
+// ObserveSchedulerLag records how late a job started.
+func ObserveSchedulerLag(job string, lag time.Duration) {
+	if lag < 0 {
+		lag = 0
+	}
+	schedulerLag.WithLabelValues(job).Observe(lag.Seconds())
+}
+
+// AlertFired records an alert.
+func AlertFired(kind, rule string) {
+	alerts.WithLabelValues(kind, rule).Inc()
+}
+
+// NotifierFailed records a message notifier failed to send.
+func NotifierFailed(notifier string) {
+	notifierFailures.WithLabelValues(notifier).Inc()
+}
+
+// ObserveDBQuery records a database statement by its GORM operation, such as query or create.
+func ObserveDBQuery(operation string, failed bool, elapsed time.Duration) {
+	dbQueryDuration.WithLabelValues(operation, boolLabel(failed)).Observe(elapsed.Seconds())
+}
+
+func boolLabel(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// dbQueryCount returns the number of statements recorded for operation and error.
+func dbQueryCount(t *testing.T, operation, failed string) uint64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range families {
+		if f.GetName() != namespace+"_db_query_duration_seconds" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			labels := make(map[string]string)
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["operation"] == operation && labels["error"] == failed {
+				return m.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return 0
+}
+
+func TestGormPlugin(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(GormPlugin{}); err != nil {
+		t.Fatal(err)
+	}
+	type price struct {
+		ID     uint
+		Symbol string
+	}
+	if err := db.AutoMigrate(&price{}); err != nil {
+		t.Fatal(err)
+	}
+	created, queried, failed := dbQueryCount(t, "create", "false"), dbQueryCount(t, "query", "false"), dbQueryCount(t, "query", "true")
+
+	db.Create(&price{Symbol: "BTCUSDT"})
+	var got price
+	db.Where("symbol = ?", "ETHUSDT").First(&got) // Not found is not a failure
+	db.Table("missing").Count(new(int64))
+
+	if n := dbQueryCount(t, "create", "false") - created; n != 1 {
+		t.Errorf("expected 1 create, got %d", n)
+	}
+	if n := dbQueryCount(t, "query", "false") - queried; n != 1 {
+		t.Errorf("expected 1 successful query, got %d", n)
+	}
+	if n := dbQueryCount(t, "query", "true") - failed; n != 1 {
+		t.Errorf("expected 1 failed query, got %d", n)
+	}
+}
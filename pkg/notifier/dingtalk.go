@@ -9,6 +9,11 @@ import (
 	"time"
 
 	"klineio/pkg/log"
+	"klineio/pkg/metrics"
+	"klineio/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // DingTalkNotifier sends messages to DingTalk.
@@ -29,51 +34,38 @@ func NewDingTalkNotifier(webhookURL string, logger *log.Logger) *DingTalkNotifie
 
 // SendMarkdownMessage sends a markdown message to DingTalk.
 func (d *DingTalkNotifier) SendMarkdownMessage(ctx context.Context, title, text string) error {
-	msg := map[string]interface{}{
+	return d.send(ctx, map[string]interface{}{
 		"msgtype": "markdown",
 		"markdown": map[string]string{
 			"title": title,
 			"text":  text,
 		},
-	}
-
-	jsonBody, err := json.Marshal(msg)
-	if err != nil {
-		return fmt.Errorf("failed to marshal dingtalk message: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", d.webhookURL, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return fmt.Errorf("failed to create dingtalk request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	res, err := d.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send dingtalk request: %w", err)
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf("dingtalk API returned non-OK status: %s", res.Status)
-	}
-
-	// Optionally read and log response body for debugging
-	// body, _ := ioutil.ReadAll(res.Body)
-	// d.logger.Debug("DingTalk response", zap.ByteString("body", body))
-
-	return nil
+	})
 }
 
 // SendTextMessage sends a plain text message to DingTalk.
 func (d *DingTalkNotifier) SendTextMessage(ctx context.Context, text string) error {
-	msg := map[string]interface{}{
+	return d.send(ctx, map[string]interface{}{
 		"msgtype": "text",
 		"text": map[string]string{
 			"content": text,
 		},
+	})
+}
+
+// send posts msg to the webhook in a span, counting failures in the notifier metrics.
+func (d *DingTalkNotifier) send(ctx context.Context, msg map[string]interface{}) error {
+	ctx, span := tracing.Start(ctx, "dingtalk.send", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("notifier.msgtype", fmt.Sprint(msg["msgtype"]))))
+	err := d.post(ctx, msg)
+	if err != nil {
+		metrics.NotifierFailed("dingtalk")
 	}
+	tracing.End(span, err)
+	return err
+}
 
+func (d *DingTalkNotifier) post(ctx context.Context, msg map[string]interface{}) error {
 	jsonBody, err := json.Marshal(msg)
 	if err != nil {
 		return fmt.Errorf("failed to marshal dingtalk message: %w", err)
@@ -95,5 +87,9 @@ func (d *DingTalkNotifier) SendTextMessage(ctx context.Context, text string) err
 		return fmt.Errorf("dingtalk API returned non-OK status: %s", res.Status)
 	}
 
+	// Optionally read and log response body for debugging
+	// body, _ := ioutil.ReadAll(res.Body)
+	// d.logger.Debug("DingTalk response", zap.ByteString("body", body))
+
 	return nil
 } 
\ No newline at end of file
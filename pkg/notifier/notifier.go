@@ -0,0 +1,8 @@
+package notifier
+
+import "context"
+
+// Notifier delivers markdown messages to a channel, such as a DingTalk group.
+type Notifier interface {
+	SendMarkdownMessage(ctx context.Context, title, text string) error
+}
@@ -0,0 +1,85 @@
+package resample
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"klineio/pkg/exchange"
+)
+
+// maxFallbackFetch caps the number of finer candles requested when resampling on the fly, for
+// clients that do not report their own limit through exchange.KlineLimiter.
+const maxFallbackFetch = 1000
+
+// ErrShortHistory is returned when one request cannot return the requested number of candles,
+// natively or resampled from finer ones.
+var ErrShortHistory = errors.New("not enough candles to resample")
+
+// FetchKlines returns the latest limit candles of interval from client. If the exchange does not
+// offer interval natively, it fetches the longest supported interval that divides it evenly and
+// resamples in UTC, returning ErrShortHistory if fewer than limit candles come out or limit is
+// beyond what the exchange returns per request. Calendar
+// intervals (months) cannot be resampled and return exchange.ErrUnsupportedInterval.
+func FetchKlines(ctx context.Context, client exchange.ExchangeClient, symbol string, interval exchange.Interval, limit int) ([]exchange.Kline, error) {
+	if client.SupportsInterval(interval) {
+		if limiter, ok := client.(exchange.KlineLimiter); ok && limit > limiter.MaxKlines() {
+			return nil, fmt.Errorf("%w: %d %s candles requested, at most %d per request", ErrShortHistory, limit, interval, limiter.MaxKlines())
+		}
+		return client.GetKlines(ctx, symbol, interval, limit)
+	}
+
+	base, ok := fallbackBase(client, interval)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s, and no finer interval to resample from", exchange.ErrUnsupportedInterval, interval)
+	}
+	ratio := int(interval.Duration() / base.Duration())
+	maxFetch := maxFallbackFetch
+	if limiter, ok := client.(exchange.KlineLimiter); ok {
+		maxFetch = limiter.MaxKlines()
+	}
+	src, err := client.GetKlines(ctx, symbol, base, min((limit+1)*ratio, maxFetch))
+	if err != nil {
+		return nil, err
+	}
+	out, err := Klines(src, interval.Duration(), Options{Location: time.UTC})
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%w: no %s candles from %d %s candles", ErrShortHistory, interval, len(src), base)
+	}
+
+	// The fetch window usually starts mid-bucket, which leaves the oldest candle incomplete.
+	earliest := src[0].OpenTime
+	for _, k := range src {
+		if k.OpenTime.Before(earliest) {
+			earliest = k.OpenTime
+		}
+	}
+	if earliest.After(out[0].OpenTime) {
+		out = out[1:]
+	}
+	if len(out) < limit {
+		return nil, fmt.Errorf("%w: %d of %d %s candles from %d %s candles", ErrShortHistory, len(out), limit, interval, len(src), base)
+	}
+	return out[len(out)-limit:], nil
+}
+
+// fallbackBase picks the longest interval the client supports that evenly divides interval.
+func fallbackBase(client exchange.ExchangeClient, interval exchange.Interval) (exchange.Interval, bool) {
+	if interval.IsCalendar() {
+		return "", false
+	}
+	target := interval.Duration()
+	for i := len(exchange.Intervals) - 1; i >= 0; i-- {
+		base := exchange.Intervals[i]
+		d := base.Duration()
+		if base.IsCalendar() || d >= target || target%d != 0 || !client.SupportsInterval(base) {
+			continue
+		}
+		return base, true
+	}
+	return "", false
+}
@@ -0,0 +1,97 @@
+package resample
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"klineio/pkg/exchange"
+)
+
+// fakeClient serves hourly candles and supports only the intervals listed.
+type fakeClient struct {
+	supported map[exchange.Interval]bool
+	requested []exchange.Interval
+	limits    []int
+	maxKlines int
+}
+
+func (f *fakeClient) MaxKlines() int {
+	return f.maxKlines
+}
+
+func (f *fakeClient) GetLatestPrice(ctx context.Context, symbol string) (float64, error) {
+	return 0, nil
+}
+
+func (f *fakeClient) GetKlines(ctx context.Context, symbol string, interval exchange.Interval, limit int) ([]exchange.Kline, error) {
+	f.requested = append(f.requested, interval)
+	f.limits = append(f.limits, limit)
+	// 13 hourly candles ending at 12:00, so the window starts mid-bucket for 4h.
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var out []exchange.Kline
+	for i := 0; i < 13; i++ {
+		open := start.Add(time.Duration(i) * time.Hour)
+		out = append(out, exchange.Kline{OpenTime: open, Open: 1, High: 2, Low: 0.5, Close: 1, Volume: 1, CloseTime: exchange.Interval1h.CloseTime(open)})
+	}
+	return out[len(out)-min(limit, len(out)):], nil
+}
+
+func (f *fakeClient) GetTopVolumeTickers(ctx context.Context, limit int) ([]exchange.Ticker, error) {
+	return nil, nil
+}
+
+func (f *fakeClient) GetOrderBook(ctx context.Context, symbol string, depth int) (*exchange.OrderBook, error) {
+	return nil, nil
+}
+
+func (f *fakeClient) GetTradesAfter(ctx context.Context, symbol string, afterID int64, limit int) ([]exchange.Trade, error) {
+	return nil, nil
+}
+
+func (f *fakeClient) SupportsInterval(interval exchange.Interval) bool {
+	return f.supported[interval]
+}
+
+func TestFetchKlinesFallback(t *testing.T) {
+	client := &fakeClient{supported: map[exchange.Interval]bool{exchange.Interval1h: true, exchange.Interval1M: true}, maxKlines: 1000}
+
+	got, err := FetchKlines(context.Background(), client, "BTCUSDT", exchange.Interval4h, 2)
+	if err != nil {
+		t.Fatalf("FetchKlines error: %v", err)
+	}
+	if client.requested[0] != exchange.Interval1h {
+		t.Errorf("expected fallback to 1h, requested %v", client.requested)
+	}
+	// The partial 00:00 bucket is dropped and the last two buckets (08:00 and the in-progress 12:00) are kept.
+	if len(got) != 2 || !got[0].OpenTime.Equal(time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)) || got[1].Volume != 1 {
+		t.Errorf("unexpected resampled candles: %+v", got)
+	}
+
+	if _, err := FetchKlines(context.Background(), client, "BTCUSDT", exchange.Interval3M, 2); !errors.Is(err, exchange.ErrUnsupportedInterval) {
+		t.Errorf("expected ErrUnsupportedInterval for 3M, got %v", err)
+	}
+}
+
+func TestFetchKlinesShortHistory(t *testing.T) {
+	client := &fakeClient{supported: map[exchange.Interval]bool{exchange.Interval1h: true}, maxKlines: 8}
+
+	// Three 4h candles need 16 hourly ones, but the client returns at most 8
+	_, err := FetchKlines(context.Background(), client, "BTCUSDT", exchange.Interval4h, 3)
+	if !errors.Is(err, ErrShortHistory) {
+		t.Errorf("expected ErrShortHistory, got %v", err)
+	}
+	if client.limits[0] != 8 {
+		t.Errorf("expected the request capped at the client's 8 candles, got %d", client.limits[0])
+	}
+
+	// Native candles are not requested beyond the client's limit either
+	_, err = FetchKlines(context.Background(), client, "BTCUSDT", exchange.Interval1h, 9)
+	if !errors.Is(err, ErrShortHistory) {
+		t.Errorf("expected ErrShortHistory for 9 native candles, got %v", err)
+	}
+	if len(client.limits) != 1 {
+		t.Errorf("expected no request beyond the client's limit, got %v", client.limits)
+	}
+}
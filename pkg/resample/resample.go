@@ -0,0 +1,178 @@
+// Package resample aggregates K-lines and price ticks into higher timeframes.
+//
+// Buckets are aligned to calendar boundaries in a configurable time zone:
+// intraday periods are aligned to local midnight, multi-day periods to the
+// Unix epoch date, and week multiples to Monday 00:00. This lets any timeframe
+// be derived from 1m candles regardless of what an exchange offers natively.
+package resample
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"klineio/pkg/exchange"
+)
+
+const (
+	day  = 24 * time.Hour
+	week = 7 * day
+)
+
+// Options controls how buckets are built.
+type Options struct {
+	// Location is the time zone buckets are aligned in. Nil means UTC.
+	Location *time.Location
+	// FillGaps emits flat candles (open=high=low=close=previous close, zero volume)
+	// for buckets that have no source data instead of skipping them.
+	FillGaps bool
+	// DropPartial drops the last bucket if the source data does not reach its end.
+	DropPartial bool
+}
+
+// Tick is a single price observation.
+type Tick struct {
+	Time   time.Time
+	Price  float64
+	Volume float64
+}
+
+// BucketStart returns the start of the period-long bucket containing t in loc.
+// period must divide a day or be a whole number of days.
+func BucketStart(t time.Time, period time.Duration, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+	y, m, d := t.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, loc)
+
+	switch {
+	case period%week == 0:
+		// 1970-01-05 was a Monday.
+		days := civilDays(y, m, d) - 4
+		return midnight.AddDate(0, 0, -int(mod(days, int64(period/day))))
+	case period%day == 0:
+		days := civilDays(y, m, d)
+		return midnight.AddDate(0, 0, -int(mod(days, int64(period/day))))
+	default:
+		elapsed := t.Sub(midnight)
+		return midnight.Add(elapsed - elapsed%period)
+	}
+}
+
+// bucketEnd returns the start of the bucket following the one that starts at start.
+func bucketEnd(start time.Time, period time.Duration) time.Time {
+	if period%day == 0 {
+		// Step in calendar days so that DST changes do not shift the boundary.
+		return start.AddDate(0, 0, int(period/day))
+	}
+	return start.Add(period)
+}
+
+// Klines aggregates source klines into period-long candles. Source candles are assigned to
+// buckets by their open time; they may be unsorted and must be shorter than period.
+func Klines(src []exchange.Kline, period time.Duration, opts Options) ([]exchange.Kline, error) {
+	if period <= 0 || (period < day && day%period != 0) || (period > day && period%day != 0) {
+		return nil, fmt.Errorf("invalid resample period %s: must divide a day or be a whole number of days", period)
+	}
+	if len(src) == 0 {
+		return nil, nil
+	}
+	sorted := make([]exchange.Kline, len(src))
+	copy(sorted, src)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].OpenTime.Before(sorted[j].OpenTime)
+	})
+
+	var out []exchange.Kline
+	var cur *exchange.Kline
+	var curEnd time.Time
+	flush := func() {
+		if cur != nil {
+			out = append(out, *cur)
+		}
+	}
+
+	for _, k := range sorted {
+		start := BucketStart(k.OpenTime, period, opts.Location)
+		if cur != nil && start.Equal(cur.OpenTime) {
+			cur.High = max(cur.High, k.High)
+			cur.Low = min(cur.Low, k.Low)
+			cur.Close = k.Close
+			cur.Volume += k.Volume
+			continue
+		}
+		flush()
+		if opts.FillGaps && cur != nil {
+			out = append(out, flatCandles(curEnd, start, cur.Close, period)...)
+		}
+		curEnd = bucketEnd(start, period)
+		cur = &exchange.Kline{
+			OpenTime:  start,
+			Open:      k.Open,
+			High:      k.High,
+			Low:       k.Low,
+			Close:     k.Close,
+			Volume:    k.Volume,
+			CloseTime: curEnd.Add(-time.Millisecond),
+		}
+	}
+	flush()
+
+	last := sorted[len(sorted)-1]
+	if opts.DropPartial && last.CloseTime.Before(out[len(out)-1].CloseTime) {
+		out = out[:len(out)-1]
+	}
+	return out, nil
+}
+
+// Ticks aggregates price ticks into period-long candles. Volumes are summed per bucket.
+func Ticks(ticks []Tick, period time.Duration, opts Options) ([]exchange.Kline, error) {
+	src := make([]exchange.Kline, len(ticks))
+	for i, t := range ticks {
+		src[i] = exchange.Kline{
+			OpenTime:  t.Time,
+			Open:      t.Price,
+			High:      t.Price,
+			Low:       t.Price,
+			Close:     t.Price,
+			Volume:    t.Volume,
+			CloseTime: t.Time,
+		}
+	}
+	// A tick has no duration, so completeness cannot be judged from it.
+	opts.DropPartial = false
+	return Klines(src, period, opts)
+}
+
+// flatCandles builds gap-filling candles for every bucket in [from, to).
+func flatCandles(from, to time.Time, price float64, period time.Duration) []exchange.Kline {
+	var out []exchange.Kline
+	for start := from; start.Before(to); {
+		end := bucketEnd(start, period)
+		out = append(out, exchange.Kline{
+			OpenTime:  start,
+			Open:      price,
+			High:      price,
+			Low:       price,
+			Close:     price,
+			CloseTime: end.Add(-time.Millisecond),
+		})
+		start = end
+	}
+	return out
+}
+
+// civilDays returns the number of days from 1970-01-01 to the given calendar date.
+func civilDays(y int, m time.Month, d int) int64 {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / int64(day/time.Second)
+}
+
+func mod(a, b int64) int64 {
+	r := a % b
+	if r < 0 {
+		r += b
+	}
+	return r
+}
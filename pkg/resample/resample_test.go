@@ -0,0 +1,108 @@
+package resample
+
+import (
+	"testing"
+	"time"
+
+	"klineio/pkg/exchange"
+)
+
+func minuteKlines(start time.Time, closes ...float64) []exchange.Kline {
+	klines := make([]exchange.Kline, len(closes))
+	for i, c := range closes {
+		open := start.Add(time.Duration(i) * time.Minute)
+		klines[i] = exchange.Kline{
+			OpenTime:  open,
+			Open:      c - 0.5,
+			High:      c + 1,
+			Low:       c - 1,
+			Close:     c,
+			Volume:    1,
+			CloseTime: open.Add(time.Minute - time.Millisecond),
+		}
+	}
+	return klines
+}
+
+func TestBucketStart(t *testing.T) {
+	ts := time.Date(2024, 3, 6, 13, 47, 12, 0, time.UTC) // A Wednesday
+	tests := []struct {
+		period time.Duration
+		want   time.Time
+	}{
+		{5 * time.Minute, time.Date(2024, 3, 6, 13, 45, 0, 0, time.UTC)},
+		{4 * time.Hour, time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)},
+		{day, time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC)},
+		{week, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := BucketStart(ts, tt.period, nil); !got.Equal(tt.want) {
+			t.Errorf("BucketStart(%s) = %s, want %s", tt.period, got, tt.want)
+		}
+	}
+
+	shanghai := time.FixedZone("UTC+8", 8*3600)
+	if got := BucketStart(ts, day, shanghai); !got.Equal(time.Date(2024, 3, 5, 16, 0, 0, 0, time.UTC)) {
+		t.Errorf("daily bucket in UTC+8 = %s, want 2024-03-05 16:00 UTC", got.UTC())
+	}
+}
+
+func TestKlines(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	src := minuteKlines(start, 10, 11, 12, 9, 13, 14, 15)
+
+	got, err := Klines(src, 5*time.Minute, Options{})
+	if err != nil {
+		t.Fatalf("Klines error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 candles, got %d", len(got))
+	}
+	first := got[0]
+	if first.Open != 9.5 || first.High != 14 || first.Low != 8 || first.Close != 13 || first.Volume != 5 {
+		t.Errorf("unexpected first candle: %+v", first)
+	}
+	if !first.CloseTime.Equal(start.Add(5*time.Minute - time.Millisecond)) {
+		t.Errorf("unexpected close time %s", first.CloseTime)
+	}
+
+	got, _ = Klines(src, 5*time.Minute, Options{DropPartial: true})
+	if len(got) != 1 {
+		t.Errorf("partial bucket should be dropped, got %d candles", len(got))
+	}
+
+	if _, err := Klines(src, 7*time.Minute, Options{}); err == nil {
+		t.Errorf("expected error for a period that does not divide a day")
+	}
+}
+
+func TestKlinesFillGaps(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	src := append(minuteKlines(start, 10), minuteKlines(start.Add(15*time.Minute), 12)...)
+
+	got, _ := Klines(src, 5*time.Minute, Options{})
+	if len(got) != 2 {
+		t.Errorf("gaps should be skipped by default, got %d candles", len(got))
+	}
+
+	got, _ = Klines(src, 5*time.Minute, Options{FillGaps: true})
+	if len(got) != 4 {
+		t.Fatalf("expected 4 candles with gaps filled, got %d", len(got))
+	}
+	if got[1].Close != 10 || got[1].Volume != 0 || !got[2].OpenTime.Equal(start.Add(10*time.Minute)) {
+		t.Errorf("unexpected filler candles: %+v", got[1:3])
+	}
+}
+
+func TestTicks(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ticks := []Tick{
+		{Time: base.Add(25 * time.Hour), Price: 105},
+		{Time: base.Add(1 * time.Hour), Price: 100},
+		{Time: base.Add(23 * time.Hour), Price: 98},
+	}
+	got, _ := Ticks(ticks, day, Options{})
+	if len(got) != 2 || got[0].Open != 100 || got[0].Close != 98 || got[0].Low != 98 || got[1].Close != 105 {
+		t.Errorf("unexpected daily candles: %+v", got)
+	}
+}
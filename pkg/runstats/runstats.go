@@ -0,0 +1,42 @@
+// Package runstats counts the items a job run processed, through its context, so that services
+// can report progress without knowing which job called them.
+package runstats
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type ctxKey struct{}
+
+// Counters are the outcomes counted during one run.
+type Counters struct {
+	succeeded atomic.Int64
+	failed    atomic.Int64
+}
+
+// NewContext returns a context that counts outcomes into the returned Counters.
+func NewContext(ctx context.Context) (context.Context, *Counters) {
+	c := &Counters{}
+	return context.WithValue(ctx, ctxKey{}, c), c
+}
+
+// Succeeded counts an item processed successfully. It does nothing if ctx does not count.
+func Succeeded(ctx context.Context) {
+	if c, ok := ctx.Value(ctxKey{}).(*Counters); ok {
+		c.succeeded.Add(1)
+	}
+}
+
+// Failed counts an item that could not be processed. It does nothing if ctx does not count.
+func Failed(ctx context.Context) {
+	if c, ok := ctx.Value(ctxKey{}).(*Counters); ok {
+		c.failed.Add(1)
+	}
+}
+
+// Succeeded returns the number of items processed successfully.
+func (c *Counters) Succeeded() int64 { return c.succeeded.Load() }
+
+// Failed returns the number of items that could not be processed.
+func (c *Counters) Failed() int64 { return c.failed.Load() }
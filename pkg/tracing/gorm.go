@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "klineio:span"
+
+// GormPlugin traces every GORM statement as a child of the span in the statement's context. Spans are
+// named after the operation, e.g. "gorm.query", and record the table, the SQL with placeholders in
+// place of the values, and the rows affected.
+type GormPlugin struct {
+	System string // Database system, such as mysql, recorded as db.system
+}
+
+func (p GormPlugin) Name() string {
+	return "klineio:tracing"
+}
+
+func (p GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("tracing:before_create", p.before("gorm.create")),
+		cb.Create().After("gorm:create").Register("tracing:after_create", p.after),
+		cb.Query().Before("gorm:query").Register("tracing:before_query", p.before("gorm.query")),
+		cb.Query().After("gorm:query").Register("tracing:after_query", p.after),
+		cb.Update().Before("gorm:update").Register("tracing:before_update", p.before("gorm.update")),
+		cb.Update().After("gorm:update").Register("tracing:after_update", p.after),
+		cb.Delete().Before("gorm:delete").Register("tracing:before_delete", p.before("gorm.delete")),
+		cb.Delete().After("gorm:delete").Register("tracing:after_delete", p.after),
+		cb.Row().Before("gorm:row").Register("tracing:before_row", p.before("gorm.row")),
+		cb.Row().After("gorm:row").Register("tracing:after_row", p.after),
+		cb.Raw().Before("gorm:raw").Register("tracing:before_raw", p.before("gorm.raw")),
+		cb.Raw().After("gorm:raw").Register("tracing:after_raw", p.after),
+	)
+}
+
+func (p GormPlugin) before(name string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx, span := Start(db.Statement.Context, name, trace.WithSpanKind(trace.SpanKindClient))
+		span.SetAttributes(attribute.String("db.system", p.System))
+		db.Statement.Context = ctx
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func (p GormPlugin) after(db *gorm.DB) {
+	v, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span := v.(trace.Span)
+	span.SetAttributes(
+		attribute.String("db.sql.table", db.Statement.Table),
+		attribute.String("db.statement", db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	err := db.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	End(span, err)
+}
@@ -0,0 +1,89 @@
+// Package tracing sets up OpenTelemetry tracing: the global tracer provider exporting over OTLP and
+// W3C trace context propagation.
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"klineio/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// instrumentationName names the tracer of the spans klineio creates itself.
+const instrumentationName = "klineio"
+
+// Setup installs the W3C traceparent and baggage propagators and, if tracing.enabled is set, a tracer
+// provider exporting to tracing.endpoint over OTLP/HTTP. Spans are sampled at tracing.sample_ratio,
+// 1 by default, unless the parent was sampled by the caller. The returned function flushes the
+// spans not exported yet.
+//
+// serviceName is used unless tracing.service_name is set.
+func Setup(conf *viper.Viper, logger *log.Logger, serviceName string) (func(), error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if !conf.GetBool("tracing.enabled") {
+		return func() {}, nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(conf.GetString("tracing.endpoint"))}
+	if conf.GetBool("tracing.insecure") {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	if name := conf.GetString("tracing.service_name"); name != "" {
+		serviceName = name
+	}
+	ratio := 1.0
+	if conf.IsSet("tracing.sample_ratio") {
+		ratio = conf.GetFloat64("tracing.sample_ratio")
+	}
+
+	provider := NewProvider(sdktrace.NewBatchSpanProcessor(exporter), serviceName, ratio)
+	otel.SetTracerProvider(provider)
+	logger.Info("Tracing enabled", zap.String("endpoint", conf.GetString("tracing.endpoint")), zap.Float64("sample_ratio", ratio))
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			logger.Warn("Failed to flush traces", zap.Error(err))
+		}
+	}, nil
+}
+
+// NewProvider creates a tracer provider sending spans to processor. Tests pass an in-process exporter
+// wrapped in sdktrace.NewSimpleSpanProcessor and install the provider with otel.SetTracerProvider.
+func NewProvider(processor sdktrace.SpanProcessor, serviceName string, ratio float64) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+}
+
+// Start starts a span named name as a child of the span in ctx, using the global tracer provider.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// End records err, if any, on span and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,120 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"klineio/pkg/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// newTestExporter installs a tracer provider recording every span in the returned exporter.
+func newTestExporter(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := NewProvider(sdktrace.NewSimpleSpanProcessor(exporter), "klineio-test", 1)
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return exporter
+}
+
+func TestGormPlugin(t *testing.T) {
+	exporter := newTestExporter(t)
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(GormPlugin{System: "sqlite"}); err != nil {
+		t.Fatal(err)
+	}
+	type price struct {
+		ID     uint
+		Symbol string
+	}
+	if err := db.AutoMigrate(&price{}); err != nil {
+		t.Fatal(err)
+	}
+	exporter.Reset()
+
+	ctx, parent := Start(context.Background(), "job price_monitor")
+	db.WithContext(ctx).Create(&price{Symbol: "BTCUSDT"})
+	var got price
+	db.WithContext(ctx).Where("symbol = ?", "BTCUSDT").First(&got)
+	db.WithContext(ctx).Table("missing").Count(new(int64))
+	parent.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 4 {
+		t.Fatalf("expected 3 query spans and the parent, got %d", len(spans))
+	}
+	for i, name := range []string{"gorm.create", "gorm.query", "gorm.query"} {
+		span := spans[i]
+		if span.Name != name || span.Parent.SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("span %d: expected %s under the parent, got %s under %s", i, name, span.Name, span.Parent.SpanID())
+		}
+	}
+	if spans[2].Status.Code != codes.Error {
+		t.Errorf("expected the query on a missing table to fail the span, got %v", spans[2].Status)
+	}
+}
+
+// TestPropagation follows a request through the gin middleware and an outgoing call, as an API request
+// reaching an exchange would: both share the caller's trace, and so do the logs written on the way.
+func TestPropagation(t *testing.T) {
+	exporter := newTestExporter(t)
+	if _, err := Setup(viper.New(), &log.Logger{Logger: zap.NewNop()}, "klineio-test"); err != nil {
+		t.Fatal(err)
+	}
+
+	var outgoing string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outgoing = r.Header.Get("traceparent")
+	}))
+	defer upstream.Close()
+	client := &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+
+	core, logs := observer.New(zap.InfoLevel)
+	logger := &log.Logger{Logger: zap.New(core)}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(otelgin.Middleware("klineio"))
+	engine.GET("/prices", func(ctx *gin.Context) {
+		logger.WithContext(ctx).Info("fetching")
+		req, _ := http.NewRequestWithContext(ctx.Request.Context(), "GET", upstream.URL, nil)
+		if res, err := client.Do(req); err == nil {
+			res.Body.Close()
+		}
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest("GET", "/prices", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	for _, span := range exporter.GetSpans() {
+		if span.SpanContext.TraceID().String() != traceID {
+			t.Errorf("span %s is not in the caller's trace", span.Name)
+		}
+	}
+	if len(exporter.GetSpans()) != 2 || len(outgoing) != 55 || outgoing[3:35] != traceID {
+		t.Errorf("expected the server and client spans and the trace in the outgoing traceparent, got %d spans and %q", len(exporter.GetSpans()), outgoing)
+	}
+	if entries := logs.All(); len(entries) != 1 || entries[0].ContextMap()["trace_id"] != traceID {
+		t.Errorf("expected the log to carry the trace ID, got %+v", entries)
+	}
+}
@@ -55,32 +55,37 @@ func (l *gormLogger) Error(ctx context.Context, msg string, data ...interface{})
 	}
 }
 
+// Trace logs failed and slow queries, and every query at Info level. The SQL is only rendered for
+// queries that are logged.
 func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
 	if l.LogLevel <= logger.Silent {
 		return
 	}
 
 	elapsed := time.Since(begin)
-	sql, rows := fc()
-	fields := []zap.Field{
-		zap.String("sql", sql),
-		zap.Duration("elapsed", elapsed),
-		zap.Int64("rows", rows),
-		// zap.String("line", utils.FileWithLine()), // Temporarily comment out due to import issue
+	fields := func() []zap.Field {
+		sql, rows := fc()
+		return []zap.Field{
+			zap.String("sql", sql),
+			zap.Duration("elapsed", elapsed),
+			zap.Int64("rows", rows),
+			// zap.String("line", utils.FileWithLine()), // Temporarily comment out due to import issue
+		}
 	}
 
 	if err != nil {
 		if l.IgnoreRecordNotFoundError && err == gorm.ErrRecordNotFound {
-			l.pkgLogger.WithContext(ctx).Logger.Debug("record not found", fields...)
+			if log := l.pkgLogger.WithContext(ctx).Logger; log.Core().Enabled(zap.DebugLevel) {
+				log.Debug("record not found", fields()...)
+			}
 			return
 		}
 		if l.LogLevel >= logger.Error {
-			fields = append(fields, zap.Error(err))
-			l.pkgLogger.WithContext(ctx).Logger.Error("trace", fields...)
+			l.pkgLogger.WithContext(ctx).Logger.Error("trace", append(fields(), zap.Error(err))...)
 		}
 	} else if elapsed > l.SlowThreshold && l.SlowThreshold != 0 && l.LogLevel >= logger.Warn {
-		l.pkgLogger.WithContext(ctx).Logger.Warn("trace", fields...)
+		l.pkgLogger.WithContext(ctx).Logger.Warn("trace", fields()...)
 	} else if l.LogLevel >= logger.Info {
-		l.pkgLogger.WithContext(ctx).Logger.Info("trace", fields...)
+		l.pkgLogger.WithContext(ctx).Logger.Info("trace", fields()...)
 	}
 }
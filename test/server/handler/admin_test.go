@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"klineio/internal/middleware"
+)
+
+func TestAdminAuth(t *testing.T) {
+	conf := viper.New()
+	conf.Set("security.admin_user_ids", []string{"admin"})
+
+	r := gin.New()
+	r.Use(middleware.StrictAuth(jwt, logger), middleware.AdminAuth(conf, logger))
+	r.POST("/jobs/:name/trigger", func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	// The token of an ordinary user is valid, but not enough for admin routes
+	newHttpExcept(t, r).POST("/jobs/price_monitor/trigger").
+		WithHeader("Authorization", "Bearer "+genToken(t)).
+		Expect().
+		Status(http.StatusForbidden)
+
+	token, err := jwt.GenToken("admin", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newHttpExcept(t, r).POST("/jobs/price_monitor/trigger").
+		WithHeader("Authorization", "Bearer "+token).
+		Expect().
+		Status(http.StatusOK)
+}